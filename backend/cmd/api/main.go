@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/sipodi/backend/internal/config"
 	"github.com/sipodi/backend/internal/database"
+	"github.com/sipodi/backend/internal/domain"
 	"github.com/sipodi/backend/internal/handler"
+	"github.com/sipodi/backend/internal/mailer"
 	"github.com/sipodi/backend/internal/middleware"
 	"github.com/sipodi/backend/internal/repository"
 	"github.com/sipodi/backend/internal/router"
@@ -22,6 +26,9 @@ import (
 func main() {
 	// Load config
 	cfg := config.Load()
+	if level := domain.CompetitionLevel(cfg.Verification.EndorsementLevel); level != "none" && !level.IsValid() {
+		log.Fatalf("Invalid VERIFICATION_ENDORSEMENT_LEVEL %q: use kota, provinsi, nasional, internasional or none", level)
+	}
 
 	// Connect to database
 	db, err := database.NewPostgresPool(cfg.Database)
@@ -38,21 +45,71 @@ func main() {
 	}
 	log.Println("Connected to MinIO")
 
+	// Initialize mailer
+	mail := mailer.New(cfg.Mail)
+	emailTemplates, err := mailer.NewRenderer()
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	schoolRepo := repository.NewSchoolRepository(db)
 	tokenRepo := repository.NewTokenRepository(db)
 	talentRepo := repository.NewTalentRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
+	registrationRepo := repository.NewRegistrationRepository(db)
+	emailVerificationRepo := repository.NewEmailVerificationRepository(db)
+	securityRepo := repository.NewSecurityRepository(db)
+	talentHistoryRepo := repository.NewTalentHistoryRepository(db)
+	reviewCommentRepo := repository.NewReviewCommentRepository(db)
+	talentVersionRepo := repository.NewTalentVersionRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	verificationQueueRepo := repository.NewVerificationQueueRepository(db)
+	duplicateRepo := repository.NewDuplicateRepository(db)
+	talentTypeRepo := repository.NewTalentTypeRepository(db)
+	scoringRepo := repository.NewScoringRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	certificateRepo := repository.NewCertificateRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	emailQueueRepo := repository.NewEmailQueueRepository(db)
+	announcementRepo := repository.NewAnnouncementRepository(db)
+	txManager := repository.NewTxManager(db)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, tokenRepo, cfg.JWT)
-	userService := service.NewUserService(userRepo, schoolRepo)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, emailQueueRepo, emailTemplates, cfg.App)
+	emailService := service.NewEmailService(emailQueueRepo, userRepo, txManager, mail, emailTemplates, cfg.App, cfg.Notification)
+	outboxService := service.NewOutboxService(outboxRepo, txManager, cfg.Events)
+	outboxService.Register(
+		service.NewNotificationConsumer(userRepo, notificationService),
+		service.NewAuditConsumer(auditRepo),
+	)
+	if cfg.Events.WebhookURL != "" {
+		outboxService.Register(service.NewWebhookConsumer(cfg.Events))
+	}
+	securityService := service.NewSecurityService(securityRepo, userRepo, notificationService)
+	authService := service.NewAuthService(userRepo, tokenRepo, registrationRepo, securityService, cfg.JWT)
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepo, userRepo, mail, cfg.App)
+	userService := service.NewUserService(userRepo, schoolRepo, emailVerificationService, securityService, txManager, outboxService)
 	schoolService := service.NewSchoolService(schoolRepo, userRepo)
-	talentService := service.NewTalentService(talentRepo, userRepo, notificationRepo)
-	notificationService := service.NewNotificationService(notificationRepo)
+	talentService := service.NewTalentService(
+		talentRepo, userRepo, notificationService, talentHistoryRepo,
+		reviewCommentRepo, talentVersionRepo, attachmentRepo, verificationQueueRepo,
+		duplicateRepo, talentTypeRepo, txManager, outboxService, cfg.Verification, cfg.Notification,
+	)
+	talentTypeService := service.NewTalentTypeService(talentTypeRepo)
+	scoringService := service.NewScoringService(scoringRepo, talentTypeRepo)
+	catalogService := service.NewCatalogService(catalogRepo, txManager)
+	reportService := service.NewReportService(reportRepo, cfg.PKB)
+	certificateService := service.NewCertificateService(certificateRepo, userRepo, notificationService, cfg.Certificate)
+	notificationHub := service.NewNotificationHub(notificationRepo)
 	uploadService := service.NewUploadService(minioStorage)
 	dashboardService := service.NewDashboardService(userRepo, schoolRepo, talentRepo, notificationRepo)
+	registrationService := service.NewRegistrationService(registrationRepo, userRepo, schoolRepo, emailVerificationService, txManager, outboxService)
+	auditService := service.NewAuditService(auditRepo)
+	announcementService := service.NewAnnouncementService(announcementRepo, txManager, notificationService)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -60,10 +117,18 @@ func main() {
 	schoolHandler := handler.NewSchoolHandler(schoolService)
 	talentHandler := handler.NewTalentHandler(talentService, uploadService)
 	verificationHandler := handler.NewVerificationHandler(talentService)
-	notificationHandler := handler.NewNotificationHandler(notificationService)
+	notificationHandler := handler.NewNotificationHandler(notificationService, notificationHub, cfg.Notification.StreamHeartbeat)
 	uploadHandler := handler.NewUploadHandler(uploadService)
 	dashboardHandler := handler.NewDashboardHandler(dashboardService)
 	exportHandler := handler.NewExportHandler(userService, schoolService, talentService)
+	registrationHandler := handler.NewRegistrationHandler(registrationService)
+	securityHandler := handler.NewSecurityHandler(securityService)
+	talentTypeHandler := handler.NewTalentTypeHandler(talentTypeService)
+	leaderboardHandler := handler.NewLeaderboardHandler(scoringService)
+	catalogHandler := handler.NewCatalogHandler(catalogService)
+	reportHandler := handler.NewReportHandler(reportService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	announcementHandler := handler.NewAnnouncementHandler(announcementService, uploadService)
 
 	// Initialize router
 	r := router.NewRouter(
@@ -76,6 +141,14 @@ func main() {
 		uploadHandler,
 		dashboardHandler,
 		exportHandler,
+		registrationHandler,
+		securityHandler,
+		talentTypeHandler,
+		leaderboardHandler,
+		catalogHandler,
+		reportHandler,
+		auditHandler,
+		announcementHandler,
 		authService,
 	)
 
@@ -93,12 +166,27 @@ func main() {
 	// Setup routes
 	r.Setup(app)
 
+	// Background jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runPeriodic(jobCtx, "verification SLA check", cfg.Verification.SLACheckInterval, talentService.CheckSLA)
+	go runPeriodic(jobCtx, "pending verification digest", cfg.Verification.SLACheckInterval, talentService.SendPendingDigests)
+	go runPeriodic(jobCtx, "certificate expiry check", cfg.Certificate.CheckInterval, certificateService.CheckExpiry)
+	go runPeriodic(jobCtx, "outbox dispatch", cfg.Events.DispatchInterval, outboxService.Dispatch)
+	go runPeriodic(jobCtx, "email queue", cfg.Notification.EmailQueueInterval, emailService.ProcessQueue)
+	go runPeriodic(jobCtx, "notification digest", cfg.Notification.DigestCheckInterval, emailService.SendDigests)
+	go runPeriodic(jobCtx, "announcement publish", cfg.Announcement.PublishInterval, announcementService.PublishDue)
+	// Stopping the jobs also closes open notification streams, which would
+	// otherwise keep the server from shutting down
+	go notificationHub.Run(jobCtx)
+
 	// Graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 		log.Println("Shutting down server...")
+		stopJobs()
 		app.Shutdown()
 	}()
 
@@ -109,6 +197,24 @@ func main() {
 	}
 }
 
+// runPeriodic calls job every interval until ctx is cancelled. Failures are
+// logged and retried on the next tick.
+func runPeriodic(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+		}
+	}
+}
+
 func errorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	if e, ok := err.(*fiber.Error); ok {
@@ -5,6 +5,16 @@
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Trigram similarity for duplicate talent detection
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+-- Accent folding for talent search
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Talent search: Indonesian stemming with accents folded, so "olimpiade",
+-- "Olimpiadé" and "berolimpiade" meet
+CREATE TEXT SEARCH CONFIGURATION sipodi_search (COPY = pg_catalog.indonesian);
+ALTER TEXT SEARCH CONFIGURATION sipodi_search
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, indonesian_stem;
 
 -- ============================================
 -- ENUM TYPES
@@ -14,11 +24,49 @@ CREATE TYPE user_role AS ENUM ('super_admin', 'admin_sekolah', 'gtk');
 CREATE TYPE gender AS ENUM ('L', 'P');
 CREATE TYPE gtk_type AS ENUM ('guru', 'tendik', 'kepala_sekolah');
 CREATE TYPE school_status AS ENUM ('negeri', 'swasta');
-CREATE TYPE talent_type AS ENUM ('peserta_pelatihan', 'pembimbing_lomba', 'peserta_lomba', 'minat_bakat');
-CREATE TYPE talent_status AS ENUM ('pending', 'approved', 'rejected');
+CREATE TYPE talent_status AS ENUM ('draft', 'pending', 'school_approved', 'needs_revision', 'approved', 'rejected');
 CREATE TYPE competition_level AS ENUM ('kota', 'provinsi', 'nasional', 'internasional');
 CREATE TYPE talent_field AS ENUM ('akademik', 'inovasi', 'teknologi', 'sosial', 'olahraga', 'seni', 'kepemimpinan');
-CREATE TYPE notification_type AS ENUM ('talent_approved', 'talent_rejected');
+CREATE TYPE notification_type AS ENUM (
+    'talent_approved',
+    'talent_rejected',
+    'registration_approved',
+    'security_alert',
+    'talent_school_approved',
+    'talent_endorsement_requested',
+    'talent_needs_revision',
+    'verification_assigned',
+    'verification_reminder',
+    'verification_escalated',
+    'certificate_expiring',
+    'talent_submitted',
+    'verification_pending_digest',
+    'announcement_published'
+);
+CREATE TYPE registration_status AS ENUM ('pending', 'approved', 'rejected');
+CREATE TYPE email_token_purpose AS ENUM ('verify', 'change');
+CREATE TYPE security_event_type AS ENUM (
+    'password_changed',
+    'new_login',
+    'account_deactivated',
+    'account_reactivated',
+    'role_changed',
+    'token_reuse_detected'
+);
+CREATE TYPE attachment_kind AS ENUM ('certificate', 'photo', 'sk', 'other');
+CREATE TYPE talent_history_action AS ENUM (
+    'submitted',
+    'edited',
+    'school_approved',
+    'approved',
+    'rejected',
+    'revision_requested',
+    'reset',
+    'drafted',
+    'withdrawn'
+);
+CREATE TYPE catalog_kind AS ENUM ('competition', 'organizer');
+CREATE TYPE catalog_queue_status AS ENUM ('pending', 'resolved', 'dismissed');
 
 -- ============================================
 -- TABLES
@@ -51,6 +99,7 @@ CREATE TABLE users (
     position VARCHAR(255),
     school_id UUID REFERENCES schools(id) ON DELETE SET NULL,
     is_active BOOLEAN DEFAULT TRUE,
+    email_verified_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -65,70 +114,306 @@ CREATE TABLE refresh_tokens (
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     token_hash VARCHAR(255) NOT NULL,
     expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    -- Set when the token is rotated; presenting a revoked token again means it was stolen
+    revoked_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Security event log
+CREATE TABLE security_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    event_type security_event_type NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Devices/IPs a user has logged in from, used to detect new login sources
+CREATE TABLE login_sources (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, ip_address, user_agent)
+);
+
+-- Email verification and email change confirmation tokens.
+-- For purpose 'change', email holds the new address awaiting confirmation.
+CREATE TABLE email_verifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    purpose email_token_purpose NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- GTK self-registration (reviewed by admin sekolah)
+CREATE TABLE user_registrations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    -- Cleared when a rejection deletes the pending account, which frees its
+    -- email, NUPTK and NIP for a new registration
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    -- Applicant as registered, kept after a rejection
+    email VARCHAR(255) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    status registration_status NOT NULL DEFAULT 'pending',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    rejection_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- ============================================
 -- TALENT TABLES (Normalized by type)
 -- ============================================
 
+-- Talent type registry. Each type declares its detail fields and their
+-- validation as a JSON Schema; the match_* columns name the detail fields
+-- duplicate detection compares.
+CREATE TABLE talent_type_definitions (
+    code VARCHAR(50) PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    detail_schema JSONB NOT NULL,
+    match_name_field VARCHAR(100),
+    match_organizer_field VARCHAR(100),
+    match_date_field VARCHAR(100),
+    -- Detail field holding a competition name, linked to the catalog;
+    -- organizers are linked through match_organizer_field
+    catalog_competition_field VARCHAR(100),
+    -- The four original types, kept queryable through the views below
+    is_builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Curated competitions and organizers. Free-text names in talent details are
+-- linked to an entry through its aliases, compared after catalog_normalize().
+CREATE TABLE catalog_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind catalog_kind NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    -- Prefilled in the talent form when a competition is picked
+    default_level competition_level,
+    default_field talent_field,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id, kind),
+    CHECK (kind = 'competition' OR (default_level IS NULL AND default_field IS NULL))
+);
+
+-- Every spelling of an entry, its own name included. A normalized spelling
+-- belongs to at most one entry of a kind.
+CREATE TABLE catalog_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entry_id UUID NOT NULL,
+    kind catalog_kind NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    normalized VARCHAR(255) NOT NULL CHECK (normalized <> ''),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (entry_id, kind) REFERENCES catalog_entries(id, kind) ON DELETE CASCADE,
+    UNIQUE (kind, normalized)
+);
+
+-- Submitted names no alias matches, waiting for a super admin to map them to
+-- an entry or dismiss them
+CREATE TABLE catalog_queue (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind catalog_kind NOT NULL,
+    -- The name as first submitted
+    raw_name VARCHAR(255) NOT NULL,
+    normalized VARCHAR(255) NOT NULL,
+    status catalog_queue_status NOT NULL DEFAULT 'pending',
+    entry_id UUID REFERENCES catalog_entries(id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, normalized)
+);
+
 -- Base talents table
 CREATE TABLE talents (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    talent_type talent_type NOT NULL,
+    talent_type VARCHAR(50) NOT NULL REFERENCES talent_type_definitions(code),
+    -- Detail fields, validated against the type's detail_schema
+    detail JSONB NOT NULL DEFAULT '{}',
     status talent_status DEFAULT 'pending',
     verified_by UUID REFERENCES users(id) ON DELETE SET NULL,
     verified_at TIMESTAMP WITH TIME ZONE,
+    -- First stage of the two-level workflow (admin sekolah)
+    school_verified_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    school_verified_at TIMESTAMP WITH TIME ZONE,
     rejection_reason TEXT,
+    -- When the talent entered its current status; drives the verification SLA
+    queued_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    -- Full-text search document, kept current by triggers
+    search_vector TSVECTOR,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Peserta Pelatihan
-CREATE TABLE talent_trainings (
+-- Verification queue state of a talent: who is working on it and which SLA
+-- notices went out. The row is cleared whenever the talent leaves its queue
+-- or is resubmitted.
+CREATE TABLE verification_queue (
+    talent_id UUID PRIMARY KEY REFERENCES talents(id) ON DELETE CASCADE,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE,
+    -- NULL for an explicit assignment by super_admin, otherwise a claim lock
+    claim_expires_at TIMESTAMP WITH TIME ZONE,
+    reminded_at TIMESTAMP WITH TIME ZONE,
+    escalated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Per-type views over talents.detail with the columns of the original
+-- detail tables, for reporting on the built-in types
+CREATE VIEW talent_trainings AS
+    SELECT id AS talent_id,
+        detail->>'activity_name' AS activity_name,
+        detail->>'organizer' AS organizer,
+        (detail->>'start_date')::date AS start_date,
+        (detail->>'duration_days')::integer AS duration_days
+    FROM talents WHERE talent_type = 'peserta_pelatihan';
+
+CREATE VIEW talent_competition_mentors AS
+    SELECT id AS talent_id,
+        detail->>'competition_name' AS competition_name,
+        (detail->>'level')::competition_level AS level,
+        detail->>'organizer' AS organizer,
+        (detail->>'field')::talent_field AS field,
+        detail->>'achievement' AS achievement
+    FROM talents WHERE talent_type = 'pembimbing_lomba';
+
+CREATE VIEW talent_competition_participants AS
+    SELECT id AS talent_id,
+        detail->>'competition_name' AS competition_name,
+        (detail->>'level')::competition_level AS level,
+        detail->>'organizer' AS organizer,
+        (detail->>'field')::talent_field AS field,
+        (detail->>'start_date')::date AS start_date,
+        (detail->>'duration_days')::integer AS duration_days,
+        detail->>'competition_field' AS competition_field,
+        detail->>'achievement' AS achievement
+    FROM talents WHERE talent_type = 'peserta_lomba';
+
+CREATE VIEW talent_interests AS
+    SELECT id AS talent_id,
+        detail->>'interest_name' AS interest_name,
+        detail->>'description' AS description
+    FROM talents WHERE talent_type = 'minat_bakat';
+
+-- Catalog entry a talent's competition or organizer name resolves to, kept
+-- current by triggers
+CREATE TABLE talent_catalog_links (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    kind catalog_kind NOT NULL,
+    entry_id UUID NOT NULL,
+    FOREIGN KEY (entry_id, kind) REFERENCES catalog_entries(id, kind) ON DELETE CASCADE,
+    PRIMARY KEY (talent_id, kind)
+);
+
+-- Files attached to a talent (certificates, photos, decree letters, reports)
+CREATE TABLE talent_attachments (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    talent_id UUID UNIQUE NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
-    activity_name VARCHAR(255) NOT NULL,
-    organizer VARCHAR(255) NOT NULL,
-    start_date DATE NOT NULL,
-    duration_days INTEGER NOT NULL CHECK (duration_days > 0)
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    kind attachment_kind NOT NULL DEFAULT 'other',
+    caption VARCHAR(255),
+    file_url VARCHAR(500) NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    file_size BIGINT,
+    -- SHA-256 of the file content, used to spot reused certificates
+    file_hash VARCHAR(64),
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing talents a submission looks like a duplicate of. Rebuilt each time
+-- the talent is submitted or edited.
+CREATE TABLE talent_duplicate_matches (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    match_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    -- similar_name, similar_organizer, same_date, same_file
+    reasons TEXT[] NOT NULL,
+    score REAL NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (talent_id, match_id)
 );
 
--- Pembimbing Lomba
-CREATE TABLE talent_competition_mentors (
+-- The fields duplicate detection compares, one row per talent whose type
+-- declares a name field
+CREATE VIEW talent_match_keys AS
+    SELECT t.id AS talent_id,
+        t.detail->>d.match_name_field AS name,
+        t.detail->>d.match_organizer_field AS organizer,
+        CASE WHEN t.detail->>d.match_date_field ~ '^\d{4}-\d{2}-\d{2}$'
+            THEN (t.detail->>d.match_date_field)::date END AS event_date
+    FROM talents t
+    JOIN talent_type_definitions d ON d.code = t.talent_type
+    WHERE d.match_name_field IS NOT NULL;
+
+-- Every status transition of a talent, kept after the talent is edited
+CREATE TABLE talent_status_history (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    talent_id UUID UNIQUE NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
-    competition_name VARCHAR(255) NOT NULL,
-    level competition_level NOT NULL,
-    organizer VARCHAR(255) NOT NULL,
-    field talent_field NOT NULL,
-    achievement TEXT NOT NULL,
-    certificate_url VARCHAR(500)
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    action talent_history_action NOT NULL,
+    from_status talent_status,
+    to_status talent_status NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    -- Talent detail as it was when the transition happened
+    detail_snapshot JSONB,
+    -- Shared by every transition made by one batch verification
+    batch_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Peserta Lomba
-CREATE TABLE talent_competition_participants (
+-- Immutable snapshot of the talent detail, one per create/update
+CREATE TABLE talent_versions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    talent_id UUID UNIQUE NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
-    competition_name VARCHAR(255) NOT NULL,
-    level competition_level NOT NULL,
-    organizer VARCHAR(255) NOT NULL,
-    field talent_field NOT NULL,
-    start_date DATE NOT NULL,
-    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
-    competition_field VARCHAR(255) NOT NULL,
-    achievement TEXT NOT NULL,
-    certificate_url VARCHAR(500)
-);
-
--- Minat/Bakat
-CREATE TABLE talent_interests (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    detail JSONB NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Set when this version was the one finally approved
+    approved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(talent_id, version)
+);
+
+-- Field-level comments left by verifiers when asking for a revision
+CREATE TABLE talent_review_comments (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    talent_id UUID UNIQUE NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
-    interest_name VARCHAR(255) NOT NULL,
-    description TEXT NOT NULL,
-    certificate_url VARCHAR(500)
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    field VARCHAR(100) NOT NULL,
+    comment TEXT NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    is_resolved BOOLEAN DEFAULT FALSE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Scoring scheme for the leaderboards; a single row, see talent_score()
+CREATE TABLE scoring_scheme (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    scheme JSONB NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
 -- ============================================
@@ -142,9 +427,154 @@ CREATE TABLE notifications (
     type notification_type NOT NULL,
     message TEXT NOT NULL,
     is_read BOOLEAN DEFAULT FALSE,
+    -- Delivery order for the notification stream, used as the SSE event ID
+    seq BIGSERIAL NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Channels a user wants per notification type. Types without a row use
+-- the defaults of the application.
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    in_app BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    digest BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type)
+);
+
+-- Rendered emails waiting to be sent, retried with backoff
+CREATE TABLE email_queue (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    to_address VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Notifications collected for the daily digest email
+CREATE TABLE notification_digest_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    message TEXT NOT NULL,
+    talent_id UUID REFERENCES talents(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Notifications that must go out only once per user, such as the weekly
+-- digest of a given week
+CREATE TABLE notification_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Certificate expiry reminders already sent, one per threshold in days
+-- before expiry; a renewed expiry date starts over
+CREATE TABLE certificate_expiry_notices (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    expiry_date DATE NOT NULL,
+    days_before INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (talent_id, expiry_date, days_before)
+);
+
+-- ============================================
+-- ANNOUNCEMENTS
+-- ============================================
+
+-- Announcements from the dinas or a school. Each target list narrows the
+-- audience; an empty list does not filter
+CREATE TABLE announcements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Set for announcements posted by a school admin, which only reach
+    -- that school and are managed by its admins
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE,
+    target_roles user_role[] NOT NULL DEFAULT '{}',
+    target_school_ids UUID[] NOT NULL DEFAULT '{}',
+    target_school_statuses school_status[] NOT NULL DEFAULT '{}',
+    target_gtk_types gtk_type[] NOT NULL DEFAULT '{}',
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    -- Set once the audience received its in-app notification
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (expires_at IS NULL OR expires_at > publish_at)
+);
+
+CREATE TABLE announcement_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    caption VARCHAR(255),
+    file_url VARCHAR(500) NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    file_size BIGINT,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE announcement_reads (
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+-- ============================================
+-- DOMAIN EVENTS
+-- ============================================
+
+-- Transactional outbox: events are written in the transaction of the state
+-- change they describe and delivered afterwards by the dispatcher
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    -- No foreign keys: events outlive the rows they describe
+    actor_id UUID,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per consumer subscribed to an event, retried until delivered or
+-- out of attempts
+CREATE TABLE outbox_deliveries (
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    consumer VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    PRIMARY KEY (event_id, consumer)
+);
+
+-- Every domain event, kept by the audit consumer after the outbox is pruned
+CREATE TABLE audit_log (
+    event_id BIGINT PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    actor_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- ============================================
 -- INDEXES
 -- ============================================
@@ -165,15 +595,59 @@ CREATE INDEX idx_schools_status ON schools(status);
 CREATE INDEX idx_talents_user_id ON talents(user_id);
 CREATE INDEX idx_talents_status ON talents(status);
 CREATE INDEX idx_talents_type ON talents(talent_type);
+CREATE INDEX idx_talents_detail ON talents USING gin (detail jsonb_path_ops);
+CREATE INDEX idx_talents_detail_level ON talents ((detail->>'level'));
+CREATE INDEX idx_talents_search ON talents USING gin (search_vector);
 CREATE INDEX idx_talents_user_status ON talents(user_id, status);
+CREATE INDEX idx_talents_status_queued_at ON talents(status, queued_at);
+CREATE INDEX idx_verification_queue_assignee_id ON verification_queue(assignee_id);
+CREATE INDEX idx_talent_status_history_talent_id ON talent_status_history(talent_id, created_at);
+CREATE INDEX idx_talent_status_history_batch_id ON talent_status_history(batch_id) WHERE batch_id IS NOT NULL;
+CREATE INDEX idx_talent_attachments_talent_id ON talent_attachments(talent_id, created_at);
+CREATE INDEX idx_talent_attachments_file_hash ON talent_attachments(file_hash);
+CREATE INDEX idx_talent_review_comments_talent_id ON talent_review_comments(talent_id, created_at);
+
+-- Catalog indexes
+CREATE INDEX idx_catalog_entries_kind ON catalog_entries(kind, is_active);
+CREATE INDEX idx_catalog_aliases_entry_id ON catalog_aliases(entry_id);
+CREATE INDEX idx_catalog_queue_status ON catalog_queue(kind, status);
+CREATE INDEX idx_talent_catalog_links_entry_id ON talent_catalog_links(entry_id);
+
+-- Domain event indexes
+CREATE INDEX idx_outbox_deliveries_due ON outbox_deliveries(next_attempt_at) WHERE delivered_at IS NULL;
+CREATE INDEX idx_outbox_events_created_at ON outbox_events(created_at);
+CREATE INDEX idx_audit_log_aggregate_id ON audit_log(aggregate_id, occurred_at DESC);
+CREATE INDEX idx_audit_log_occurred_at ON audit_log(occurred_at DESC);
 
 -- Refresh tokens indexes
 CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
 CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
 
+-- Security events indexes
+CREATE INDEX idx_security_events_user_id ON security_events(user_id, created_at DESC);
+CREATE INDEX idx_security_events_type ON security_events(event_type);
+CREATE INDEX idx_security_events_created_at ON security_events(created_at DESC);
+
+-- Email verifications indexes
+CREATE INDEX idx_email_verifications_token_hash ON email_verifications(token_hash);
+CREATE INDEX idx_email_verifications_user_purpose ON email_verifications(user_id, purpose);
+
+-- User registrations indexes
+CREATE INDEX idx_user_registrations_school_status ON user_registrations(school_id, status);
+
 -- Notifications indexes
 CREATE INDEX idx_notifications_user_id ON notifications(user_id);
 CREATE INDEX idx_notifications_is_read ON notifications(user_id, is_read);
+CREATE INDEX idx_notifications_seq ON notifications(user_id, seq);
+CREATE INDEX idx_email_queue_due ON email_queue(next_attempt_at) WHERE sent_at IS NULL;
+CREATE INDEX idx_notification_digest_items_pending ON notification_digest_items(user_id, created_at) WHERE sent_at IS NULL;
+
+-- Announcements indexes
+CREATE INDEX idx_announcements_publish_at ON announcements(publish_at DESC);
+CREATE INDEX idx_announcements_school_id ON announcements(school_id);
+CREATE INDEX idx_announcements_unnotified ON announcements(publish_at) WHERE notified_at IS NULL;
+CREATE INDEX idx_announcement_attachments_announcement_id ON announcement_attachments(announcement_id);
+CREATE INDEX idx_announcement_reads_user_id ON announcement_reads(user_id);
 
 -- ============================================
 -- FUNCTIONS & TRIGGERS
@@ -199,84 +673,399 @@ CREATE TRIGGER update_schools_updated_at
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_talent_type_definitions_updated_at
+    BEFORE UPDATE ON talent_type_definitions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_catalog_entries_updated_at
+    BEFORE UPDATE ON catalog_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TRIGGER update_talents_updated_at
     BEFORE UPDATE ON talents
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
--- Function to create notification when talent status changes
-CREATE OR REPLACE FUNCTION notify_talent_status_change()
+CREATE TRIGGER update_announcements_updated_at
+    BEFORE UPDATE ON announcements
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_registrations_updated_at
+    BEFORE UPDATE ON user_registrations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Every string in a talent detail, nested ones included, as one text
+CREATE OR REPLACE FUNCTION talent_search_text(p_detail JSONB)
+RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(v #>> '{}', ' '), '')
+    FROM jsonb_path_query(p_detail, 'strict $.**') AS v
+    WHERE jsonb_typeof(v) = 'string'
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Search document of a talent: the name field of its type ranks highest,
+-- then the owner's name, then everything else in the detail
+CREATE OR REPLACE FUNCTION talent_search_vector(p_talent_type VARCHAR, p_detail JSONB, p_user_id UUID)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('sipodi_search', COALESCE(p_detail->>d.match_name_field, '')), 'A')
+        || setweight(to_tsvector('sipodi_search', COALESCE(u.full_name, '')), 'B')
+        || setweight(to_tsvector('sipodi_search', talent_search_text(p_detail)), 'C')
+    FROM (SELECT 1) AS one
+    LEFT JOIN talent_type_definitions d ON d.code = p_talent_type
+    LEFT JOIN users u ON u.id = p_user_id
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION update_talent_search_vector()
 RETURNS TRIGGER AS $$
 BEGIN
-    IF OLD.status = 'pending' AND NEW.status IN ('approved', 'rejected') THEN
-        INSERT INTO notifications (user_id, talent_id, type, message)
-        VALUES (
-            NEW.user_id,
-            NEW.id,
-            CASE WHEN NEW.status = 'approved' THEN 'talent_approved'::notification_type 
-                 ELSE 'talent_rejected'::notification_type END,
-            CASE WHEN NEW.status = 'approved' 
-                 THEN 'Talenta Anda telah disetujui'
-                 ELSE 'Talenta Anda ditolak. Alasan: ' || COALESCE(NEW.rejection_reason, '-') END
-        );
-    END IF;
+    NEW.search_vector = talent_search_vector(NEW.talent_type, NEW.detail, NEW.user_id);
     RETURN NEW;
 END;
 $$ LANGUAGE plpgsql;
 
-CREATE TRIGGER trigger_talent_status_notification
-    AFTER UPDATE ON talents
+CREATE TRIGGER update_talents_search_vector
+    BEFORE INSERT OR UPDATE OF detail, talent_type, user_id ON talents
     FOR EACH ROW
-    WHEN (OLD.status IS DISTINCT FROM NEW.status)
-    EXECUTE FUNCTION notify_talent_status_change();
+    EXECUTE FUNCTION update_talent_search_vector();
 
--- Function to reset talent status to pending on update
-CREATE OR REPLACE FUNCTION reset_talent_status_on_update()
+-- A renamed GTK or a new name field changes the documents of many talents
+CREATE OR REPLACE FUNCTION refresh_user_talent_search()
 RETURNS TRIGGER AS $$
 BEGIN
-    -- Reset status to pending when talent detail is updated
-    UPDATE talents 
-    SET status = 'pending', 
-        verified_by = NULL, 
-        verified_at = NULL,
-        rejection_reason = NULL
-    WHERE id = NEW.talent_id 
-    AND status != 'pending';
-    RETURN NEW;
+    UPDATE talents SET search_vector = talent_search_vector(talent_type, detail, user_id)
+    WHERE user_id = NEW.id;
+    RETURN NULL;
 END;
 $$ LANGUAGE plpgsql;
 
--- Apply reset trigger to all talent detail tables
-CREATE TRIGGER reset_training_status
-    AFTER UPDATE ON talent_trainings
+CREATE TRIGGER refresh_talent_search_on_user_rename
+    AFTER UPDATE OF full_name ON users
     FOR EACH ROW
-    EXECUTE FUNCTION reset_talent_status_on_update();
+    WHEN (OLD.full_name IS DISTINCT FROM NEW.full_name)
+    EXECUTE FUNCTION refresh_user_talent_search();
+
+CREATE OR REPLACE FUNCTION refresh_type_talent_search()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE talents SET search_vector = talent_search_vector(talent_type, detail, user_id)
+    WHERE talent_type = NEW.code;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
 
-CREATE TRIGGER reset_mentor_status
-    AFTER UPDATE ON talent_competition_mentors
+CREATE TRIGGER refresh_talent_search_on_name_field
+    AFTER UPDATE OF match_name_field ON talent_type_definitions
     FOR EACH ROW
-    EXECUTE FUNCTION reset_talent_status_on_update();
+    WHEN (OLD.match_name_field IS DISTINCT FROM NEW.match_name_field)
+    EXECUTE FUNCTION refresh_type_talent_search();
+
+-- Catalog matching key: lower case, no accents, letters and digits only, so
+-- "O.S.N." and "OSN" compare equal. NULL when nothing is left.
+CREATE OR REPLACE FUNCTION catalog_normalize(p_name TEXT)
+RETURNS TEXT AS $$
+    SELECT NULLIF(regexp_replace(lower(unaccent(COALESCE(p_name, ''))), '[^a-z0-9]+', '', 'g'), '')
+$$ LANGUAGE sql STABLE;
+
+-- Entry a name resolves to. An unknown name is queued for normalization
+-- unless p_queue is false.
+CREATE OR REPLACE FUNCTION catalog_match(p_kind catalog_kind, p_name TEXT, p_queue BOOLEAN)
+RETURNS UUID AS $$
+DECLARE
+    v_normalized TEXT := catalog_normalize(p_name);
+    v_entry_id UUID;
+BEGIN
+    IF v_normalized IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT entry_id INTO v_entry_id FROM catalog_aliases
+    WHERE kind = p_kind AND normalized = v_normalized;
 
-CREATE TRIGGER reset_participant_status
-    AFTER UPDATE ON talent_competition_participants
+    IF v_entry_id IS NULL AND p_queue THEN
+        -- A resolved item whose alias was removed goes back to the queue
+        INSERT INTO catalog_queue (kind, raw_name, normalized)
+        VALUES (p_kind, left(btrim(p_name), 255), v_normalized)
+        ON CONFLICT (kind, normalized) DO UPDATE
+            SET status = 'pending', entry_id = NULL, resolved_by = NULL, resolved_at = NULL
+            WHERE catalog_queue.status = 'resolved';
+    END IF;
+    RETURN v_entry_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Relinks one talent to the catalog. Drafts are linked but never queued.
+CREATE OR REPLACE FUNCTION link_talent_catalog(p_talent_id UUID, p_talent_type VARCHAR, p_detail JSONB, p_status talent_status)
+RETURNS VOID AS $$
+DECLARE
+    v_def talent_type_definitions%ROWTYPE;
+    v_queue BOOLEAN := p_status <> 'draft';
+BEGIN
+    SELECT * INTO v_def FROM talent_type_definitions WHERE code = p_talent_type;
+
+    DELETE FROM talent_catalog_links WHERE talent_id = p_talent_id;
+    INSERT INTO talent_catalog_links (talent_id, kind, entry_id)
+    SELECT p_talent_id, m.kind, m.entry_id
+    FROM (VALUES
+        ('competition'::catalog_kind, catalog_match('competition', p_detail->>v_def.catalog_competition_field, v_queue)),
+        ('organizer'::catalog_kind, catalog_match('organizer', p_detail->>v_def.match_organizer_field, v_queue))
+    ) AS m(kind, entry_id)
+    WHERE m.entry_id IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_talent_catalog_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM link_talent_catalog(NEW.id, NEW.talent_type, NEW.detail, NEW.status);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_talents_catalog_links
+    AFTER INSERT OR UPDATE OF detail, talent_type, status ON talents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_talent_catalog_links();
+
+-- A new alias links the talents already carrying that name and resolves its
+-- queue item; a removed alias unlinks them
+CREATE OR REPLACE FUNCTION refresh_alias_talent_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM talent_catalog_links l
+        USING talents t, talent_type_definitions d
+        WHERE l.talent_id = t.id AND d.code = t.talent_type
+            AND l.kind = OLD.kind AND l.entry_id = OLD.entry_id
+            AND catalog_normalize(t.detail->>CASE OLD.kind
+                WHEN 'competition' THEN d.catalog_competition_field
+                ELSE d.match_organizer_field END) = OLD.normalized;
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO talent_catalog_links (talent_id, kind, entry_id)
+    SELECT t.id, NEW.kind, NEW.entry_id
+    FROM talents t
+    JOIN talent_type_definitions d ON d.code = t.talent_type
+    WHERE catalog_normalize(t.detail->>CASE NEW.kind
+            WHEN 'competition' THEN d.catalog_competition_field
+            ELSE d.match_organizer_field END) = NEW.normalized
+    ON CONFLICT (talent_id, kind) DO NOTHING;
+
+    UPDATE catalog_queue SET status = 'resolved', entry_id = NEW.entry_id, resolved_at = CURRENT_TIMESTAMP
+    WHERE kind = NEW.kind AND normalized = NEW.normalized AND status = 'pending';
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER refresh_talent_links_on_alias
+    AFTER INSERT OR DELETE ON catalog_aliases
+    FOR EACH ROW
+    EXECUTE FUNCTION refresh_alias_talent_links();
+
+-- Pointing a type at other detail fields relinks all its talents
+CREATE OR REPLACE FUNCTION refresh_type_talent_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM link_talent_catalog(id, talent_type, detail, status)
+    FROM talents WHERE talent_type = NEW.code;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER refresh_talent_links_on_catalog_fields
+    AFTER UPDATE OF catalog_competition_field, match_organizer_field ON talent_type_definitions
+    FOR EACH ROW
+    WHEN (OLD.catalog_competition_field IS DISTINCT FROM NEW.catalog_competition_field
+        OR OLD.match_organizer_field IS DISTINCT FROM NEW.match_organizer_field)
+    EXECUTE FUNCTION refresh_type_talent_links();
+
+-- Points of one talent under a scoring scheme: flat points of its type,
+-- points per training day (capped at max_days) and level points scaled by
+-- the first achievement pattern that matches
+CREATE OR REPLACE FUNCTION talent_score(p_scheme JSONB, p_talent_type VARCHAR, p_detail JSONB)
+RETURNS NUMERIC AS $$
+    SELECT COALESCE((r->>'points')::numeric, 0)
+        + COALESCE((r->>'points_per_day')::numeric, 0)
+            * LEAST(days, COALESCE(NULLIF((r->>'max_days')::numeric, 0), days))
+        + COALESCE((r->>'level_factor')::numeric, 0)
+            * COALESCE((p_scheme->'level_points'->>(p_detail->>'level'))::numeric, 0)
+            * COALESCE((
+                SELECT (m.value->>'multiplier')::numeric
+                FROM jsonb_array_elements(p_scheme->'achievement_multipliers') WITH ORDINALITY AS m(value, position)
+                WHERE p_detail->>'achievement' ~* (m.value->>'pattern')
+                ORDER BY m.position
+                LIMIT 1
+            ), (p_scheme->>'default_achievement_multiplier')::numeric, 1)
+    FROM (SELECT p_scheme->'types'->p_talent_type AS r,
+            COALESCE((p_detail->>'duration_days')::numeric, 0) AS days) AS rule
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Expiry date of the certificate a talent carries in its detail, if any
+CREATE OR REPLACE FUNCTION certificate_expiry(p_detail JSONB)
+RETURNS DATE AS $$
+    SELECT CASE WHEN p_detail->>'certificate_expiry_date' ~ '^\d{4}-\d{2}-\d{2}$'
+        THEN (p_detail->>'certificate_expiry_date')::date END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Numbers a notification once no other transaction can still add one for
+-- the same user. The lock is held until commit, so a user's notifications
+-- commit in seq order and a stream reading seq > last never skips a row that
+-- commits late. Jobs notifying several users in one transaction go through
+-- them in user order, so their locks cannot deadlock.
+CREATE OR REPLACE FUNCTION assign_notification_seq()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('notifications:' || NEW.user_id::text, 0));
+    NEW.seq := nextval(pg_get_serial_sequence('notifications', 'seq'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER assign_notification_seq
+    BEFORE INSERT ON notifications
     FOR EACH ROW
-    EXECUTE FUNCTION reset_talent_status_on_update();
+    EXECUTE FUNCTION assign_notification_seq();
+
+-- Tells notification streams on every API instance that a user's
+-- notifications changed. NOTIFY is sent on commit, and identical payloads in
+-- one transaction are folded into one.
+CREATE OR REPLACE FUNCTION notify_notification_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('notification_changes', COALESCE(NEW.user_id, OLD.user_id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
 
-CREATE TRIGGER reset_interest_status
-    AFTER UPDATE ON talent_interests
+CREATE TRIGGER notify_notification_change
+    AFTER INSERT OR UPDATE OF is_read OR DELETE ON notifications
     FOR EACH ROW
-    EXECUTE FUNCTION reset_talent_status_on_update();
+    EXECUTE FUNCTION notify_notification_change();
 
 -- ============================================
--- SEED DATA (Super Admin)
+-- SEED DATA (Super Admin, talent types, scoring scheme, catalog)
 -- ============================================
 
 -- Default super admin (password: admin123 - hashed with bcrypt)
 -- Note: Change this password in production!
-INSERT INTO users (email, password_hash, role, full_name)
+INSERT INTO users (email, password_hash, role, full_name, email_verified_at)
 VALUES (
     'superadmin@sipodi.go.id',
     '$2a$10$dXG3Jih7K.UvR5mYXAaeoOb4TAqTqp9JR170UuLVaYkm79xsxsyHi',
     'super_admin',
-    'Super Administrator'
+    'Super Administrator',
+    CURRENT_TIMESTAMP
+);
+
+-- Built-in talent types
+INSERT INTO talent_type_definitions (code, name, detail_schema, match_name_field, match_organizer_field, match_date_field, catalog_competition_field, is_builtin)
+VALUES (
+    'peserta_pelatihan', 'Peserta Pelatihan', '{
+        "type": "object",
+        "required": ["activity_name", "organizer", "start_date", "duration_days"],
+        "properties": {
+            "activity_name": {"type": "string", "title": "Nama Kegiatan", "pattern": "\\S", "x-messages": {"required": "Nama kegiatan wajib diisi", "pattern": "Nama kegiatan wajib diisi"}, "maxLength": 255},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "start_date": {"type": "string", "title": "Tanggal Mulai", "format": "date", "x-not-future": true, "minLength": 1, "x-messages": {"required": "Tanggal mulai wajib diisi", "minLength": "Tanggal mulai wajib diisi"}},
+            "duration_days": {"type": "integer", "title": "Jangka Waktu (hari)", "exclusiveMinimum": 0, "x-messages": {"required": "Jangka waktu harus lebih dari 0", "exclusiveMinimum": "Jangka waktu harus lebih dari 0"}},
+            "certificate_number": {"type": "string", "title": "Nomor Sertifikat", "maxLength": 100},
+            "certificate_issued_date": {"type": "string", "title": "Tanggal Terbit Sertifikat", "format": "date", "x-not-future": true},
+            "certificate_expiry_date": {"type": "string", "title": "Sertifikat Berlaku Sampai", "format": "date"}
+        }
+    }',
+    'activity_name', 'organizer', 'start_date', NULL, TRUE
+), (
+    'pembimbing_lomba', 'Pembimbing Lomba', '{
+        "type": "object",
+        "required": ["competition_name", "level", "organizer", "field", "achievement"],
+        "properties": {
+            "competition_name": {"type": "string", "title": "Nama Lomba", "pattern": "\\S", "x-messages": {"required": "Nama lomba wajib diisi", "pattern": "Nama lomba wajib diisi"}, "maxLength": 255},
+            "level": {"type": "string", "title": "Jenjang", "enum": ["kota", "provinsi", "nasional", "internasional"], "minLength": 1, "x-messages": {"required": "Jenjang wajib diisi", "minLength": "Jenjang wajib diisi", "enum": "Jenjang harus kota, provinsi, nasional, atau internasional"}},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "field": {"type": "string", "title": "Bidang", "enum": ["akademik", "inovasi", "teknologi", "sosial", "olahraga", "seni", "kepemimpinan"], "minLength": 1, "x-messages": {"required": "Bidang wajib diisi", "minLength": "Bidang wajib diisi", "enum": "Bidang tidak valid"}},
+            "achievement": {"type": "string", "title": "Prestasi", "pattern": "\\S", "x-messages": {"required": "Prestasi wajib diisi", "pattern": "Prestasi wajib diisi"}}
+        }
+    }',
+    'competition_name', 'organizer', NULL, 'competition_name', TRUE
+), (
+    'peserta_lomba', 'Peserta Lomba', '{
+        "type": "object",
+        "required": ["competition_name", "level", "organizer", "field", "start_date", "duration_days", "competition_field", "achievement"],
+        "properties": {
+            "competition_name": {"type": "string", "title": "Nama Lomba", "pattern": "\\S", "x-messages": {"required": "Nama lomba wajib diisi", "pattern": "Nama lomba wajib diisi"}, "maxLength": 255},
+            "level": {"type": "string", "title": "Jenjang", "enum": ["kota", "provinsi", "nasional", "internasional"], "minLength": 1, "x-messages": {"required": "Jenjang wajib diisi", "minLength": "Jenjang wajib diisi", "enum": "Jenjang harus kota, provinsi, nasional, atau internasional"}},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "field": {"type": "string", "title": "Bidang", "enum": ["akademik", "inovasi", "teknologi", "sosial", "olahraga", "seni", "kepemimpinan"], "minLength": 1, "x-messages": {"required": "Bidang wajib diisi", "minLength": "Bidang wajib diisi", "enum": "Bidang tidak valid"}},
+            "start_date": {"type": "string", "title": "Tanggal Mulai", "format": "date", "x-not-future": true, "minLength": 1, "x-messages": {"required": "Tanggal mulai wajib diisi", "minLength": "Tanggal mulai wajib diisi"}},
+            "duration_days": {"type": "integer", "title": "Jangka Waktu (hari)", "exclusiveMinimum": 0, "x-messages": {"required": "Jangka waktu harus lebih dari 0", "exclusiveMinimum": "Jangka waktu harus lebih dari 0"}},
+            "competition_field": {"type": "string", "title": "Bidang Lomba", "pattern": "\\S", "x-messages": {"required": "Bidang lomba wajib diisi", "pattern": "Bidang lomba wajib diisi"}, "maxLength": 255},
+            "achievement": {"type": "string", "title": "Prestasi", "pattern": "\\S", "x-messages": {"required": "Prestasi wajib diisi", "pattern": "Prestasi wajib diisi"}}
+        }
+    }',
+    'competition_name', 'organizer', 'start_date', 'competition_name', TRUE
+), (
+    'minat_bakat', 'Minat/Bakat', '{
+        "type": "object",
+        "required": ["interest_name", "description"],
+        "properties": {
+            "interest_name": {"type": "string", "title": "Nama Minat/Bakat", "pattern": "\\S", "x-messages": {"required": "Nama minat/bakat wajib diisi", "pattern": "Nama minat/bakat wajib diisi"}, "maxLength": 255},
+            "description": {"type": "string", "title": "Deskripsi", "pattern": "\\S", "x-messages": {"required": "Deskripsi wajib diisi", "pattern": "Deskripsi wajib diisi"}},
+            "certificate_number": {"type": "string", "title": "Nomor Sertifikat", "maxLength": 100},
+            "certificate_issued_date": {"type": "string", "title": "Tanggal Terbit Sertifikat", "format": "date", "x-not-future": true},
+            "certificate_expiry_date": {"type": "string", "title": "Sertifikat Berlaku Sampai", "format": "date"}
+        }
+    }',
+    'interest_name', NULL, NULL, NULL, TRUE
 );
+
+INSERT INTO scoring_scheme (scheme) VALUES ('{
+    "level_points": {"kota": 10, "provinsi": 20, "nasional": 40, "internasional": 80},
+    "achievement_multipliers": [
+        {"pattern": "juara\\s*(umum|1|i|satu)([^0-9a-z]|$)", "multiplier": 3},
+        {"pattern": "juara\\s*(2|ii|dua)([^0-9a-z]|$)", "multiplier": 2.5},
+        {"pattern": "juara\\s*(3|iii|tiga)([^0-9a-z]|$)", "multiplier": 2},
+        {"pattern": "harapan", "multiplier": 1.5},
+        {"pattern": "finalis", "multiplier": 1.25}
+    ],
+    "default_achievement_multiplier": 1,
+    "types": {
+        "peserta_pelatihan": {"points": 5, "points_per_day": 1, "max_days": 30},
+        "pembimbing_lomba": {"level_factor": 1},
+        "peserta_lomba": {"level_factor": 1},
+        "minat_bakat": {"points": 5}
+    }
+}');
+
+-- Common competitions and organizers; the rest come in through the queue
+WITH entries AS (
+    INSERT INTO catalog_entries (kind, name, default_level, default_field)
+    VALUES
+        ('competition', 'Olimpiade Sains Nasional', 'nasional', 'akademik'),
+        ('competition', 'Olimpiade Olahraga Siswa Nasional', 'nasional', 'olahraga'),
+        ('competition', 'Festival dan Lomba Seni Siswa Nasional', 'nasional', 'seni'),
+        ('competition', 'Lomba Kompetensi Siswa', 'nasional', NULL),
+        ('organizer', 'Pusat Prestasi Nasional', NULL, NULL),
+        ('organizer', 'Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', NULL, NULL)
+    RETURNING id, kind, name
+)
+INSERT INTO catalog_aliases (entry_id, kind, alias, normalized)
+SELECT e.id, e.kind, a.alias, catalog_normalize(a.alias)
+FROM entries e
+JOIN (VALUES
+    ('Olimpiade Sains Nasional', 'Olimpiade Sains Nasional'),
+    ('Olimpiade Sains Nasional', 'OSN'),
+    ('Olimpiade Olahraga Siswa Nasional', 'Olimpiade Olahraga Siswa Nasional'),
+    ('Olimpiade Olahraga Siswa Nasional', 'O2SN'),
+    ('Festival dan Lomba Seni Siswa Nasional', 'Festival dan Lomba Seni Siswa Nasional'),
+    ('Festival dan Lomba Seni Siswa Nasional', 'FLS2N'),
+    ('Lomba Kompetensi Siswa', 'Lomba Kompetensi Siswa'),
+    ('Lomba Kompetensi Siswa', 'LKS'),
+    ('Pusat Prestasi Nasional', 'Pusat Prestasi Nasional'),
+    ('Pusat Prestasi Nasional', 'Puspresnas'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kemendikbudristek'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kemendikbud')
+) AS a(name, alias) ON a.name = e.name;
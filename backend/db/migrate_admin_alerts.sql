@@ -0,0 +1,19 @@
+-- ============================================
+-- Add admin alerts for submissions and pending verifications
+-- ============================================
+-- For databases created before admin alerts existed. New databases created
+-- from db.sql already have the final layout. Safe to run twice. ALTER TYPE
+-- ... ADD VALUE cannot share a transaction with statements using the new
+-- value, so this file runs without BEGIN/COMMIT.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'talent_submitted';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'verification_pending_digest';
+
+-- Notifications that must go out only once per user, such as the weekly
+-- digest of a given week
+CREATE TABLE IF NOT EXISTS notification_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key)
+);
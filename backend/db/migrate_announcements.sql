@@ -0,0 +1,64 @@
+-- ============================================
+-- Add broadcast announcements
+-- ============================================
+-- For databases created before announcements existed. New databases created
+-- from db.sql already have the final layout. Safe to run twice. ALTER TYPE
+-- ... ADD VALUE cannot share a transaction with statements using the new
+-- value, so this file runs without BEGIN/COMMIT.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'announcement_published';
+
+-- Announcements from the dinas or a school. Each target list narrows the
+-- audience; an empty list does not filter
+CREATE TABLE IF NOT EXISTS announcements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Set for announcements posted by a school admin, which only reach
+    -- that school and are managed by its admins
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE,
+    target_roles user_role[] NOT NULL DEFAULT '{}',
+    target_school_ids UUID[] NOT NULL DEFAULT '{}',
+    target_school_statuses school_status[] NOT NULL DEFAULT '{}',
+    target_gtk_types gtk_type[] NOT NULL DEFAULT '{}',
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    -- Set once the audience received its in-app notification
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (expires_at IS NULL OR expires_at > publish_at)
+);
+
+CREATE TABLE IF NOT EXISTS announcement_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    caption VARCHAR(255),
+    file_url VARCHAR(500) NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    file_size BIGINT,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS announcement_reads (
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_publish_at ON announcements(publish_at DESC);
+CREATE INDEX IF NOT EXISTS idx_announcements_school_id ON announcements(school_id);
+CREATE INDEX IF NOT EXISTS idx_announcements_unnotified ON announcements(publish_at) WHERE notified_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_announcement_attachments_announcement_id ON announcement_attachments(announcement_id);
+CREATE INDEX IF NOT EXISTS idx_announcement_reads_user_id ON announcement_reads(user_id);
+
+DROP TRIGGER IF EXISTS update_announcements_updated_at ON announcements;
+CREATE TRIGGER update_announcements_updated_at
+    BEFORE UPDATE ON announcements
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,36 @@
+-- ============================================
+-- Add certificate validity and expiry reminders
+-- ============================================
+-- For databases created before certificate expiry tracking existed. New
+-- databases created from db.sql already have the final layout. Safe to run
+-- twice. ALTER TYPE ... ADD VALUE cannot share a transaction with statements
+-- using the new value, so this file runs without BEGIN/COMMIT.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'certificate_expiring';
+
+-- Certificate expiry reminders already sent, one per threshold in days
+-- before expiry; a renewed expiry date starts over
+CREATE TABLE IF NOT EXISTS certificate_expiry_notices (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    expiry_date DATE NOT NULL,
+    days_before INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (talent_id, expiry_date, days_before)
+);
+
+-- Expiry date of the certificate a talent carries in its detail, if any
+CREATE OR REPLACE FUNCTION certificate_expiry(p_detail JSONB)
+RETURNS DATE AS $$
+    SELECT CASE WHEN p_detail->>'certificate_expiry_date' ~ '^\d{4}-\d{2}-\d{2}$'
+        THEN (p_detail->>'certificate_expiry_date')::date END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Optional certificate fields on trainings and interests
+UPDATE talent_type_definitions
+SET detail_schema = jsonb_set(detail_schema, '{properties}', detail_schema->'properties' || '{
+    "certificate_number": {"type": "string", "title": "Nomor Sertifikat", "maxLength": 100},
+    "certificate_issued_date": {"type": "string", "title": "Tanggal Terbit Sertifikat", "format": "date", "x-not-future": true},
+    "certificate_expiry_date": {"type": "string", "title": "Sertifikat Berlaku Sampai", "format": "date"}
+}'::jsonb)
+WHERE code IN ('peserta_pelatihan', 'minat_bakat')
+    AND NOT detail_schema->'properties' ? 'certificate_expiry_date';
@@ -0,0 +1,33 @@
+-- ============================================
+-- Add email verification and verified email changes
+-- ============================================
+-- For databases created before email verification existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice.
+-- Existing accounts start unverified and can request a verification email.
+
+BEGIN;
+
+DO $$
+BEGIN
+    CREATE TYPE email_token_purpose AS ENUM ('verify', 'change');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    purpose email_token_purpose NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_token_hash ON email_verifications(token_hash);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_purpose ON email_verifications(user_id, purpose);
+
+COMMIT;
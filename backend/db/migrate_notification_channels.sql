@@ -0,0 +1,55 @@
+-- ============================================
+-- Add email and digest notification channels
+-- ============================================
+-- For databases created before notification preferences existed. New
+-- databases created from db.sql already have the final layout. Safe to run
+-- twice.
+
+BEGIN;
+
+-- Channels a user wants per notification type. Types without a row use
+-- the defaults of the application.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    in_app BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    digest BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type)
+);
+
+-- Rendered emails waiting to be sent, retried with backoff
+CREATE TABLE IF NOT EXISTS email_queue (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    to_address VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Notifications collected for the daily digest email
+CREATE TABLE IF NOT EXISTS notification_digest_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    message TEXT NOT NULL,
+    talent_id UUID REFERENCES talents(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_queue_due ON email_queue(next_attempt_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notification_digest_items_pending ON notification_digest_items(user_id, created_at) WHERE sent_at IS NULL;
+
+-- Emails are now queued by the notification consumer; drop deliveries left
+-- for the retired email consumer so they are not retried in vain
+DELETE FROM outbox_deliveries WHERE consumer = 'email' AND delivered_at IS NULL;
+
+COMMIT;
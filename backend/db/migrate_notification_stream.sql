@@ -0,0 +1,48 @@
+-- ============================================
+-- Add the real-time notification stream
+-- ============================================
+-- For databases created before the notification stream existed. New
+-- databases created from db.sql already have the final layout. Safe to run
+-- twice. Existing notifications get a sequence number in no particular order.
+
+BEGIN;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS seq BIGSERIAL NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_seq ON notifications(user_id, seq);
+
+-- Numbers a notification once no other transaction can still add one for
+-- the same user. The lock is held until commit, so a user's notifications
+-- commit in seq order and a stream reading seq > last never skips a row that
+-- commits late. Jobs notifying several users in one transaction go through
+-- them in user order, so their locks cannot deadlock.
+CREATE OR REPLACE FUNCTION assign_notification_seq()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('notifications:' || NEW.user_id::text, 0));
+    NEW.seq := nextval(pg_get_serial_sequence('notifications', 'seq'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS assign_notification_seq ON notifications;
+CREATE TRIGGER assign_notification_seq
+    BEFORE INSERT ON notifications
+    FOR EACH ROW
+    EXECUTE FUNCTION assign_notification_seq();
+
+CREATE OR REPLACE FUNCTION notify_notification_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('notification_changes', COALESCE(NEW.user_id, OLD.user_id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notify_notification_change ON notifications;
+CREATE TRIGGER notify_notification_change
+    AFTER INSERT OR UPDATE OF is_read OR DELETE ON notifications
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_notification_change();
+
+COMMIT;
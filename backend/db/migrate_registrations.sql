@@ -0,0 +1,62 @@
+-- ============================================
+-- Add GTK self-registration
+-- ============================================
+-- For databases created before self-registration existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice,
+-- also on databases that have the earlier layout in which a rejected
+-- registration kept its inactive account. ALTER TYPE ... ADD VALUE cannot
+-- share a transaction with statements using the new value, so this file
+-- runs without BEGIN/COMMIT.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'registration_approved';
+
+DO $$
+BEGIN
+    CREATE TYPE registration_status AS ENUM ('pending', 'approved', 'rejected');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE TABLE IF NOT EXISTS user_registrations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    status registration_status NOT NULL DEFAULT 'pending',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    rejection_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Earlier layout: keep a snapshot of the applicant and let a rejection
+-- delete the account
+ALTER TABLE user_registrations ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE user_registrations ADD COLUMN IF NOT EXISTS full_name VARCHAR(255);
+UPDATE user_registrations r
+SET email = u.email, full_name = u.full_name
+FROM users u
+WHERE u.id = r.user_id AND r.email IS NULL;
+ALTER TABLE user_registrations ALTER COLUMN email SET NOT NULL;
+ALTER TABLE user_registrations ALTER COLUMN full_name SET NOT NULL;
+
+ALTER TABLE user_registrations ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE user_registrations DROP CONSTRAINT IF EXISTS user_registrations_user_id_fkey;
+ALTER TABLE user_registrations ADD CONSTRAINT user_registrations_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Accounts of registrations rejected before are removed, freeing their
+-- email, NUPTK and NIP
+DELETE FROM users u
+USING user_registrations r
+WHERE r.user_id = u.id AND r.status = 'rejected' AND NOT u.is_active;
+
+CREATE INDEX IF NOT EXISTS idx_user_registrations_school_status ON user_registrations(school_id, status);
+
+DROP TRIGGER IF EXISTS update_user_registrations_updated_at ON user_registrations;
+CREATE TRIGGER update_user_registrations_updated_at
+    BEFORE UPDATE ON user_registrations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,26 @@
+-- ============================================
+-- Add revision requests with field-level review comments
+-- ============================================
+-- For databases created before the needs_revision status existed. New
+-- databases created from db.sql already have the final layout. Safe to run
+-- twice. Run it after migrate_talent_history.sql. ALTER TYPE ... ADD VALUE
+-- cannot share a transaction with statements using the new value, so this
+-- file runs without BEGIN/COMMIT.
+
+ALTER TYPE talent_status ADD VALUE IF NOT EXISTS 'needs_revision' BEFORE 'approved';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'talent_needs_revision';
+ALTER TYPE talent_history_action ADD VALUE IF NOT EXISTS 'revision_requested' BEFORE 'reset';
+
+-- Field-level comments left by verifiers when asking for a revision
+CREATE TABLE IF NOT EXISTS talent_review_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    field VARCHAR(100) NOT NULL,
+    comment TEXT NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    is_resolved BOOLEAN DEFAULT FALSE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_talent_review_comments_talent_id ON talent_review_comments(talent_id, created_at);
@@ -0,0 +1,52 @@
+-- ============================================
+-- Add the security event log
+-- ============================================
+-- For databases created before security events existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice.
+-- ALTER TYPE ... ADD VALUE cannot share a transaction with statements using
+-- the new value, so this file runs without BEGIN/COMMIT.
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'security_alert';
+
+DO $$
+BEGIN
+    CREATE TYPE security_event_type AS ENUM (
+        'password_changed',
+        'new_login',
+        'account_deactivated',
+        'account_reactivated',
+        'role_changed',
+        'token_reuse_detected'
+    );
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
+
+-- Set when the token is rotated; presenting a revoked token again means it was stolen
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    event_type security_event_type NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Devices/IPs a user has logged in from, used to detect new login sources
+CREATE TABLE IF NOT EXISTS login_sources (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, ip_address, user_agent)
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_security_events_type ON security_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events(created_at DESC);
@@ -0,0 +1,53 @@
+-- ============================================
+-- Move single certificate URLs into talent_attachments
+-- ============================================
+-- For databases created before talent attachments existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice.
+
+BEGIN;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'attachment_kind') THEN
+        CREATE TYPE attachment_kind AS ENUM ('certificate', 'photo', 'sk', 'other');
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS talent_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    kind attachment_kind NOT NULL DEFAULT 'other',
+    caption VARCHAR(255),
+    file_url VARCHAR(500) NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    file_size BIGINT,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_talent_attachments_talent_id ON talent_attachments(talent_id, created_at);
+
+DO $$
+DECLARE
+    detail_table TEXT;
+BEGIN
+    FOREACH detail_table IN ARRAY ARRAY['talent_competition_mentors', 'talent_competition_participants', 'talent_interests']
+    LOOP
+        IF EXISTS (
+            SELECT 1 FROM information_schema.columns
+            WHERE table_name = detail_table AND column_name = 'certificate_url'
+        ) THEN
+            EXECUTE format(
+                'INSERT INTO talent_attachments (talent_id, kind, file_url, uploaded_by, created_at)
+                 SELECT d.talent_id, ''certificate'', d.certificate_url, t.user_id, t.created_at
+                 FROM %I d JOIN talents t ON t.id = d.talent_id
+                 WHERE d.certificate_url IS NOT NULL AND d.certificate_url <> ''''',
+                detail_table
+            );
+            EXECUTE format('ALTER TABLE %I DROP COLUMN certificate_url', detail_table);
+        END IF;
+    END LOOP;
+END $$;
+
+COMMIT;
@@ -0,0 +1,255 @@
+-- ============================================
+-- Add the competition and organizer catalog
+-- ============================================
+-- For databases created before the catalog existed. New databases created
+-- from db.sql already have the final layout. Safe to run twice; the catalog
+-- is only seeded when empty.
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'catalog_kind') THEN
+        CREATE TYPE catalog_kind AS ENUM ('competition', 'organizer');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'catalog_queue_status') THEN
+        CREATE TYPE catalog_queue_status AS ENUM ('pending', 'resolved', 'dismissed');
+    END IF;
+END $$;
+
+ALTER TABLE talent_type_definitions ADD COLUMN IF NOT EXISTS catalog_competition_field VARCHAR(100);
+
+-- Curated competitions and organizers. Free-text names in talent details are
+-- linked to an entry through its aliases, compared after catalog_normalize().
+CREATE TABLE IF NOT EXISTS catalog_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind catalog_kind NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    -- Prefilled in the talent form when a competition is picked
+    default_level competition_level,
+    default_field talent_field,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id, kind),
+    CHECK (kind = 'competition' OR (default_level IS NULL AND default_field IS NULL))
+);
+
+-- Every spelling of an entry, its own name included. A normalized spelling
+-- belongs to at most one entry of a kind.
+CREATE TABLE IF NOT EXISTS catalog_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entry_id UUID NOT NULL,
+    kind catalog_kind NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    normalized VARCHAR(255) NOT NULL CHECK (normalized <> ''),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (entry_id, kind) REFERENCES catalog_entries(id, kind) ON DELETE CASCADE,
+    UNIQUE (kind, normalized)
+);
+
+-- Submitted names no alias matches, waiting for a super admin to map them to
+-- an entry or dismiss them
+CREATE TABLE IF NOT EXISTS catalog_queue (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind catalog_kind NOT NULL,
+    -- The name as first submitted
+    raw_name VARCHAR(255) NOT NULL,
+    normalized VARCHAR(255) NOT NULL,
+    status catalog_queue_status NOT NULL DEFAULT 'pending',
+    entry_id UUID REFERENCES catalog_entries(id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, normalized)
+);
+
+-- Catalog entry a talent's competition or organizer name resolves to, kept
+-- current by triggers
+CREATE TABLE IF NOT EXISTS talent_catalog_links (
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    kind catalog_kind NOT NULL,
+    entry_id UUID NOT NULL,
+    FOREIGN KEY (entry_id, kind) REFERENCES catalog_entries(id, kind) ON DELETE CASCADE,
+    PRIMARY KEY (talent_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalog_entries_kind ON catalog_entries(kind, is_active);
+CREATE INDEX IF NOT EXISTS idx_catalog_aliases_entry_id ON catalog_aliases(entry_id);
+CREATE INDEX IF NOT EXISTS idx_catalog_queue_status ON catalog_queue(kind, status);
+CREATE INDEX IF NOT EXISTS idx_talent_catalog_links_entry_id ON talent_catalog_links(entry_id);
+
+DROP TRIGGER IF EXISTS update_catalog_entries_updated_at ON catalog_entries;
+CREATE TRIGGER update_catalog_entries_updated_at
+    BEFORE UPDATE ON catalog_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Catalog matching key: lower case, no accents, letters and digits only, so
+-- "O.S.N." and "OSN" compare equal. NULL when nothing is left.
+CREATE OR REPLACE FUNCTION catalog_normalize(p_name TEXT)
+RETURNS TEXT AS $$
+    SELECT NULLIF(regexp_replace(lower(unaccent(COALESCE(p_name, ''))), '[^a-z0-9]+', '', 'g'), '')
+$$ LANGUAGE sql STABLE;
+
+-- Entry a name resolves to. An unknown name is queued for normalization
+-- unless p_queue is false.
+CREATE OR REPLACE FUNCTION catalog_match(p_kind catalog_kind, p_name TEXT, p_queue BOOLEAN)
+RETURNS UUID AS $$
+DECLARE
+    v_normalized TEXT := catalog_normalize(p_name);
+    v_entry_id UUID;
+BEGIN
+    IF v_normalized IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT entry_id INTO v_entry_id FROM catalog_aliases
+    WHERE kind = p_kind AND normalized = v_normalized;
+
+    IF v_entry_id IS NULL AND p_queue THEN
+        -- A resolved item whose alias was removed goes back to the queue
+        INSERT INTO catalog_queue (kind, raw_name, normalized)
+        VALUES (p_kind, left(btrim(p_name), 255), v_normalized)
+        ON CONFLICT (kind, normalized) DO UPDATE
+            SET status = 'pending', entry_id = NULL, resolved_by = NULL, resolved_at = NULL
+            WHERE catalog_queue.status = 'resolved';
+    END IF;
+    RETURN v_entry_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Relinks one talent to the catalog. Drafts are linked but never queued.
+CREATE OR REPLACE FUNCTION link_talent_catalog(p_talent_id UUID, p_talent_type VARCHAR, p_detail JSONB, p_status talent_status)
+RETURNS VOID AS $$
+DECLARE
+    v_def talent_type_definitions%ROWTYPE;
+    v_queue BOOLEAN := p_status <> 'draft';
+BEGIN
+    SELECT * INTO v_def FROM talent_type_definitions WHERE code = p_talent_type;
+
+    DELETE FROM talent_catalog_links WHERE talent_id = p_talent_id;
+    INSERT INTO talent_catalog_links (talent_id, kind, entry_id)
+    SELECT p_talent_id, m.kind, m.entry_id
+    FROM (VALUES
+        ('competition'::catalog_kind, catalog_match('competition', p_detail->>v_def.catalog_competition_field, v_queue)),
+        ('organizer'::catalog_kind, catalog_match('organizer', p_detail->>v_def.match_organizer_field, v_queue))
+    ) AS m(kind, entry_id)
+    WHERE m.entry_id IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_talent_catalog_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM link_talent_catalog(NEW.id, NEW.talent_type, NEW.detail, NEW.status);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_talents_catalog_links ON talents;
+CREATE TRIGGER update_talents_catalog_links
+    AFTER INSERT OR UPDATE OF detail, talent_type, status ON talents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_talent_catalog_links();
+
+-- A new alias links the talents already carrying that name and resolves its
+-- queue item; a removed alias unlinks them
+CREATE OR REPLACE FUNCTION refresh_alias_talent_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM talent_catalog_links l
+        USING talents t, talent_type_definitions d
+        WHERE l.talent_id = t.id AND d.code = t.talent_type
+            AND l.kind = OLD.kind AND l.entry_id = OLD.entry_id
+            AND catalog_normalize(t.detail->>CASE OLD.kind
+                WHEN 'competition' THEN d.catalog_competition_field
+                ELSE d.match_organizer_field END) = OLD.normalized;
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO talent_catalog_links (talent_id, kind, entry_id)
+    SELECT t.id, NEW.kind, NEW.entry_id
+    FROM talents t
+    JOIN talent_type_definitions d ON d.code = t.talent_type
+    WHERE catalog_normalize(t.detail->>CASE NEW.kind
+            WHEN 'competition' THEN d.catalog_competition_field
+            ELSE d.match_organizer_field END) = NEW.normalized
+    ON CONFLICT (talent_id, kind) DO NOTHING;
+
+    UPDATE catalog_queue SET status = 'resolved', entry_id = NEW.entry_id, resolved_at = CURRENT_TIMESTAMP
+    WHERE kind = NEW.kind AND normalized = NEW.normalized AND status = 'pending';
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS refresh_talent_links_on_alias ON catalog_aliases;
+CREATE TRIGGER refresh_talent_links_on_alias
+    AFTER INSERT OR DELETE ON catalog_aliases
+    FOR EACH ROW
+    EXECUTE FUNCTION refresh_alias_talent_links();
+
+-- Pointing a type at other detail fields relinks all its talents
+CREATE OR REPLACE FUNCTION refresh_type_talent_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM link_talent_catalog(id, talent_type, detail, status)
+    FROM talents WHERE talent_type = NEW.code;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS refresh_talent_links_on_catalog_fields ON talent_type_definitions;
+CREATE TRIGGER refresh_talent_links_on_catalog_fields
+    AFTER UPDATE OF catalog_competition_field, match_organizer_field ON talent_type_definitions
+    FOR EACH ROW
+    WHEN (OLD.catalog_competition_field IS DISTINCT FROM NEW.catalog_competition_field
+        OR OLD.match_organizer_field IS DISTINCT FROM NEW.match_organizer_field)
+    EXECUTE FUNCTION refresh_type_talent_links();
+
+-- Common competitions and organizers; the rest come in through the queue
+WITH entries AS (
+    INSERT INTO catalog_entries (kind, name, default_level, default_field)
+    SELECT v.kind::catalog_kind, v.name, v.default_level::competition_level, v.default_field::talent_field FROM (VALUES
+        ('competition', 'Olimpiade Sains Nasional', 'nasional', 'akademik'),
+        ('competition', 'Olimpiade Olahraga Siswa Nasional', 'nasional', 'olahraga'),
+        ('competition', 'Festival dan Lomba Seni Siswa Nasional', 'nasional', 'seni'),
+        ('competition', 'Lomba Kompetensi Siswa', 'nasional', NULL),
+        ('organizer', 'Pusat Prestasi Nasional', NULL, NULL),
+        ('organizer', 'Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', NULL, NULL)
+    ) AS v(kind, name, default_level, default_field)
+    WHERE NOT EXISTS (SELECT 1 FROM catalog_entries)
+    RETURNING id, kind, name
+)
+INSERT INTO catalog_aliases (entry_id, kind, alias, normalized)
+SELECT e.id, e.kind, a.alias, catalog_normalize(a.alias)
+FROM entries e
+JOIN (VALUES
+    ('Olimpiade Sains Nasional', 'Olimpiade Sains Nasional'),
+    ('Olimpiade Sains Nasional', 'OSN'),
+    ('Olimpiade Olahraga Siswa Nasional', 'Olimpiade Olahraga Siswa Nasional'),
+    ('Olimpiade Olahraga Siswa Nasional', 'O2SN'),
+    ('Festival dan Lomba Seni Siswa Nasional', 'Festival dan Lomba Seni Siswa Nasional'),
+    ('Festival dan Lomba Seni Siswa Nasional', 'FLS2N'),
+    ('Lomba Kompetensi Siswa', 'Lomba Kompetensi Siswa'),
+    ('Lomba Kompetensi Siswa', 'LKS'),
+    ('Pusat Prestasi Nasional', 'Pusat Prestasi Nasional'),
+    ('Pusat Prestasi Nasional', 'Puspresnas'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kemendikbudristek'),
+    ('Kementerian Pendidikan, Kebudayaan, Riset, dan Teknologi', 'Kemendikbud')
+) AS a(name, alias) ON a.name = e.name;
+
+-- Built-in competition types name their competition field; this relinks
+-- their talents through refresh_talent_links_on_catalog_fields
+UPDATE talent_type_definitions SET catalog_competition_field = 'competition_name'
+WHERE code IN ('pembimbing_lomba', 'peserta_lomba') AND catalog_competition_field IS NULL;
+
+-- Link every other talent and queue the names the catalog does not know
+SELECT link_talent_catalog(id, talent_type, detail, status) FROM talents;
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- ============================================
+-- Add draft talents and submission withdrawal
+-- ============================================
+-- For databases created before talent drafts existed. New databases created
+-- from db.sql already have these values. Safe to run twice. ALTER TYPE ...
+-- ADD VALUE cannot share a transaction with statements using the new value,
+-- so this file runs without BEGIN/COMMIT.
+
+ALTER TYPE talent_status ADD VALUE IF NOT EXISTS 'draft' BEFORE 'pending';
+ALTER TYPE talent_history_action ADD VALUE IF NOT EXISTS 'drafted';
+ALTER TYPE talent_history_action ADD VALUE IF NOT EXISTS 'withdrawn';
@@ -0,0 +1,46 @@
+-- ============================================
+-- Add talent status history
+-- ============================================
+-- For databases created before talent status history existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice. Run
+-- it before migrate_talent_drafts.sql, which extends talent_history_action.
+
+BEGIN;
+
+DO $$
+BEGIN
+    CREATE TYPE talent_history_action AS ENUM (
+        'submitted',
+        'edited',
+        'school_approved',
+        'approved',
+        'rejected',
+        'reset'
+    );
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
+
+-- Every status transition of a talent, kept after the talent is edited
+CREATE TABLE IF NOT EXISTS talent_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    action talent_history_action NOT NULL,
+    from_status talent_status,
+    to_status talent_status NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    -- Talent detail as it was when the transition happened
+    detail_snapshot JSONB,
+    -- Shared by every transition made by one batch verification
+    batch_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tables created from db.sql before batch verification existed
+ALTER TABLE talent_status_history ADD COLUMN IF NOT EXISTS batch_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_talent_status_history_talent_id ON talent_status_history(talent_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_talent_status_history_batch_id ON talent_status_history(batch_id) WHERE batch_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,57 @@
+-- ============================================
+-- Add the scoring scheme behind the leaderboards
+-- ============================================
+-- For databases created before leaderboards existed. New databases created
+-- from db.sql already have the final layout. Safe to run twice; an existing
+-- scheme is kept.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS scoring_scheme (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    scheme JSONB NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Points of one talent under a scoring scheme: flat points of its type,
+-- points per training day (capped at max_days) and level points scaled by
+-- the first achievement pattern that matches
+CREATE OR REPLACE FUNCTION talent_score(p_scheme JSONB, p_talent_type VARCHAR, p_detail JSONB)
+RETURNS NUMERIC AS $$
+    SELECT COALESCE((r->>'points')::numeric, 0)
+        + COALESCE((r->>'points_per_day')::numeric, 0)
+            * LEAST(days, COALESCE(NULLIF((r->>'max_days')::numeric, 0), days))
+        + COALESCE((r->>'level_factor')::numeric, 0)
+            * COALESCE((p_scheme->'level_points'->>(p_detail->>'level'))::numeric, 0)
+            * COALESCE((
+                SELECT (m.value->>'multiplier')::numeric
+                FROM jsonb_array_elements(p_scheme->'achievement_multipliers') WITH ORDINALITY AS m(value, position)
+                WHERE p_detail->>'achievement' ~* (m.value->>'pattern')
+                ORDER BY m.position
+                LIMIT 1
+            ), (p_scheme->>'default_achievement_multiplier')::numeric, 1)
+    FROM (SELECT p_scheme->'types'->p_talent_type AS r,
+            COALESCE((p_detail->>'duration_days')::numeric, 0) AS days) AS rule
+$$ LANGUAGE sql IMMUTABLE;
+
+INSERT INTO scoring_scheme (scheme) VALUES ('{
+    "level_points": {"kota": 10, "provinsi": 20, "nasional": 40, "internasional": 80},
+    "achievement_multipliers": [
+        {"pattern": "juara\\s*(umum|1|i|satu)([^0-9a-z]|$)", "multiplier": 3},
+        {"pattern": "juara\\s*(2|ii|dua)([^0-9a-z]|$)", "multiplier": 2.5},
+        {"pattern": "juara\\s*(3|iii|tiga)([^0-9a-z]|$)", "multiplier": 2},
+        {"pattern": "harapan", "multiplier": 1.5},
+        {"pattern": "finalis", "multiplier": 1.25}
+    ],
+    "default_achievement_multiplier": 1,
+    "types": {
+        "peserta_pelatihan": {"points": 5, "points_per_day": 1, "max_days": 30},
+        "pembimbing_lomba": {"level_factor": 1},
+        "peserta_lomba": {"level_factor": 1},
+        "minat_bakat": {"points": 5}
+    }
+}')
+ON CONFLICT (id) DO NOTHING;
+
+COMMIT;
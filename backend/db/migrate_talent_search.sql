@@ -0,0 +1,92 @@
+-- ============================================
+-- Add full-text search over talents
+-- ============================================
+-- For databases created before talent search existed. New databases created
+-- from db.sql already have the final layout. Safe to run twice.
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'sipodi_search') THEN
+        CREATE TEXT SEARCH CONFIGURATION sipodi_search (COPY = pg_catalog.indonesian);
+        ALTER TEXT SEARCH CONFIGURATION sipodi_search
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, indonesian_stem;
+    END IF;
+END $$;
+
+ALTER TABLE talents ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION talent_search_text(p_detail JSONB)
+RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(v #>> '{}', ' '), '')
+    FROM jsonb_path_query(p_detail, 'strict $.**') AS v
+    WHERE jsonb_typeof(v) = 'string'
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION talent_search_vector(p_talent_type VARCHAR, p_detail JSONB, p_user_id UUID)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('sipodi_search', COALESCE(p_detail->>d.match_name_field, '')), 'A')
+        || setweight(to_tsvector('sipodi_search', COALESCE(u.full_name, '')), 'B')
+        || setweight(to_tsvector('sipodi_search', talent_search_text(p_detail)), 'C')
+    FROM (SELECT 1) AS one
+    LEFT JOIN talent_type_definitions d ON d.code = p_talent_type
+    LEFT JOIN users u ON u.id = p_user_id
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION update_talent_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = talent_search_vector(NEW.talent_type, NEW.detail, NEW.user_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_talents_search_vector ON talents;
+CREATE TRIGGER update_talents_search_vector
+    BEFORE INSERT OR UPDATE OF detail, talent_type, user_id ON talents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_talent_search_vector();
+
+CREATE OR REPLACE FUNCTION refresh_user_talent_search()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE talents SET search_vector = talent_search_vector(talent_type, detail, user_id)
+    WHERE user_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS refresh_talent_search_on_user_rename ON users;
+CREATE TRIGGER refresh_talent_search_on_user_rename
+    AFTER UPDATE OF full_name ON users
+    FOR EACH ROW
+    WHEN (OLD.full_name IS DISTINCT FROM NEW.full_name)
+    EXECUTE FUNCTION refresh_user_talent_search();
+
+CREATE OR REPLACE FUNCTION refresh_type_talent_search()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE talents SET search_vector = talent_search_vector(talent_type, detail, user_id)
+    WHERE talent_type = NEW.code;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS refresh_talent_search_on_name_field ON talent_type_definitions;
+CREATE TRIGGER refresh_talent_search_on_name_field
+    AFTER UPDATE OF match_name_field ON talent_type_definitions
+    FOR EACH ROW
+    WHEN (OLD.match_name_field IS DISTINCT FROM NEW.match_name_field)
+    EXECUTE FUNCTION refresh_type_talent_search();
+
+-- Backfill without touching updated_at
+ALTER TABLE talents DISABLE TRIGGER update_talents_updated_at;
+UPDATE talents SET search_vector = talent_search_vector(talent_type, detail, user_id);
+ALTER TABLE talents ENABLE TRIGGER update_talents_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_talents_search ON talents USING gin (search_vector);
+
+COMMIT;
@@ -0,0 +1,202 @@
+-- ============================================
+-- Move talent details into the talent type registry
+-- ============================================
+-- For databases created before the talent type registry existed. Copies the
+-- rows of the four detail tables into talents.detail, turns talent_type into
+-- a reference to talent_type_definitions and replaces the detail tables with
+-- views of the same name. New databases created from db.sql already have the
+-- final layout. Safe to run twice.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS talent_type_definitions (
+    code VARCHAR(50) PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    detail_schema JSONB NOT NULL,
+    match_name_field VARCHAR(100),
+    match_organizer_field VARCHAR(100),
+    match_date_field VARCHAR(100),
+    is_builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_talent_type_definitions_updated_at ON talent_type_definitions;
+CREATE TRIGGER update_talent_type_definitions_updated_at
+    BEFORE UPDATE ON talent_type_definitions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO talent_type_definitions (code, name, detail_schema, match_name_field, match_organizer_field, match_date_field, is_builtin)
+VALUES (
+    'peserta_pelatihan', 'Peserta Pelatihan', '{
+        "type": "object",
+        "required": ["activity_name", "organizer", "start_date", "duration_days"],
+        "properties": {
+            "activity_name": {"type": "string", "title": "Nama Kegiatan", "pattern": "\\S", "x-messages": {"required": "Nama kegiatan wajib diisi", "pattern": "Nama kegiatan wajib diisi"}, "maxLength": 255},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "start_date": {"type": "string", "title": "Tanggal Mulai", "format": "date", "x-not-future": true, "minLength": 1, "x-messages": {"required": "Tanggal mulai wajib diisi", "minLength": "Tanggal mulai wajib diisi"}},
+            "duration_days": {"type": "integer", "title": "Jangka Waktu (hari)", "exclusiveMinimum": 0, "x-messages": {"required": "Jangka waktu harus lebih dari 0", "exclusiveMinimum": "Jangka waktu harus lebih dari 0"}}
+        }
+    }',
+    'activity_name', 'organizer', 'start_date', TRUE
+), (
+    'pembimbing_lomba', 'Pembimbing Lomba', '{
+        "type": "object",
+        "required": ["competition_name", "level", "organizer", "field", "achievement"],
+        "properties": {
+            "competition_name": {"type": "string", "title": "Nama Lomba", "pattern": "\\S", "x-messages": {"required": "Nama lomba wajib diisi", "pattern": "Nama lomba wajib diisi"}, "maxLength": 255},
+            "level": {"type": "string", "title": "Jenjang", "enum": ["kota", "provinsi", "nasional", "internasional"], "minLength": 1, "x-messages": {"required": "Jenjang wajib diisi", "minLength": "Jenjang wajib diisi", "enum": "Jenjang harus kota, provinsi, nasional, atau internasional"}},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "field": {"type": "string", "title": "Bidang", "enum": ["akademik", "inovasi", "teknologi", "sosial", "olahraga", "seni", "kepemimpinan"], "minLength": 1, "x-messages": {"required": "Bidang wajib diisi", "minLength": "Bidang wajib diisi", "enum": "Bidang tidak valid"}},
+            "achievement": {"type": "string", "title": "Prestasi", "pattern": "\\S", "x-messages": {"required": "Prestasi wajib diisi", "pattern": "Prestasi wajib diisi"}}
+        }
+    }',
+    'competition_name', 'organizer', NULL, TRUE
+), (
+    'peserta_lomba', 'Peserta Lomba', '{
+        "type": "object",
+        "required": ["competition_name", "level", "organizer", "field", "start_date", "duration_days", "competition_field", "achievement"],
+        "properties": {
+            "competition_name": {"type": "string", "title": "Nama Lomba", "pattern": "\\S", "x-messages": {"required": "Nama lomba wajib diisi", "pattern": "Nama lomba wajib diisi"}, "maxLength": 255},
+            "level": {"type": "string", "title": "Jenjang", "enum": ["kota", "provinsi", "nasional", "internasional"], "minLength": 1, "x-messages": {"required": "Jenjang wajib diisi", "minLength": "Jenjang wajib diisi", "enum": "Jenjang harus kota, provinsi, nasional, atau internasional"}},
+            "organizer": {"type": "string", "title": "Penyelenggara", "pattern": "\\S", "x-messages": {"required": "Penyelenggara wajib diisi", "pattern": "Penyelenggara wajib diisi"}, "maxLength": 255},
+            "field": {"type": "string", "title": "Bidang", "enum": ["akademik", "inovasi", "teknologi", "sosial", "olahraga", "seni", "kepemimpinan"], "minLength": 1, "x-messages": {"required": "Bidang wajib diisi", "minLength": "Bidang wajib diisi", "enum": "Bidang tidak valid"}},
+            "start_date": {"type": "string", "title": "Tanggal Mulai", "format": "date", "x-not-future": true, "minLength": 1, "x-messages": {"required": "Tanggal mulai wajib diisi", "minLength": "Tanggal mulai wajib diisi"}},
+            "duration_days": {"type": "integer", "title": "Jangka Waktu (hari)", "exclusiveMinimum": 0, "x-messages": {"required": "Jangka waktu harus lebih dari 0", "exclusiveMinimum": "Jangka waktu harus lebih dari 0"}},
+            "competition_field": {"type": "string", "title": "Bidang Lomba", "pattern": "\\S", "x-messages": {"required": "Bidang lomba wajib diisi", "pattern": "Bidang lomba wajib diisi"}, "maxLength": 255},
+            "achievement": {"type": "string", "title": "Prestasi", "pattern": "\\S", "x-messages": {"required": "Prestasi wajib diisi", "pattern": "Prestasi wajib diisi"}}
+        }
+    }',
+    'competition_name', 'organizer', 'start_date', TRUE
+), (
+    'minat_bakat', 'Minat/Bakat', '{
+        "type": "object",
+        "required": ["interest_name", "description"],
+        "properties": {
+            "interest_name": {"type": "string", "title": "Nama Minat/Bakat", "pattern": "\\S", "x-messages": {"required": "Nama minat/bakat wajib diisi", "pattern": "Nama minat/bakat wajib diisi"}, "maxLength": 255},
+            "description": {"type": "string", "title": "Deskripsi", "pattern": "\\S", "x-messages": {"required": "Deskripsi wajib diisi", "pattern": "Deskripsi wajib diisi"}}
+        }
+    }',
+    'interest_name', NULL, NULL, TRUE
+)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE talents ADD COLUMN IF NOT EXISTS detail JSONB NOT NULL DEFAULT '{}';
+
+DROP VIEW IF EXISTS talent_match_keys;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.tables
+        WHERE table_name = 'talent_trainings' AND table_type = 'BASE TABLE'
+    ) THEN
+        UPDATE talents t SET detail = jsonb_build_object(
+            'activity_name', d.activity_name,
+            'organizer', d.organizer,
+            'start_date', to_char(d.start_date, 'YYYY-MM-DD'),
+            'duration_days', d.duration_days
+        ) FROM talent_trainings d WHERE d.talent_id = t.id;
+
+        UPDATE talents t SET detail = jsonb_build_object(
+            'competition_name', d.competition_name,
+            'level', d.level,
+            'organizer', d.organizer,
+            'field', d.field,
+            'achievement', d.achievement
+        ) FROM talent_competition_mentors d WHERE d.talent_id = t.id;
+
+        UPDATE talents t SET detail = jsonb_build_object(
+            'competition_name', d.competition_name,
+            'level', d.level,
+            'organizer', d.organizer,
+            'field', d.field,
+            'start_date', to_char(d.start_date, 'YYYY-MM-DD'),
+            'duration_days', d.duration_days,
+            'competition_field', d.competition_field,
+            'achievement', d.achievement
+        ) FROM talent_competition_participants d WHERE d.talent_id = t.id;
+
+        UPDATE talents t SET detail = jsonb_build_object(
+            'interest_name', d.interest_name,
+            'description', d.description
+        ) FROM talent_interests d WHERE d.talent_id = t.id;
+
+        DROP TABLE talent_trainings, talent_competition_mentors,
+            talent_competition_participants, talent_interests;
+    END IF;
+END $$;
+
+DROP FUNCTION IF EXISTS reset_talent_status_on_update() CASCADE;
+
+-- Version snapshots used to carry row identifiers and timestamps for dates
+UPDATE talent_versions SET detail = detail - 'id' - 'talent_id'
+WHERE detail ? 'id' OR detail ? 'talent_id';
+UPDATE talent_versions SET detail = jsonb_set(detail, '{start_date}', to_jsonb(left(detail->>'start_date', 10)))
+WHERE length(detail->>'start_date') > 10;
+
+-- On a second run the detail tables are already views over talents, which
+-- would block the type change below. They are recreated further down. The
+-- drop comes after the copy above, since on the first run these names are
+-- still tables.
+DROP VIEW IF EXISTS talent_trainings, talent_competition_mentors,
+    talent_competition_participants, talent_interests;
+
+ALTER TABLE talents ALTER COLUMN talent_type TYPE VARCHAR(50) USING talent_type::text;
+ALTER TABLE talents DROP CONSTRAINT IF EXISTS talents_talent_type_fkey;
+ALTER TABLE talents ADD CONSTRAINT talents_talent_type_fkey
+    FOREIGN KEY (talent_type) REFERENCES talent_type_definitions(code);
+DROP TYPE IF EXISTS talent_type;
+
+CREATE INDEX IF NOT EXISTS idx_talents_detail ON talents USING gin (detail jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_talents_detail_level ON talents ((detail->>'level'));
+
+CREATE VIEW talent_trainings AS
+    SELECT id AS talent_id,
+        detail->>'activity_name' AS activity_name,
+        detail->>'organizer' AS organizer,
+        (detail->>'start_date')::date AS start_date,
+        (detail->>'duration_days')::integer AS duration_days
+    FROM talents WHERE talent_type = 'peserta_pelatihan';
+
+CREATE VIEW talent_competition_mentors AS
+    SELECT id AS talent_id,
+        detail->>'competition_name' AS competition_name,
+        (detail->>'level')::competition_level AS level,
+        detail->>'organizer' AS organizer,
+        (detail->>'field')::talent_field AS field,
+        detail->>'achievement' AS achievement
+    FROM talents WHERE talent_type = 'pembimbing_lomba';
+
+CREATE VIEW talent_competition_participants AS
+    SELECT id AS talent_id,
+        detail->>'competition_name' AS competition_name,
+        (detail->>'level')::competition_level AS level,
+        detail->>'organizer' AS organizer,
+        (detail->>'field')::talent_field AS field,
+        (detail->>'start_date')::date AS start_date,
+        (detail->>'duration_days')::integer AS duration_days,
+        detail->>'competition_field' AS competition_field,
+        detail->>'achievement' AS achievement
+    FROM talents WHERE talent_type = 'peserta_lomba';
+
+CREATE VIEW talent_interests AS
+    SELECT id AS talent_id,
+        detail->>'interest_name' AS interest_name,
+        detail->>'description' AS description
+    FROM talents WHERE talent_type = 'minat_bakat';
+
+CREATE VIEW talent_match_keys AS
+    SELECT t.id AS talent_id,
+        t.detail->>d.match_name_field AS name,
+        t.detail->>d.match_organizer_field AS organizer,
+        CASE WHEN t.detail->>d.match_date_field ~ '^\d{4}-\d{2}-\d{2}$'
+            THEN (t.detail->>d.match_date_field)::date END AS event_date
+    FROM talents t
+    JOIN talent_type_definitions d ON d.code = t.talent_type
+    WHERE d.match_name_field IS NOT NULL;
+
+COMMIT;
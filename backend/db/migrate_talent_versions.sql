@@ -0,0 +1,52 @@
+-- ============================================
+-- Add talent detail versions
+-- ============================================
+-- For databases created before talent versions existed. New databases
+-- created from db.sql already have the final layout. Safe to run twice. Run
+-- it before migrate_talent_type_registry.sql, which rewrites the snapshots.
+
+BEGIN;
+
+-- Immutable snapshot of the talent detail, one per create/update
+CREATE TABLE IF NOT EXISTS talent_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    talent_id UUID NOT NULL REFERENCES talents(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    detail JSONB NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Set when this version was the one finally approved
+    approved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(talent_id, version)
+);
+
+-- Existing talents start at version 1 with their current detail, approved
+-- if the talent is, so later edits can be compared with it. The detail
+-- lives in talents.detail or, before the type registry, in the per-type
+-- tables.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'talents' AND column_name = 'detail'
+    ) THEN
+        INSERT INTO talent_versions (talent_id, version, detail, created_by, approved_at, created_at)
+        SELECT t.id, 1, t.detail, t.user_id,
+            CASE WHEN t.status = 'approved' THEN t.verified_at END, t.updated_at
+        FROM talents t
+        ON CONFLICT (talent_id, version) DO NOTHING;
+    ELSE
+        INSERT INTO talent_versions (talent_id, version, detail, created_by, approved_at, created_at)
+        SELECT t.id, 1,
+            COALESCE(to_jsonb(tr), to_jsonb(cm), to_jsonb(cp), to_jsonb(ti), '{}'::jsonb),
+            t.user_id, CASE WHEN t.status = 'approved' THEN t.verified_at END, t.updated_at
+        FROM talents t
+        LEFT JOIN talent_trainings tr ON tr.talent_id = t.id
+        LEFT JOIN talent_competition_mentors cm ON cm.talent_id = t.id
+        LEFT JOIN talent_competition_participants cp ON cp.talent_id = t.id
+        LEFT JOIN talent_interests ti ON ti.talent_id = t.id
+        ON CONFLICT (talent_id, version) DO NOTHING;
+    END IF;
+END $$;
+
+COMMIT;
@@ -3,23 +3,32 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	MinIO    MinIOConfig
-	CORS     CORSConfig
+	App          AppConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	MinIO        MinIOConfig
+	CORS         CORSConfig
+	Mail         MailConfig
+	Verification VerificationConfig
+	PKB          PKBConfig
+	Certificate  CertificateConfig
+	Events       EventsConfig
+	Notification NotificationConfig
+	Announcement AnnouncementConfig
 }
 
 type AppConfig struct {
-	Env  string
-	Port string
-	Name string
+	Env         string
+	Port        string
+	Name        string
+	FrontendURL string
 }
 
 type DatabaseConfig struct {
@@ -35,6 +44,9 @@ type JWTConfig struct {
 	Secret        string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
+	// StreamTicketExpiry is how long a notification stream ticket can be
+	// used to open the stream.
+	StreamTicketExpiry time.Duration
 }
 
 type MinIOConfig struct {
@@ -50,14 +62,117 @@ type CORSConfig struct {
 	Origins string
 }
 
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type VerificationConfig struct {
+	// EndorsementLevel is the lowest competition level that needs endorsement
+	// by super_admin after school approval. "none" disables the second stage.
+	EndorsementLevel string
+	// ClaimTTL is how long a verifier's claim on a queue item lasts.
+	ClaimTTL time.Duration
+	// SLAWarningDays is how long an item may wait before it is highlighted
+	// and its verifiers are reminded.
+	SLAWarningDays int
+	// SLAEscalationDays is how long an item may wait before it is escalated
+	// to super_admin.
+	SLAEscalationDays int
+	// SLACheckInterval is how often the SLA job scans the queues.
+	SLACheckInterval time.Duration
+	// BatchLimit is the most talents one batch approve or reject may touch.
+	BatchLimit int
+	// PendingDigestDay is the weekday (e.g. "monday") verifiers receive the
+	// weekly summary of their queue, at the notification digest hour. "none"
+	// disables the summary.
+	PendingDigestDay string
+}
+
+type PKBConfig struct {
+	// JPPerDay converts a training day into lesson hours (jam pelajaran).
+	JPPerDay int
+	// AnnualTargetJP is the professional development load each GTK should
+	// reach in a year.
+	AnnualTargetJP int
+}
+
+type CertificateConfig struct {
+	// ReminderDays are the days before expiry at which the GTK and their
+	// school admins are reminded of an expiring certificate.
+	ReminderDays []int
+	// CheckInterval is how often the expiry job scans the certificates.
+	CheckInterval time.Duration
+}
+
+type NotificationConfig struct {
+	// StreamHeartbeat is how often an idle notification stream sends a
+	// comment, keeping proxies from closing it and detecting gone clients.
+	StreamHeartbeat time.Duration
+	// EmailQueueInterval is how often queued emails are sent.
+	EmailQueueInterval time.Duration
+	// EmailMaxAttempts is how often an email is tried before it is given up.
+	EmailMaxAttempts int
+	// EmailBatchSize caps the emails sent per queue run.
+	EmailBatchSize int
+	// DigestHour is the hour of the day, in Timezone, the daily digest goes
+	// out.
+	DigestHour int
+	// Timezone is the IANA zone digest hours and times are shown in.
+	Timezone string
+	// DigestCheckInterval is how often the digest job looks for digests due.
+	DigestCheckInterval time.Duration
+}
+
+type AnnouncementConfig struct {
+	// PublishInterval is how often announcements that reached their publish
+	// time are sent out to their audience.
+	PublishInterval time.Duration
+}
+
+type EventsConfig struct {
+	// DispatchInterval is how often the dispatcher delivers outbox events.
+	DispatchInterval time.Duration
+	// MaxAttempts is how often a delivery is tried before it is given up.
+	MaxAttempts int
+	// BatchSize caps the deliveries handled per dispatcher run.
+	BatchSize int
+	// Retention is how long events are kept in the outbox once delivered.
+	Retention time.Duration
+	// WebhookURL receives every event as a signed POST. Empty disables the
+	// webhook consumer.
+	WebhookURL string
+	// WebhookSecret signs the webhook body with HMAC-SHA256.
+	WebhookSecret string
+}
+
+// Location returns the notification time zone, falling back to WIB when the
+// zone is unknown.
+func (c NotificationConfig) Location() *time.Location {
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return location
+}
+
+// Enabled reports whether an SMTP server has been configured.
+func (c MailConfig) Enabled() bool {
+	return c.Host != ""
+}
+
 func Load() *Config {
 	godotenv.Load()
 
 	return &Config{
 		App: AppConfig{
-			Env:  getEnv("APP_ENV", "development"),
-			Port: getEnv("APP_PORT", "8080"),
-			Name: getEnv("APP_NAME", "SIPODI"),
+			Env:         getEnv("APP_ENV", "development"),
+			Port:        getEnv("APP_PORT", "8080"),
+			Name:        getEnv("APP_NAME", "SIPODI"),
+			FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -68,9 +183,10 @@ func Load() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:        getEnv("JWT_SECRET", "secret"),
-			AccessExpiry:  parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m")),
-			RefreshExpiry: parseDuration(getEnv("JWT_REFRESH_EXPIRY", "168h")),
+			Secret:             getEnv("JWT_SECRET", "secret"),
+			AccessExpiry:       parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m")),
+			RefreshExpiry:      parseDuration(getEnv("JWT_REFRESH_EXPIRY", "168h")),
+			StreamTicketExpiry: parseDuration(getEnv("JWT_STREAM_TICKET_EXPIRY", "1m")),
 		},
 		MinIO: MinIOConfig{
 			Endpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
@@ -83,6 +199,50 @@ func Load() *Config {
 		CORS: CORSConfig{
 			Origins: getEnv("CORS_ORIGINS", "http://localhost:3000"),
 		},
+		Mail: MailConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "SIPODI <no-reply@sipodi.go.id>"),
+		},
+		Verification: VerificationConfig{
+			EndorsementLevel:  getEnv("VERIFICATION_ENDORSEMENT_LEVEL", "nasional"),
+			ClaimTTL:          parseDuration(getEnv("VERIFICATION_CLAIM_TTL", "30m")),
+			SLAWarningDays:    getEnvInt("VERIFICATION_SLA_WARNING_DAYS", 3),
+			SLAEscalationDays: getEnvInt("VERIFICATION_SLA_ESCALATION_DAYS", 7),
+			SLACheckInterval:  parseDuration(getEnv("VERIFICATION_SLA_CHECK_INTERVAL", "1h")),
+			BatchLimit:        getEnvInt("VERIFICATION_BATCH_LIMIT", 100),
+			PendingDigestDay:  getEnv("VERIFICATION_PENDING_DIGEST_DAY", "monday"),
+		},
+		PKB: PKBConfig{
+			JPPerDay:       getEnvInt("PKB_JP_PER_DAY", 8),
+			AnnualTargetJP: getEnvInt("PKB_ANNUAL_TARGET_JP", 32),
+		},
+		Certificate: CertificateConfig{
+			ReminderDays:  getEnvIntList("CERTIFICATE_REMINDER_DAYS", []int{60, 30, 7}),
+			CheckInterval: parseDuration(getEnv("CERTIFICATE_CHECK_INTERVAL", "1h")),
+		},
+		Events: EventsConfig{
+			DispatchInterval: parseDuration(getEnv("OUTBOX_DISPATCH_INTERVAL", "5s")),
+			MaxAttempts:      getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			BatchSize:        getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			Retention:        parseDuration(getEnv("OUTBOX_RETENTION", "720h")),
+			WebhookURL:       getEnv("WEBHOOK_URL", ""),
+			WebhookSecret:    getEnv("WEBHOOK_SECRET", ""),
+		},
+		Notification: NotificationConfig{
+			StreamHeartbeat:     parseDuration(getEnv("NOTIFICATION_STREAM_HEARTBEAT", "15s")),
+			EmailQueueInterval:  parseDuration(getEnv("EMAIL_QUEUE_INTERVAL", "10s")),
+			EmailMaxAttempts:    getEnvInt("EMAIL_MAX_ATTEMPTS", 8),
+			EmailBatchSize:      getEnvInt("EMAIL_BATCH_SIZE", 50),
+			DigestHour:          getEnvInt("NOTIFICATION_DIGEST_HOUR", 7),
+			Timezone:            getEnv("NOTIFICATION_TIMEZONE", "Asia/Jakarta"),
+			DigestCheckInterval: parseDuration(getEnv("NOTIFICATION_DIGEST_CHECK_INTERVAL", "15m")),
+		},
+		Announcement: AnnouncementConfig{
+			PublishInterval: parseDuration(getEnv("ANNOUNCEMENT_PUBLISH_INTERVAL", "1m")),
+		},
 	}
 }
 
@@ -104,6 +264,34 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return defaultValue
+		}
+		return i
+	}
+	return defaultValue
+}
+
+// getEnvIntList reads a comma separated list of positive integers.
+func getEnvIntList(key string, defaultValue []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []int
+	for _, part := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || i <= 0 {
+			return defaultValue
+		}
+		list = append(list, i)
+	}
+	return list
+}
+
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,36 +28,39 @@ type RefreshResponse struct {
 
 // User DTOs
 type UserResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Email     string     `json:"email"`
-	Role      UserRole   `json:"role"`
-	FullName  string     `json:"full_name"`
-	PhotoURL  *string    `json:"photo_url,omitempty"`
-	NUPTK     *string    `json:"nuptk,omitempty"`
-	NIP       *string    `json:"nip,omitempty"`
-	Gender    *Gender    `json:"gender,omitempty"`
-	BirthDate *string    `json:"birth_date,omitempty"`
-	GTKType   *GTKType   `json:"gtk_type,omitempty"`
-	Position  *string    `json:"position,omitempty"`
-	IsActive  bool       `json:"is_active"`
-	School    *SchoolRef `json:"school,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	Role            UserRole   `json:"role"`
+	FullName        string     `json:"full_name"`
+	PhotoURL        *string    `json:"photo_url,omitempty"`
+	NUPTK           *string    `json:"nuptk,omitempty"`
+	NIP             *string    `json:"nip,omitempty"`
+	Gender          *Gender    `json:"gender,omitempty"`
+	BirthDate       *string    `json:"birth_date,omitempty"`
+	GTKType         *GTKType   `json:"gtk_type,omitempty"`
+	Position        *string    `json:"position,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PendingEmail    *string    `json:"pending_email,omitempty"`
+	School          *SchoolRef `json:"school,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type UserListResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Email     string     `json:"email"`
-	Role      UserRole   `json:"role"`
-	FullName  string     `json:"full_name"`
-	PhotoURL  *string    `json:"photo_url,omitempty"`
-	NUPTK     *string    `json:"nuptk,omitempty"`
-	NIP       *string    `json:"nip,omitempty"`
-	GTKType   *GTKType   `json:"gtk_type,omitempty"`
-	Position  *string    `json:"position,omitempty"`
-	IsActive  bool       `json:"is_active"`
-	School    *SchoolRef `json:"school,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	Role            UserRole   `json:"role"`
+	FullName        string     `json:"full_name"`
+	PhotoURL        *string    `json:"photo_url,omitempty"`
+	NUPTK           *string    `json:"nuptk,omitempty"`
+	NIP             *string    `json:"nip,omitempty"`
+	GTKType         *GTKType   `json:"gtk_type,omitempty"`
+	Position        *string    `json:"position,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	School          *SchoolRef `json:"school,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 type CreateUserRequest struct {
@@ -74,6 +78,8 @@ type CreateUserRequest struct {
 }
 
 type UpdateUserRequest struct {
+	Email     *string    `json:"email,omitempty"`
+	Role      *UserRole  `json:"role,omitempty"`
 	FullName  *string    `json:"full_name,omitempty"`
 	NUPTK     *string    `json:"nuptk,omitempty"`
 	NIP       *string    `json:"nip,omitempty"`
@@ -85,6 +91,7 @@ type UpdateUserRequest struct {
 }
 
 type UpdateProfileRequest struct {
+	Email     *string `json:"email,omitempty"`
 	FullName  *string `json:"full_name,omitempty"`
 	Gender    *Gender `json:"gender,omitempty"`
 	BirthDate *string `json:"birth_date,omitempty"`
@@ -97,6 +104,41 @@ type ChangePasswordRequest struct {
 	NewPasswordConfirmation string `json:"new_password_confirmation"`
 }
 
+type ConfirmEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// Registration DTOs
+type RegisterRequest struct {
+	NPSN      string   `json:"npsn"`
+	Email     string   `json:"email"`
+	Password  string   `json:"password"`
+	FullName  string   `json:"full_name"`
+	NUPTK     *string  `json:"nuptk,omitempty"`
+	NIP       *string  `json:"nip,omitempty"`
+	Gender    *Gender  `json:"gender,omitempty"`
+	BirthDate *string  `json:"birth_date,omitempty"`
+	GTKType   *GTKType `json:"gtk_type,omitempty"`
+	Position  *string  `json:"position,omitempty"`
+}
+
+type RegistrationResponse struct {
+	ID              uuid.UUID          `json:"id"`
+	Email           string             `json:"email"`
+	FullName        string             `json:"full_name"`
+	User            *UserResponse      `json:"user,omitempty"`
+	School          *SchoolRef         `json:"school,omitempty"`
+	Status          RegistrationStatus `json:"status"`
+	ReviewedBy      *UserRef           `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time         `json:"reviewed_at,omitempty"`
+	RejectionReason *string            `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+type RejectRegistrationRequest struct {
+	RejectionReason string `json:"rejection_reason"`
+}
+
 // School DTOs
 type SchoolRef struct {
 	ID   uuid.UUID `json:"id"`
@@ -154,76 +196,165 @@ type UserRef struct {
 
 // Talent DTOs
 type TalentResponse struct {
-	ID              uuid.UUID    `json:"id"`
-	User            *UserRef     `json:"user,omitempty"`
-	TalentType      TalentType   `json:"talent_type"`
-	Status          TalentStatus `json:"status"`
-	Detail          interface{}  `json:"detail"`
-	CertificateURL  *string      `json:"certificate_url,omitempty"`
-	VerifiedBy      *UserRef     `json:"verified_by,omitempty"`
-	VerifiedAt      *time.Time   `json:"verified_at,omitempty"`
-	RejectionReason *string      `json:"rejection_reason,omitempty"`
-	CreatedAt       time.Time    `json:"created_at"`
-	UpdatedAt       time.Time    `json:"updated_at"`
-}
-
+	ID                 uuid.UUID                `json:"id"`
+	User               *UserRef                 `json:"user,omitempty"`
+	TalentType         TalentType               `json:"talent_type"`
+	Status             TalentStatus             `json:"status"`
+	Detail             interface{}              `json:"detail"`
+	CertificateURL     *string                  `json:"certificate_url,omitempty"`
+	Attachments        []AttachmentResponse     `json:"attachments"`
+	VerifiedBy         *UserRef                 `json:"verified_by,omitempty"`
+	VerifiedAt         *time.Time               `json:"verified_at,omitempty"`
+	SchoolVerifiedBy   *UserRef                 `json:"school_verified_by,omitempty"`
+	SchoolVerifiedAt   *time.Time               `json:"school_verified_at,omitempty"`
+	RejectionReason    *string                  `json:"rejection_reason,omitempty"`
+	ReviewComments     []ReviewCommentResponse  `json:"review_comments,omitempty"`
+	SuspectedDuplicate bool                     `json:"suspected_duplicate,omitempty"`
+	DuplicateMatches   []DuplicateMatchResponse `json:"duplicate_matches,omitempty"`
+	Competition        *CatalogRef              `json:"competition,omitempty"`
+	Organizer          *CatalogRef              `json:"organizer,omitempty"`
+	CreatedAt          time.Time                `json:"created_at"`
+	UpdatedAt          time.Time                `json:"updated_at"`
+}
+
+type DuplicateMatchResponse struct {
+	TalentID   uuid.UUID         `json:"talent_id"`
+	TalentType TalentType        `json:"talent_type"`
+	Status     TalentStatus      `json:"status"`
+	User       *UserRef          `json:"user,omitempty"`
+	Reasons    []DuplicateReason `json:"reasons"`
+	Score      float64           `json:"score"`
+	Link       string            `json:"link"`
+}
+
+type TalentVersionResponse struct {
+	Version    int             `json:"version"`
+	Detail     json.RawMessage `json:"detail"`
+	CreatedBy  *UserRef        `json:"created_by,omitempty"`
+	ApprovedAt *time.Time      `json:"approved_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// FieldChange is one changed detail field between two talent versions.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type TalentVersionDiff struct {
+	FromVersion int           `json:"from_version"`
+	ToVersion   int           `json:"to_version"`
+	Changes     []FieldChange `json:"changes"`
+}
+
+type ReviewCommentResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Field      string     `json:"field"`
+	Comment    string     `json:"comment"`
+	Author     *UserRef   `json:"author,omitempty"`
+	IsResolved bool       `json:"is_resolved"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type TalentHistoryResponse struct {
+	ID             uuid.UUID           `json:"id"`
+	Action         TalentHistoryAction `json:"action"`
+	FromStatus     *TalentStatus       `json:"from_status,omitempty"`
+	ToStatus       TalentStatus        `json:"to_status"`
+	Actor          *UserRef            `json:"actor,omitempty"`
+	Reason         *string             `json:"reason,omitempty"`
+	DetailSnapshot json.RawMessage     `json:"detail_snapshot,omitempty"`
+	BatchID        *uuid.UUID          `json:"batch_id,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+}
+
+// TalentListResponse is one talent in a list. User, School and Detail are
+// only present when requested through include; the shape of Detail is the
+// detail schema of TalentType.
 type TalentListResponse struct {
-	ID         uuid.UUID    `json:"id"`
-	User       *TalentUser  `json:"user,omitempty"`
-	TalentType TalentType   `json:"talent_type"`
-	Status     TalentStatus `json:"status"`
-	Detail     interface{}  `json:"detail"`
-	CreatedAt  time.Time    `json:"created_at"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID         uuid.UUID       `json:"id"`
+	User       *TalentUser     `json:"user,omitempty"`
+	School     *SchoolRef      `json:"school,omitempty"`
+	TalentType TalentType      `json:"talent_type"`
+	Status     TalentStatus    `json:"status"`
+	Detail     json.RawMessage `json:"detail,omitempty"`
+	Highlight  *string         `json:"highlight,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
 }
 
 type TalentUser struct {
 	ID         uuid.UUID `json:"id"`
 	FullName   string    `json:"full_name"`
+	NIP        *string   `json:"nip,omitempty"`
+	PhotoURL   *string   `json:"photo_url,omitempty"`
 	SchoolName string    `json:"school_name,omitempty"`
 }
 
+// TalentInclude selects the related data a talent list embeds. Each part is
+// loaded in the list query itself, so the number of queries does not grow
+// with the page size.
+type TalentInclude struct {
+	Detail bool
+	User   bool
+	School bool
+}
+
 type CreateTalentRequest struct {
 	TalentType TalentType  `json:"talent_type"`
 	Detail     interface{} `json:"detail"`
 	UploadID   *uuid.UUID  `json:"upload_id,omitempty"`
+	// Attachments are added on top of the legacy upload_id certificate
+	Attachments []AttachmentRequest `json:"attachments,omitempty"`
+	// Draft saves the talent without sending it to verification
+	Draft bool `json:"draft,omitempty"`
 }
 
-type UpdateTalentRequest struct {
-	Detail   interface{} `json:"detail"`
-	UploadID *uuid.UUID  `json:"upload_id,omitempty"`
+type AttachmentRequest struct {
+	UploadID uuid.UUID      `json:"upload_id"`
+	Kind     AttachmentKind `json:"kind"`
+	Caption  *string        `json:"caption,omitempty"`
 }
 
-// Talent Detail DTOs
-type TrainingDetail struct {
-	ActivityName string `json:"activity_name"`
-	Organizer    string `json:"organizer"`
-	StartDate    string `json:"start_date"`
-	DurationDays int    `json:"duration_days"`
+type AttachmentResponse struct {
+	ID          uuid.UUID      `json:"id"`
+	Kind        AttachmentKind `json:"kind"`
+	Caption     *string        `json:"caption,omitempty"`
+	FileURL     string         `json:"file_url"`
+	Filename    *string        `json:"filename,omitempty"`
+	ContentType *string        `json:"content_type,omitempty"`
+	FileSize    *int64         `json:"file_size,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
 
-type MentorDetail struct {
-	CompetitionName string           `json:"competition_name"`
-	Level           CompetitionLevel `json:"level"`
-	Organizer       string           `json:"organizer"`
-	Field           TalentField      `json:"field"`
-	Achievement     string           `json:"achievement"`
+type UpdateTalentRequest struct {
+	Detail   interface{} `json:"detail"`
+	UploadID *uuid.UUID  `json:"upload_id,omitempty"`
 }
 
-type ParticipantDetail struct {
-	CompetitionName  string           `json:"competition_name"`
-	Level            CompetitionLevel `json:"level"`
-	Organizer        string           `json:"organizer"`
-	Field            TalentField      `json:"field"`
-	StartDate        string           `json:"start_date"`
-	DurationDays     int              `json:"duration_days"`
-	CompetitionField string           `json:"competition_field"`
-	Achievement      string           `json:"achievement"`
+// Talent type registry DTOs
+type CreateTalentTypeRequest struct {
+	Code                    TalentType      `json:"code"`
+	Name                    string          `json:"name"`
+	Description             *string         `json:"description,omitempty"`
+	DetailSchema            json.RawMessage `json:"detail_schema"`
+	MatchNameField          *string         `json:"match_name_field,omitempty"`
+	MatchOrganizerField     *string         `json:"match_organizer_field,omitempty"`
+	MatchDateField          *string         `json:"match_date_field,omitempty"`
+	CatalogCompetitionField *string         `json:"catalog_competition_field,omitempty"`
 }
 
-type InterestDetail struct {
-	InterestName string `json:"interest_name"`
-	Description  string `json:"description"`
+type UpdateTalentTypeRequest struct {
+	Name                    *string         `json:"name,omitempty"`
+	Description             *string         `json:"description,omitempty"`
+	DetailSchema            json.RawMessage `json:"detail_schema,omitempty"`
+	MatchNameField          *string         `json:"match_name_field,omitempty"`
+	MatchOrganizerField     *string         `json:"match_organizer_field,omitempty"`
+	MatchDateField          *string         `json:"match_date_field,omitempty"`
+	CatalogCompetitionField *string         `json:"catalog_competition_field,omitempty"`
+	IsActive                *bool           `json:"is_active,omitempty"`
 }
 
 // Verification DTOs
@@ -231,19 +362,58 @@ type RejectTalentRequest struct {
 	RejectionReason string `json:"rejection_reason"`
 }
 
+type AssignVerifierRequest struct {
+	AssigneeID uuid.UUID `json:"assignee_id"`
+}
+
+type ReviewCommentRequest struct {
+	Field   string `json:"field"`
+	Comment string `json:"comment"`
+}
+
+type RequestRevisionRequest struct {
+	Comments []ReviewCommentRequest `json:"comments"`
+}
+
+// BatchSelection picks the talents of a batch either by ID or by the
+// filters of GET /talents, never both.
+type BatchSelection struct {
+	IDs     []uuid.UUID       `json:"ids,omitempty"`
+	Filters map[string]string `json:"filters,omitempty"`
+}
+
 type BatchApproveRequest struct {
-	IDs []uuid.UUID `json:"ids"`
+	BatchSelection
+	Mode BatchMode `json:"mode,omitempty"`
 }
 
 type BatchRejectRequest struct {
-	IDs             []uuid.UUID `json:"ids"`
-	RejectionReason string      `json:"rejection_reason"`
+	BatchSelection
+	Mode            BatchMode `json:"mode,omitempty"`
+	RejectionReason string    `json:"rejection_reason"`
+}
+
+type BatchPreviewResponse struct {
+	MatchedCount int         `json:"matched_count"`
+	Limit        int         `json:"limit"`
+	IDs          []uuid.UUID `json:"ids"`
 }
 
 type BatchResult struct {
-	SuccessCount int          `json:"approved_count,omitempty"`
-	FailedCount  int          `json:"failed_count"`
-	FailedIDs    []FailedItem `json:"failed_ids"`
+	BatchID      uuid.UUID         `json:"batch_id"`
+	Mode         BatchMode         `json:"mode"`
+	SuccessCount int               `json:"approved_count,omitempty"`
+	FailedCount  int               `json:"failed_count"`
+	FailedIDs    []FailedItem      `json:"failed_ids"`
+	Results      []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	ID      uuid.UUID        `json:"id"`
+	Outcome BatchItemOutcome `json:"outcome"`
+	// Status is the talent status after the batch, for succeeded items
+	Status *TalentStatus `json:"status,omitempty"`
+	Reason string        `json:"reason,omitempty"`
 }
 
 type FailedItem struct {
@@ -251,6 +421,18 @@ type FailedItem struct {
 	Reason string    `json:"reason"`
 }
 
+// Security event DTOs
+type SecurityEventResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	EventType SecurityEventType `json:"event_type"`
+	User      *UserRef          `json:"user,omitempty"`
+	Actor     *UserRef          `json:"actor,omitempty"`
+	IPAddress *string           `json:"ip_address,omitempty"`
+	UserAgent *string           `json:"user_agent,omitempty"`
+	Detail    *string           `json:"detail,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
 // Notification DTOs
 type NotificationResponse struct {
 	ID        uuid.UUID        `json:"id"`
@@ -261,6 +443,10 @@ type NotificationResponse struct {
 	CreatedAt time.Time        `json:"created_at"`
 }
 
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
+
 // Upload DTOs
 type PresignRequest struct {
 	Filename    string `json:"filename"`
@@ -344,6 +530,118 @@ type FieldError struct {
 	Message string `json:"message"`
 }
 
+// Leaderboard DTOs
+type GTKLeaderboardEntry struct {
+	Rank        int        `json:"rank"`
+	User        UserRef    `json:"user"`
+	School      *SchoolRef `json:"school,omitempty"`
+	Points      float64    `json:"points"`
+	TalentCount int        `json:"talent_count"`
+}
+
+type SchoolLeaderboardEntry struct {
+	Rank          int          `json:"rank"`
+	School        SchoolRef    `json:"school"`
+	Status        SchoolStatus `json:"status"`
+	Points        float64      `json:"points"`
+	AveragePoints float64      `json:"average_points"`
+	GTKCount      int          `json:"gtk_count"`
+	TalentCount   int          `json:"talent_count"`
+}
+
+// PKBReportEntry is one GTK's professional development load for a year,
+// counted from approved trainings.
+type PKBReportEntry struct {
+	User          UserRef    `json:"user"`
+	School        *SchoolRef `json:"school,omitempty"`
+	Year          int        `json:"year"`
+	TrainingCount int        `json:"training_count"`
+	TotalDays     int        `json:"total_days"`
+	TotalJP       int        `json:"total_jp"`
+	TargetJP      int        `json:"target_jp"`
+	BelowTarget   bool       `json:"below_target"`
+}
+
+// Catalog DTOs
+type CatalogRef struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// CatalogSuggestion is an autocomplete hit. MatchedAlias is the spelling
+// that matched the query, which may differ from the canonical name.
+type CatalogSuggestion struct {
+	ID           uuid.UUID         `json:"id"`
+	Kind         CatalogKind       `json:"kind"`
+	Name         string            `json:"name"`
+	DefaultLevel *CompetitionLevel `json:"default_level,omitempty"`
+	DefaultField *TalentField      `json:"default_field,omitempty"`
+	MatchedAlias string            `json:"matched_alias"`
+}
+
+type CreateCatalogEntryRequest struct {
+	Kind         CatalogKind       `json:"kind"`
+	Name         string            `json:"name"`
+	DefaultLevel *CompetitionLevel `json:"default_level,omitempty"`
+	DefaultField *TalentField      `json:"default_field,omitempty"`
+	Aliases      []string          `json:"aliases,omitempty"`
+}
+
+// UpdateCatalogEntryRequest changes an entry. An empty default_level or
+// default_field clears it.
+type UpdateCatalogEntryRequest struct {
+	Name         *string           `json:"name,omitempty"`
+	DefaultLevel *CompetitionLevel `json:"default_level,omitempty"`
+	DefaultField *TalentField      `json:"default_field,omitempty"`
+	IsActive     *bool             `json:"is_active,omitempty"`
+}
+
+type AddCatalogAliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+type MergeCatalogEntryRequest struct {
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+// ResolveCatalogQueueRequest maps a queued name to entry_id, or creates a
+// new entry for it when entry_id is omitted. Name defaults to the queued
+// name.
+type ResolveCatalogQueueRequest struct {
+	EntryID      *uuid.UUID        `json:"entry_id,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	DefaultLevel *CompetitionLevel `json:"default_level,omitempty"`
+	DefaultField *TalentField      `json:"default_field,omitempty"`
+}
+
+// Announcement DTOs
+type CreateAnnouncementRequest struct {
+	Title    string               `json:"title"`
+	Body     string               `json:"body"`
+	Audience AnnouncementAudience `json:"audience"`
+	IsPinned bool                 `json:"is_pinned"`
+	// PublishAt defaults to now; ExpiresAt to never
+	PublishAt   *time.Time                      `json:"publish_at,omitempty"`
+	ExpiresAt   *time.Time                      `json:"expires_at,omitempty"`
+	Attachments []AnnouncementAttachmentRequest `json:"attachments,omitempty"`
+}
+
+// UpdateAnnouncementRequest replaces an announcement. An omitted
+// publish_at keeps the current one; an omitted expires_at clears it.
+type UpdateAnnouncementRequest struct {
+	Title     string               `json:"title"`
+	Body      string               `json:"body"`
+	Audience  AnnouncementAudience `json:"audience"`
+	IsPinned  bool                 `json:"is_pinned"`
+	PublishAt *time.Time           `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time           `json:"expires_at,omitempty"`
+}
+
+type AnnouncementAttachmentRequest struct {
+	UploadID uuid.UUID `json:"upload_id"`
+	Caption  *string   `json:"caption,omitempty"`
+}
+
 // School Statistics DTO
 type SchoolStatistics struct {
 	ID            uuid.UUID    `json:"id"`
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,10 @@ const (
 	RoleGTK          UserRole = "gtk"
 )
 
+func (r UserRole) IsValid() bool {
+	return r == RoleSuperAdmin || r == RoleAdminSekolah || r == RoleGTK
+}
+
 type Gender string
 
 const (
@@ -30,6 +35,10 @@ const (
 	GTKTypeKepalaSekolah GTKType = "kepala_sekolah"
 )
 
+func (t GTKType) IsValid() bool {
+	return t == GTKTypeGuru || t == GTKTypeTendik || t == GTKTypeKepalaSekolah
+}
+
 type SchoolStatus string
 
 const (
@@ -37,6 +46,12 @@ const (
 	SchoolStatusSwasta SchoolStatus = "swasta"
 )
 
+func (s SchoolStatus) IsValid() bool {
+	return s == SchoolStatusNegeri || s == SchoolStatusSwasta
+}
+
+// TalentType is the code of a talent type definition. The built-in types
+// below are seeded; others are added through the registry.
 type TalentType string
 
 const (
@@ -49,11 +64,116 @@ const (
 type TalentStatus string
 
 const (
-	TalentStatusPending  TalentStatus = "pending"
-	TalentStatusApproved TalentStatus = "approved"
-	TalentStatusRejected TalentStatus = "rejected"
+	// TalentStatusDraft is saved by its owner but not yet submitted for
+	// verification.
+	TalentStatusDraft          TalentStatus = "draft"
+	TalentStatusPending        TalentStatus = "pending"
+	TalentStatusSchoolApproved TalentStatus = "school_approved"
+	TalentStatusNeedsRevision  TalentStatus = "needs_revision"
+	TalentStatusApproved       TalentStatus = "approved"
+	TalentStatusRejected       TalentStatus = "rejected"
+)
+
+// VerificationStage identifies a verification queue.
+type VerificationStage string
+
+const (
+	// VerificationStageSchool holds pending talents reviewed by admin_sekolah.
+	VerificationStageSchool VerificationStage = "school"
+	// VerificationStageEndorsement holds school_approved talents awaiting
+	// endorsement by super_admin (dinas).
+	VerificationStageEndorsement VerificationStage = "endorsement"
+)
+
+// TalentHistoryAction describes what caused a talent status transition.
+type TalentHistoryAction string
+
+const (
+	TalentHistorySubmitted         TalentHistoryAction = "submitted"
+	TalentHistoryEdited            TalentHistoryAction = "edited"
+	TalentHistorySchoolApproved    TalentHistoryAction = "school_approved"
+	TalentHistoryApproved          TalentHistoryAction = "approved"
+	TalentHistoryRejected          TalentHistoryAction = "rejected"
+	TalentHistoryRevisionRequested TalentHistoryAction = "revision_requested"
+	TalentHistoryReset             TalentHistoryAction = "reset"
+	TalentHistoryDrafted           TalentHistoryAction = "drafted"
+	TalentHistoryWithdrawn         TalentHistoryAction = "withdrawn"
+)
+
+// ReviewFieldAttachments refers to the talent attachments in review comments
+// and version diffs.
+const ReviewFieldAttachments = "attachments"
+
+// BatchMode decides what a batch verification does when some items fail.
+type BatchMode string
+
+const (
+	// BatchModeBestEffort keeps every item that succeeded.
+	BatchModeBestEffort BatchMode = "best_effort"
+	// BatchModeAllOrNothing rolls the whole batch back when any item fails.
+	BatchModeAllOrNothing BatchMode = "all_or_nothing"
+)
+
+func (m BatchMode) IsValid() bool {
+	return m == BatchModeBestEffort || m == BatchModeAllOrNothing
+}
+
+type BatchItemOutcome string
+
+const (
+	BatchItemSucceeded BatchItemOutcome = "succeeded"
+	BatchItemFailed    BatchItemOutcome = "failed"
+	// BatchItemRolledBack succeeded but was undone by an all_or_nothing batch
+	BatchItemRolledBack BatchItemOutcome = "rolled_back"
+	// BatchItemSkipped was not attempted after an all_or_nothing batch failed
+	BatchItemSkipped BatchItemOutcome = "skipped"
 )
 
+type AttachmentKind string
+
+const (
+	AttachmentCertificate AttachmentKind = "certificate"
+	AttachmentPhoto       AttachmentKind = "photo"
+	AttachmentSK          AttachmentKind = "sk"
+	AttachmentOther       AttachmentKind = "other"
+)
+
+func (k AttachmentKind) IsValid() bool {
+	switch k {
+	case AttachmentCertificate, AttachmentPhoto, AttachmentSK, AttachmentOther:
+		return true
+	}
+	return false
+}
+
+// CatalogKind tells competitions and organizers apart in the catalog.
+type CatalogKind string
+
+const (
+	CatalogCompetition CatalogKind = "competition"
+	CatalogOrganizer   CatalogKind = "organizer"
+)
+
+func (k CatalogKind) IsValid() bool {
+	return k == CatalogCompetition || k == CatalogOrganizer
+}
+
+type CatalogQueueStatus string
+
+const (
+	CatalogQueuePending   CatalogQueueStatus = "pending"
+	CatalogQueueResolved  CatalogQueueStatus = "resolved"
+	CatalogQueueDismissed CatalogQueueStatus = "dismissed"
+)
+
+func (s CatalogQueueStatus) IsValid() bool {
+	switch s {
+	case CatalogQueuePending, CatalogQueueResolved, CatalogQueueDismissed:
+		return true
+	}
+	return false
+}
+
 type CompetitionLevel string
 
 const (
@@ -63,6 +183,29 @@ const (
 	LevelInternasional CompetitionLevel = "internasional"
 )
 
+var competitionLevelRanks = map[CompetitionLevel]int{
+	LevelKota:          1,
+	LevelProvinsi:      2,
+	LevelNasional:      3,
+	LevelInternasional: 4,
+}
+
+func (l CompetitionLevel) IsValid() bool {
+	_, ok := competitionLevelRanks[l]
+	return ok
+}
+
+// AtLeast reports whether the level is at or above the given level.
+// Unknown levels never match.
+func (l CompetitionLevel) AtLeast(other CompetitionLevel) bool {
+	rank, ok := competitionLevelRanks[l]
+	if !ok {
+		return false
+	}
+	otherRank, ok := competitionLevelRanks[other]
+	return ok && rank >= otherRank
+}
+
 type TalentField string
 
 const (
@@ -75,11 +218,127 @@ const (
 	FieldKepemimpinan TalentField = "kepemimpinan"
 )
 
+func (f TalentField) IsValid() bool {
+	switch f {
+	case FieldAkademik, FieldInovasi, FieldTeknologi, FieldSosial, FieldOlahraga, FieldSeni, FieldKepemimpinan:
+		return true
+	}
+	return false
+}
+
 type NotificationType string
 
 const (
-	NotificationTalentApproved NotificationType = "talent_approved"
-	NotificationTalentRejected NotificationType = "talent_rejected"
+	NotificationTalentApproved        NotificationType = "talent_approved"
+	NotificationTalentRejected        NotificationType = "talent_rejected"
+	NotificationRegistrationApproved  NotificationType = "registration_approved"
+	NotificationSecurityAlert         NotificationType = "security_alert"
+	NotificationTalentSchoolApproved  NotificationType = "talent_school_approved"
+	NotificationEndorsementRequested  NotificationType = "talent_endorsement_requested"
+	NotificationTalentNeedsRevision   NotificationType = "talent_needs_revision"
+	NotificationVerificationAssigned  NotificationType = "verification_assigned"
+	NotificationVerificationReminder  NotificationType = "verification_reminder"
+	NotificationVerificationEscalated NotificationType = "verification_escalated"
+	NotificationCertificateExpiring   NotificationType = "certificate_expiring"
+	NotificationTalentSubmitted       NotificationType = "talent_submitted"
+	NotificationPendingDigest         NotificationType = "verification_pending_digest"
+	NotificationAnnouncement          NotificationType = "announcement_published"
+)
+
+// NotificationTypes lists every notification type in the order preferences
+// are shown.
+var NotificationTypes = []NotificationType{
+	NotificationTalentSchoolApproved,
+	NotificationTalentApproved,
+	NotificationTalentRejected,
+	NotificationTalentNeedsRevision,
+	NotificationCertificateExpiring,
+	NotificationTalentSubmitted,
+	NotificationEndorsementRequested,
+	NotificationVerificationAssigned,
+	NotificationVerificationReminder,
+	NotificationVerificationEscalated,
+	NotificationPendingDigest,
+	NotificationRegistrationApproved,
+	NotificationAnnouncement,
+	NotificationSecurityAlert,
+}
+
+func (t NotificationType) IsValid() bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPreference returns the channels used for t until the user sets
+// their own. Decisions on a GTK's own talents go out by email right away;
+// new submissions and the routine verifier reminders are collected in the
+// daily digest.
+func (t NotificationType) DefaultPreference() NotificationPreference {
+	switch t {
+	case NotificationTalentSubmitted, NotificationEndorsementRequested, NotificationVerificationReminder:
+		return NotificationPreference{Type: t, InApp: true, Digest: true}
+	case NotificationAnnouncement:
+		return NotificationPreference{Type: t, InApp: true}
+	}
+	return NotificationPreference{Type: t, InApp: true, Email: true}
+}
+
+// SLAStatus tells how long a talent has been waiting in a verification queue
+// relative to the configured SLA.
+type SLAStatus string
+
+const (
+	SLAStatusOK      SLAStatus = "ok"
+	SLAStatusWarning SLAStatus = "warning"
+	SLAStatusOverdue SLAStatus = "overdue"
+)
+
+type SecurityEventType string
+
+const (
+	SecurityPasswordChanged    SecurityEventType = "password_changed"
+	SecurityNewLogin           SecurityEventType = "new_login"
+	SecurityAccountDeactivated SecurityEventType = "account_deactivated"
+	SecurityAccountReactivated SecurityEventType = "account_reactivated"
+	SecurityRoleChanged        SecurityEventType = "role_changed"
+	SecurityTokenReuseDetected SecurityEventType = "token_reuse_detected"
+)
+
+type EmailTokenPurpose string
+
+const (
+	EmailTokenVerify EmailTokenPurpose = "verify"
+	EmailTokenChange EmailTokenPurpose = "change"
+)
+
+type RegistrationStatus string
+
+const (
+	RegistrationStatusPending  RegistrationStatus = "pending"
+	RegistrationStatusApproved RegistrationStatus = "approved"
+	RegistrationStatusRejected RegistrationStatus = "rejected"
+)
+
+// EventType names a domain event written to the outbox.
+type EventType string
+
+const (
+	EventTalentSubmitted          EventType = "talent.submitted"
+	EventTalentUpdated            EventType = "talent.updated"
+	EventTalentWithdrawn          EventType = "talent.withdrawn"
+	EventTalentSchoolApproved     EventType = "talent.school_approved"
+	EventTalentApproved           EventType = "talent.approved"
+	EventTalentRejected           EventType = "talent.rejected"
+	EventTalentRevisionRequested  EventType = "talent.revision_requested"
+	EventTalentDeleted            EventType = "talent.deleted"
+	EventUserCreated              EventType = "user.created"
+	EventUserRegistered           EventType = "user.registered"
+	EventUserRegistrationApproved EventType = "user.registration_approved"
+	EventUserRegistrationRejected EventType = "user.registration_rejected"
 )
 
 // Entities
@@ -95,84 +354,273 @@ type School struct {
 }
 
 type User struct {
-	ID           uuid.UUID  `json:"id"`
-	Email        string     `json:"email"`
-	PasswordHash string     `json:"-"`
-	Role         UserRole   `json:"role"`
-	FullName     string     `json:"full_name"`
-	PhotoURL     *string    `json:"photo_url,omitempty"`
-	NUPTK        *string    `json:"nuptk,omitempty"`
-	NIP          *string    `json:"nip,omitempty"`
-	Gender       *Gender    `json:"gender,omitempty"`
-	BirthDate    *time.Time `json:"birth_date,omitempty"`
-	GTKType      *GTKType   `json:"gtk_type,omitempty"`
-	Position     *string    `json:"position,omitempty"`
-	SchoolID     *uuid.UUID `json:"school_id,omitempty"`
-	IsActive     bool       `json:"is_active"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	PasswordHash    string     `json:"-"`
+	Role            UserRole   `json:"role"`
+	FullName        string     `json:"full_name"`
+	PhotoURL        *string    `json:"photo_url,omitempty"`
+	NUPTK           *string    `json:"nuptk,omitempty"`
+	NIP             *string    `json:"nip,omitempty"`
+	Gender          *Gender    `json:"gender,omitempty"`
+	BirthDate       *time.Time `json:"birth_date,omitempty"`
+	GTKType         *GTKType   `json:"gtk_type,omitempty"`
+	Position        *string    `json:"position,omitempty"`
+	SchoolID        *uuid.UUID `json:"school_id,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type EmailVerification struct {
+	ID          uuid.UUID         `json:"id"`
+	UserID      uuid.UUID         `json:"user_id"`
+	Email       string            `json:"email"`
+	Purpose     EmailTokenPurpose `json:"purpose"`
+	TokenHash   string            `json:"-"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	ConfirmedAt *time.Time        `json:"confirmed_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// UserRegistration is a self-registration awaiting review. UserID is nil
+// once a rejection deleted the pending account.
+type UserRegistration struct {
+	ID              uuid.UUID          `json:"id"`
+	UserID          *uuid.UUID         `json:"user_id,omitempty"`
+	SchoolID        uuid.UUID          `json:"school_id"`
+	Email           string             `json:"email"`
+	FullName        string             `json:"full_name"`
+	Status          RegistrationStatus `json:"status"`
+	ReviewedBy      *uuid.UUID         `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time         `json:"reviewed_at,omitempty"`
+	RejectionReason *string            `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
 }
 
 type RefreshToken struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	TokenHash string    `json:"-"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID         `json:"id"`
+	UserID    uuid.UUID         `json:"user_id"`
+	ActorID   *uuid.UUID        `json:"actor_id,omitempty"`
+	EventType SecurityEventType `json:"event_type"`
+	IPAddress *string           `json:"ip_address,omitempty"`
+	UserAgent *string           `json:"user_agent,omitempty"`
+	Detail    *string           `json:"detail,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// RequestMeta describes where a request came from.
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+// TalentTypeDefinition is a registered talent type. DetailSchema is a JSON
+// Schema for the talent detail; the Match fields name the detail fields
+// compared by duplicate detection.
+type TalentTypeDefinition struct {
+	Code                    TalentType      `json:"code"`
+	Name                    string          `json:"name"`
+	Description             *string         `json:"description,omitempty"`
+	DetailSchema            json.RawMessage `json:"detail_schema"`
+	MatchNameField          *string         `json:"match_name_field,omitempty"`
+	MatchOrganizerField     *string         `json:"match_organizer_field,omitempty"`
+	MatchDateField          *string         `json:"match_date_field,omitempty"`
+	CatalogCompetitionField *string         `json:"catalog_competition_field,omitempty"`
+	IsBuiltin               bool            `json:"is_builtin"`
+	IsActive                bool            `json:"is_active"`
+	CreatedAt               time.Time       `json:"created_at"`
+	UpdatedAt               time.Time       `json:"updated_at"`
 }
 
 type Talent struct {
-	ID              uuid.UUID    `json:"id"`
-	UserID          uuid.UUID    `json:"user_id"`
-	TalentType      TalentType   `json:"talent_type"`
-	Status          TalentStatus `json:"status"`
-	VerifiedBy      *uuid.UUID   `json:"verified_by,omitempty"`
-	VerifiedAt      *time.Time   `json:"verified_at,omitempty"`
-	RejectionReason *string      `json:"rejection_reason,omitempty"`
-	CreatedAt       time.Time    `json:"created_at"`
-	UpdatedAt       time.Time    `json:"updated_at"`
-}
-
-type TalentTraining struct {
-	ID           uuid.UUID `json:"id"`
-	TalentID     uuid.UUID `json:"talent_id"`
-	ActivityName string    `json:"activity_name"`
-	Organizer    string    `json:"organizer"`
-	StartDate    time.Time `json:"start_date"`
-	DurationDays int       `json:"duration_days"`
-}
-
-type TalentCompetitionMentor struct {
-	ID              uuid.UUID        `json:"id"`
-	TalentID        uuid.UUID        `json:"talent_id"`
-	CompetitionName string           `json:"competition_name"`
-	Level           CompetitionLevel `json:"level"`
-	Organizer       string           `json:"organizer"`
-	Field           TalentField      `json:"field"`
-	Achievement     string           `json:"achievement"`
-	CertificateURL  *string          `json:"certificate_url,omitempty"`
-}
-
-type TalentCompetitionParticipant struct {
-	ID               uuid.UUID        `json:"id"`
-	TalentID         uuid.UUID        `json:"talent_id"`
-	CompetitionName  string           `json:"competition_name"`
-	Level            CompetitionLevel `json:"level"`
-	Organizer        string           `json:"organizer"`
-	Field            TalentField      `json:"field"`
-	StartDate        time.Time        `json:"start_date"`
-	DurationDays     int              `json:"duration_days"`
-	CompetitionField string           `json:"competition_field"`
-	Achievement      string           `json:"achievement"`
-	CertificateURL   *string          `json:"certificate_url,omitempty"`
-}
-
-type TalentInterest struct {
-	ID             uuid.UUID `json:"id"`
-	TalentID       uuid.UUID `json:"talent_id"`
-	InterestName   string    `json:"interest_name"`
-	Description    string    `json:"description"`
-	CertificateURL *string   `json:"certificate_url,omitempty"`
+	ID               uuid.UUID    `json:"id"`
+	UserID           uuid.UUID    `json:"user_id"`
+	TalentType       TalentType   `json:"talent_type"`
+	Status           TalentStatus `json:"status"`
+	VerifiedBy       *uuid.UUID   `json:"verified_by,omitempty"`
+	VerifiedAt       *time.Time   `json:"verified_at,omitempty"`
+	SchoolVerifiedBy *uuid.UUID   `json:"school_verified_by,omitempty"`
+	SchoolVerifiedAt *time.Time   `json:"school_verified_at,omitempty"`
+	RejectionReason  *string      `json:"rejection_reason,omitempty"`
+	QueuedAt         time.Time    `json:"queued_at"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// TalentListItem is a talent list row together with the related data asked
+// for through TalentInclude. Fields that were not included are nil.
+type TalentListItem struct {
+	Talent
+	Detail json.RawMessage
+	Owner  *TalentOwner
+	School *SchoolRef
+	// Highlight is the search snippet when the list was searched
+	Highlight *string
+}
+
+// TalentOwner is the part of the owning user a talent list shows.
+type TalentOwner struct {
+	ID       uuid.UUID
+	FullName string
+	NIP      *string
+	PhotoURL *string
+}
+
+// QueueEntry is the verification queue state of a talent. An assignee with
+// a ClaimExpiresAt holds a claim lock; without one it was assigned explicitly
+// by a super_admin.
+type QueueEntry struct {
+	TalentID       uuid.UUID  `json:"talent_id"`
+	AssigneeID     *uuid.UUID `json:"assignee_id,omitempty"`
+	AssignedBy     *uuid.UUID `json:"assigned_by,omitempty"`
+	AssignedAt     *time.Time `json:"assigned_at,omitempty"`
+	ClaimExpiresAt *time.Time `json:"claim_expires_at,omitempty"`
+	RemindedAt     *time.Time `json:"reminded_at,omitempty"`
+	EscalatedAt    *time.Time `json:"escalated_at,omitempty"`
+}
+
+// ActiveAssignee returns the user currently holding the talent, or nil when
+// nobody does or the claim lock has expired.
+func (e *QueueEntry) ActiveAssignee(now time.Time) *uuid.UUID {
+	if e == nil || e.AssigneeID == nil {
+		return nil
+	}
+	if e.ClaimExpiresAt != nil && !e.ClaimExpiresAt.After(now) {
+		return nil
+	}
+	return e.AssigneeID
+}
+
+// QueuePageDetails is what the verification queue shows next to the talents
+// of one page, keyed by talent ID. Talents without an entry, duplicates,
+// approved version or changes are missing from the respective map.
+type QueuePageDetails struct {
+	Attachments map[uuid.UUID][]TalentAttachment
+	Entries     map[uuid.UUID]*QueueEntry
+	// Assignees are keyed by user ID
+	Assignees  map[uuid.UUID]*User
+	Duplicates map[uuid.UUID][]TalentDuplicateMatchDetail
+	Changes    map[uuid.UUID]*TalentVersionDiff
+}
+
+// QueuedTalent is a talent waiting for verification together with what the
+// SLA job needs to route its notices.
+type QueuedTalent struct {
+	TalentID   uuid.UUID
+	Status     TalentStatus
+	SchoolID   *uuid.UUID
+	AssigneeID *uuid.UUID
+	QueuedAt   time.Time
+}
+
+// ExpiringCertificate is an approved talent whose certificate expires within
+// a reminder threshold its owner has not been told about yet.
+type ExpiringCertificate struct {
+	TalentID   uuid.UUID
+	UserID     uuid.UUID
+	SchoolID   *uuid.UUID
+	Name       string
+	ExpiryDate time.Time
+	DaysLeft   int
+	DaysBefore int
+}
+
+type TalentStatusHistory struct {
+	ID             uuid.UUID           `json:"id"`
+	TalentID       uuid.UUID           `json:"talent_id"`
+	Action         TalentHistoryAction `json:"action"`
+	FromStatus     *TalentStatus       `json:"from_status,omitempty"`
+	ToStatus       TalentStatus        `json:"to_status"`
+	ActorID        *uuid.UUID          `json:"actor_id,omitempty"`
+	Reason         *string             `json:"reason,omitempty"`
+	DetailSnapshot json.RawMessage     `json:"detail_snapshot,omitempty"`
+	BatchID        *uuid.UUID          `json:"batch_id,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+}
+
+type TalentAttachment struct {
+	ID          uuid.UUID      `json:"id"`
+	TalentID    uuid.UUID      `json:"talent_id"`
+	Kind        AttachmentKind `json:"kind"`
+	Caption     *string        `json:"caption,omitempty"`
+	FileURL     string         `json:"file_url"`
+	Filename    *string        `json:"filename,omitempty"`
+	ContentType *string        `json:"content_type,omitempty"`
+	FileSize    *int64         `json:"file_size,omitempty"`
+	FileHash    *string        `json:"file_hash,omitempty"`
+	UploadedBy  *uuid.UUID     `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// DuplicateReason names one signal that made a talent look like a duplicate.
+type DuplicateReason string
+
+const (
+	DuplicateSimilarName      DuplicateReason = "similar_name"
+	DuplicateSimilarOrganizer DuplicateReason = "similar_organizer"
+	DuplicateSameDate         DuplicateReason = "same_date"
+	DuplicateSameFile         DuplicateReason = "same_file"
+)
+
+// TalentDuplicateMatch links a talent to an existing talent it looks like a
+// duplicate of.
+type TalentDuplicateMatch struct {
+	TalentID  uuid.UUID         `json:"talent_id"`
+	MatchID   uuid.UUID         `json:"match_id"`
+	Reasons   []DuplicateReason `json:"reasons"`
+	Score     float64           `json:"score"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// TalentDuplicateMatchDetail is a suspected duplicate together with the
+// matched talent and its owner.
+type TalentDuplicateMatchDetail struct {
+	TalentDuplicateMatch
+	MatchType   TalentType
+	MatchStatus TalentStatus
+	MatchOwner  UserRef
+}
+
+// DuplicateCandidate is a talent of the same owner and type whose name is
+// textually close, with the similarity of the other compared fields.
+type DuplicateCandidate struct {
+	TalentID       uuid.UUID
+	NameScore      float64
+	OrganizerScore float64
+	SameDate       bool
+}
+
+type TalentVersion struct {
+	ID         uuid.UUID       `json:"id"`
+	TalentID   uuid.UUID       `json:"talent_id"`
+	Version    int             `json:"version"`
+	Detail     json.RawMessage `json:"detail"`
+	CreatedBy  *uuid.UUID      `json:"created_by,omitempty"`
+	ApprovedAt *time.Time      `json:"approved_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type TalentReviewComment struct {
+	ID         uuid.UUID  `json:"id"`
+	TalentID   uuid.UUID  `json:"talent_id"`
+	Field      string     `json:"field"`
+	Comment    string     `json:"comment"`
+	AuthorID   *uuid.UUID `json:"author_id,omitempty"`
+	IsResolved bool       `json:"is_resolved"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 type Notification struct {
@@ -182,5 +630,215 @@ type Notification struct {
 	Type      NotificationType `json:"type"`
 	Message   string           `json:"message"`
 	IsRead    bool             `json:"is_read"`
+	Seq       int64            `json:"-"`
 	CreatedAt time.Time        `json:"created_at"`
 }
+
+// NotificationPreference is how a user receives one notification type: in
+// the app, by email right away, and/or in the daily digest email.
+type NotificationPreference struct {
+	Type   NotificationType `json:"type"`
+	InApp  bool             `json:"in_app"`
+	Email  bool             `json:"email"`
+	Digest bool             `json:"digest"`
+}
+
+// EmailJob is a rendered email in the send queue.
+type EmailJob struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID
+	To        string
+	Subject   string
+	Text      string
+	HTML      string
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// DigestItem is a notification waiting for the recipient's daily digest.
+type DigestItem struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      NotificationType
+	Message   string
+	TalentID  *uuid.UUID
+	CreatedAt time.Time
+}
+
+// ScoringScheme decides how many points an approved talent is worth on the
+// leaderboards. A talent scores the flat points of its type, points per
+// training day up to MaxDays, and the level points of its competition level
+// times LevelFactor times the multiplier of the first achievement pattern
+// that matches. Types without a rule score nothing.
+type ScoringScheme struct {
+	LevelPoints                  map[CompetitionLevel]float64   `json:"level_points"`
+	AchievementMultipliers       []AchievementMultiplier        `json:"achievement_multipliers"`
+	DefaultAchievementMultiplier float64                        `json:"default_achievement_multiplier"`
+	Types                        map[TalentType]TalentTypeScore `json:"types"`
+}
+
+// AchievementMultiplier scales level points for achievements matching
+// Pattern, a case-insensitive PostgreSQL regular expression.
+type AchievementMultiplier struct {
+	Pattern    string  `json:"pattern"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// TalentTypeScore is the scoring rule of one talent type. MaxDays 0 means
+// training days are not capped.
+type TalentTypeScore struct {
+	Points       float64 `json:"points,omitempty"`
+	PointsPerDay float64 `json:"points_per_day,omitempty"`
+	MaxDays      int     `json:"max_days,omitempty"`
+	LevelFactor  float64 `json:"level_factor,omitempty"`
+}
+
+type ScoringSchemeSettings struct {
+	Scheme    ScoringScheme `json:"scheme"`
+	UpdatedBy *uuid.UUID    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// CatalogEntry is a curated competition or organizer. Talents whose name
+// matches one of its aliases after normalization are linked to it.
+type CatalogEntry struct {
+	ID           uuid.UUID         `json:"id"`
+	Kind         CatalogKind       `json:"kind"`
+	Name         string            `json:"name"`
+	DefaultLevel *CompetitionLevel `json:"default_level,omitempty"`
+	DefaultField *TalentField      `json:"default_field,omitempty"`
+	IsActive     bool              `json:"is_active"`
+	Aliases      []CatalogAlias    `json:"aliases,omitempty"`
+	TalentCount  int               `json:"talent_count"`
+	CreatedBy    *uuid.UUID        `json:"created_by,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+type CatalogAlias struct {
+	ID        uuid.UUID `json:"id"`
+	EntryID   uuid.UUID `json:"entry_id"`
+	Alias     string    `json:"alias"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CatalogQueueItem is a submitted name no alias matched. TalentCount is the
+// number of submitted talents still carrying it.
+type CatalogQueueItem struct {
+	ID          uuid.UUID          `json:"id"`
+	Kind        CatalogKind        `json:"kind"`
+	RawName     string             `json:"raw_name"`
+	Status      CatalogQueueStatus `json:"status"`
+	EntryID     *uuid.UUID         `json:"entry_id,omitempty"`
+	ResolvedBy  *uuid.UUID         `json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time         `json:"resolved_at,omitempty"`
+	TalentCount int                `json:"talent_count"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// OutboxEvent is a domain event stored in the transaction of the change it
+// describes. AggregateID is the talent or user the event is about.
+type OutboxEvent struct {
+	ID          int64           `json:"id"`
+	EventType   EventType       `json:"event_type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	ActorID     *uuid.UUID      `json:"actor_id,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// OutboxDelivery is an event waiting to be handled by one consumer.
+type OutboxDelivery struct {
+	Event    OutboxEvent
+	Consumer string
+	Attempts int
+}
+
+// TalentEventPayload is the payload of every talent.* event.
+type TalentEventPayload struct {
+	TalentID   uuid.UUID     `json:"talent_id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	TalentType TalentType    `json:"talent_type"`
+	FromStatus *TalentStatus `json:"from_status,omitempty"`
+	ToStatus   TalentStatus  `json:"to_status"`
+	Reason     *string       `json:"reason,omitempty"`
+	BatchID    *uuid.UUID    `json:"batch_id,omitempty"`
+}
+
+// UserEventPayload is the payload of every user.* event.
+type UserEventPayload struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Email    string     `json:"email"`
+	FullName string     `json:"full_name"`
+	Role     UserRole   `json:"role"`
+	SchoolID *uuid.UUID `json:"school_id,omitempty"`
+	// Reason is only set on user.registration_rejected
+	Reason *string `json:"reason,omitempty"`
+}
+
+// AuditLogEntry is a domain event as kept by the audit consumer.
+type AuditLogEntry struct {
+	EventID     int64           `json:"event_id"`
+	EventType   EventType       `json:"event_type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	ActorID     *uuid.UUID      `json:"actor_id,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// AnnouncementStatus is where an announcement stands in its publish window.
+type AnnouncementStatus string
+
+const (
+	AnnouncementScheduled AnnouncementStatus = "scheduled"
+	AnnouncementActive    AnnouncementStatus = "active"
+	AnnouncementExpired   AnnouncementStatus = "expired"
+)
+
+func (s AnnouncementStatus) IsValid() bool {
+	return s == AnnouncementScheduled || s == AnnouncementActive || s == AnnouncementExpired
+}
+
+// Announcement is a message from the dinas, or from a school when SchoolID
+// is set, shown to its audience between PublishAt and ExpiresAt.
+type Announcement struct {
+	ID          uuid.UUID                `json:"id"`
+	Title       string                   `json:"title"`
+	Body        string                   `json:"body"`
+	AuthorID    *uuid.UUID               `json:"author_id,omitempty"`
+	AuthorName  *string                  `json:"author_name,omitempty"`
+	SchoolID    *uuid.UUID               `json:"school_id,omitempty"`
+	Audience    AnnouncementAudience     `json:"audience"`
+	IsPinned    bool                     `json:"is_pinned"`
+	PublishAt   time.Time                `json:"publish_at"`
+	ExpiresAt   *time.Time               `json:"expires_at,omitempty"`
+	Status      AnnouncementStatus       `json:"status"`
+	Attachments []AnnouncementAttachment `json:"attachments"`
+	IsRead      bool                     `json:"is_read"`
+	// ReadCount is only filled in for the authors' management views
+	ReadCount *int      `json:"read_count,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnnouncementAudience narrows who receives an announcement. A user must
+// match every non-empty list; all lists empty means everyone.
+type AnnouncementAudience struct {
+	Roles          []UserRole     `json:"roles"`
+	SchoolIDs      []uuid.UUID    `json:"school_ids"`
+	SchoolStatuses []SchoolStatus `json:"school_statuses"`
+	GTKTypes       []GTKType      `json:"gtk_types"`
+}
+
+type AnnouncementAttachment struct {
+	ID             uuid.UUID  `json:"id"`
+	AnnouncementID uuid.UUID  `json:"announcement_id"`
+	Caption        *string    `json:"caption,omitempty"`
+	FileURL        string     `json:"file_url"`
+	Filename       *string    `json:"filename,omitempty"`
+	ContentType    *string    `json:"content_type,omitempty"`
+	FileSize       *int64     `json:"file_size,omitempty"`
+	UploadedBy     *uuid.UUID `json:"uploaded_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type AnnouncementHandler struct {
+	announcementService *service.AnnouncementService
+	uploadService       *service.UploadService
+}
+
+func NewAnnouncementHandler(announcementService *service.AnnouncementService, uploadService *service.UploadService) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+		uploadService:       uploadService,
+	}
+}
+
+// Feed lists the active announcements addressed to the user, pinned ones
+// first.
+func (h *AnnouncementHandler) Feed(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	params := h.parseListParams(c)
+	if isRead := c.Query("is_read"); isRead != "" {
+		params.Filters["is_read"] = isRead
+	}
+
+	announcements, total, err := h.announcementService.ListFeed(c.Context(), claims.UserID, params)
+	if err != nil {
+		return InternalError(c)
+	}
+	if announcements == nil {
+		announcements = []domain.Announcement{}
+	}
+
+	unreadCount, _ := h.announcementService.CountUnread(c.Context(), claims.UserID)
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+
+	return c.JSON(fiber.Map{
+		"data": announcements,
+		"meta": fiber.Map{
+			"current_page": meta.CurrentPage,
+			"per_page":     meta.PerPage,
+			"total_pages":  meta.TotalPages,
+			"total_count":  meta.TotalCount,
+			"unread_count": unreadCount,
+		},
+	})
+}
+
+// ListManaged lists the announcements the admin may manage, including
+// scheduled and expired ones.
+func (h *AnnouncementHandler) ListManaged(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	params := h.parseListParams(c)
+	status := c.Query("status")
+	if status != "" && !domain.AnnouncementStatus(status).IsValid() {
+		return BadRequest(c, "INVALID_STATUS", "status harus scheduled, active atau expired")
+	}
+	params.Filters["status"] = status
+	if schoolID == nil {
+		params.Filters["school_id"] = c.Query("school_id")
+	}
+
+	announcements, total, err := h.announcementService.ListManaged(c.Context(), params, schoolID)
+	if err != nil {
+		return InternalError(c)
+	}
+	if announcements == nil {
+		announcements = []domain.Announcement{}
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, announcements, meta)
+}
+
+// GetByID shows an announcement to its audience, or to an admin who may
+// manage it in any status.
+func (h *AnnouncementHandler) GetByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	isAdmin := claims.Role == domain.RoleSuperAdmin || claims.Role == domain.RoleAdminSekolah
+	if schoolID, ok := managedSchool(claims); ok && isAdmin {
+		announcement, err := h.announcementService.GetManaged(c.Context(), id, schoolID)
+		if err == nil {
+			return Success(c, announcement)
+		}
+		if err != service.ErrForbidden {
+			return announcementError(c, err)
+		}
+	}
+
+	announcement, err := h.announcementService.GetForUser(c.Context(), id, claims.UserID)
+	if err != nil {
+		return announcementError(c, err)
+	}
+	return Success(c, announcement)
+}
+
+func (h *AnnouncementHandler) Create(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	var req domain.CreateAnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	attachments, errs := h.resolveAttachments(claims.UserID, req.Attachments)
+	if len(errs) > 0 {
+		return ValidationError(c, errs)
+	}
+
+	announcement, err := h.announcementService.Create(c.Context(), req, attachments, claims.UserID, schoolID)
+	if err != nil {
+		return announcementError(c, err)
+	}
+	h.releaseUploads(req.Attachments)
+
+	return SuccessCreated(c, announcement, "Pengumuman berhasil dibuat")
+}
+
+func (h *AnnouncementHandler) Update(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	var req domain.UpdateAnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	announcement, err := h.announcementService.Update(c.Context(), id, req, schoolID)
+	if err != nil {
+		return announcementError(c, err)
+	}
+	return SuccessWithMessage(c, announcement, "Pengumuman berhasil diperbarui")
+}
+
+func (h *AnnouncementHandler) Delete(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	if err := h.announcementService.Delete(c.Context(), id, schoolID); err != nil {
+		return announcementError(c, err)
+	}
+	return Message(c, "Pengumuman berhasil dihapus")
+}
+
+func (h *AnnouncementHandler) MarkAsRead(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	if err := h.announcementService.MarkAsRead(c.Context(), id, claims.UserID); err != nil {
+		return announcementError(c, err)
+	}
+	return Message(c, "Pengumuman ditandai sudah dibaca")
+}
+
+func (h *AnnouncementHandler) AddAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	var req domain.AnnouncementAttachmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	reqs := []domain.AnnouncementAttachmentRequest{req}
+	attachments, errs := h.resolveAttachments(claims.UserID, reqs)
+	if len(errs) > 0 {
+		return ValidationError(c, errs)
+	}
+
+	announcement, err := h.announcementService.AddAttachment(c.Context(), id, &attachments[0], claims.UserID, schoolID)
+	if err != nil {
+		return announcementError(c, err)
+	}
+	h.releaseUploads(reqs)
+
+	return SuccessCreated(c, announcement, "Lampiran berhasil ditambahkan")
+}
+
+func (h *AnnouncementHandler) DeleteAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachment_id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID lampiran tidak valid")
+	}
+
+	claims := GetClaims(c)
+	schoolID, ok := managedSchool(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+
+	announcement, err := h.announcementService.DeleteAttachment(c.Context(), id, attachmentID, schoolID)
+	if err != nil {
+		return announcementError(c, err)
+	}
+	return SuccessWithMessage(c, announcement, "Lampiran berhasil dihapus")
+}
+
+// resolveAttachments turns confirmed uploads of the user into attachments.
+func (h *AnnouncementHandler) resolveAttachments(userID uuid.UUID, reqs []domain.AnnouncementAttachmentRequest) ([]domain.AnnouncementAttachment, []domain.FieldError) {
+	var errors []domain.FieldError
+	var attachments []domain.AnnouncementAttachment
+
+	for i, req := range reqs {
+		prefix := "attachments[" + strconv.Itoa(i) + "]"
+
+		info, err := h.uploadService.GetConfirmedUpload(req.UploadID, userID)
+		if err != nil {
+			message := "Upload tidak ditemukan atau sudah expired"
+			if err == service.ErrFileNotUploaded {
+				message = "Upload belum dikonfirmasi"
+			}
+			errors = append(errors, domain.FieldError{Field: prefix + ".upload_id", Message: message})
+			continue
+		}
+
+		filename, contentType, size := info.Filename, info.ContentType, info.FileSize
+		attachments = append(attachments, domain.AnnouncementAttachment{
+			Caption:     req.Caption,
+			FileURL:     h.uploadService.GetFileURL(info.ObjectName),
+			Filename:    &filename,
+			ContentType: &contentType,
+			FileSize:    &size,
+		})
+	}
+
+	return attachments, errors
+}
+
+func (h *AnnouncementHandler) releaseUploads(reqs []domain.AnnouncementAttachmentRequest) {
+	for _, req := range reqs {
+		h.uploadService.Release(req.UploadID)
+	}
+}
+
+func (h *AnnouncementHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:    page,
+		Limit:   limit,
+		Filters: map[string]string{},
+	}
+}
+
+// managedSchool returns the school whose announcements a school admin
+// manages, or nil for a super admin. It is not ok for a school admin
+// without a school.
+func managedSchool(claims *service.JWTClaims) (*uuid.UUID, bool) {
+	if claims.Role != domain.RoleAdminSekolah {
+		return nil, true
+	}
+	return claims.SchoolID, claims.SchoolID != nil
+}
+
+// announcementError answers the errors shared by the announcement endpoints.
+func announcementError(c *fiber.Ctx, err error) error {
+	if errs, ok := err.(service.ValidationErrors); ok {
+		return ValidationError(c, errs)
+	}
+	switch err {
+	case service.ErrAnnouncementNotFound:
+		return NotFound(c, "Pengumuman tidak ditemukan")
+	case service.ErrAnnouncementAttachmentNotFound:
+		return NotFound(c, "Lampiran tidak ditemukan")
+	case service.ErrForbidden:
+		return Forbidden(c, "Anda hanya dapat mengelola pengumuman sekolah sendiri")
+	}
+	return InternalError(c)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+func (h *AuditHandler) List(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+
+	entries, total, err := h.auditService.List(c.Context(), params)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+	if entries == nil {
+		entries = []domain.AuditLogEntry{}
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, entries, meta)
+}
+
+func (h *AuditHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Filters: map[string]string{
+			"event_type":   c.Query("event_type"),
+			"aggregate_id": c.Query("aggregate_id"),
+			"actor_id":     c.Query("actor_id"),
+			"date_from":    c.Query("date_from"),
+			"date_to":      c.Query("date_to"),
+		},
+	}
+}
@@ -35,13 +35,17 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return ValidationError(c, errors)
 	}
 
-	resp, refreshToken, err := h.authService.Login(c.Context(), req)
+	resp, refreshToken, err := h.authService.Login(c.Context(), req, RequestMeta(c))
 	if err != nil {
 		switch err {
 		case service.ErrInvalidCredentials:
 			return Error(c, fiber.StatusUnauthorized, "INVALID_CREDENTIALS", "Email atau password salah")
 		case service.ErrAccountDisabled:
 			return Error(c, fiber.StatusForbidden, "ACCOUNT_DISABLED", "Akun Anda telah dinonaktifkan. Hubungi admin.")
+		case service.ErrAccountPending:
+			return Error(c, fiber.StatusForbidden, "ACCOUNT_PENDING", "Pendaftaran Anda sedang menunggu persetujuan admin sekolah")
+		case service.ErrAccountRejected:
+			return Error(c, fiber.StatusForbidden, "ACCOUNT_REJECTED", "Pendaftaran Anda ditolak. Hubungi admin sekolah.")
 		default:
 			return InternalError(c)
 		}
@@ -67,7 +71,7 @@ func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusUnauthorized, "INVALID_TOKEN", "Refresh token tidak ditemukan")
 	}
 
-	resp, newRefreshToken, err := h.authService.RefreshToken(c.Context(), refreshToken)
+	resp, newRefreshToken, err := h.authService.RefreshToken(c.Context(), refreshToken, RequestMeta(c))
 	if err != nil {
 		switch err {
 		case service.ErrTokenExpired:
@@ -136,12 +140,34 @@ func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
 	return SuccessWithMessage(c, fiber.Map{"sessions_terminated": count}, "Berhasil logout dari semua perangkat")
 }
 
+// StreamTicket issues a short-lived ticket for opening the notification
+// stream with ?ticket= where the Authorization header cannot be sent.
+func (h *AuthHandler) StreamTicket(c *fiber.Ctx) error {
+	ticket, err := h.authService.IssueStreamTicket(GetClaims(c))
+	if err != nil {
+		return InternalError(c)
+	}
+
+	return Success(c, fiber.Map{
+		"ticket":     ticket,
+		"expires_in": int(h.authService.StreamTicketExpiry().Seconds()),
+	})
+}
+
 // Helper to get claims from context
 func GetClaims(c *fiber.Ctx) *service.JWTClaims {
 	claims, _ := c.Locals("claims").(*service.JWTClaims)
 	return claims
 }
 
+// RequestMeta collects the client details recorded with security events.
+func RequestMeta(c *fiber.Ctx) domain.RequestMeta {
+	return domain.RequestMeta{
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}
+}
+
 // Helper to extract token from header
 func ExtractToken(c *fiber.Ctx) string {
 	auth := c.Get("Authorization")
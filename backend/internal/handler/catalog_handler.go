@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type CatalogHandler struct {
+	catalogService *service.CatalogService
+}
+
+func NewCatalogHandler(catalogService *service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{catalogService: catalogService}
+}
+
+// Suggest autocompletes competition and organizer names in talent forms.
+func (h *CatalogHandler) Suggest(c *fiber.Ctx) error {
+	kind := domain.CatalogKind(c.Query("kind"))
+	if !kind.IsValid() {
+		return BadRequest(c, "INVALID_KIND", "kind harus competition atau organizer")
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	suggestions, err := h.catalogService.Suggest(c.Context(), kind, c.Query("q"), limit)
+	if err != nil {
+		return InternalError(c)
+	}
+	if suggestions == nil {
+		suggestions = []domain.CatalogSuggestion{}
+	}
+	return Success(c, suggestions)
+}
+
+func (h *CatalogHandler) List(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+	if kind := params.Filters["kind"]; kind != "" && !domain.CatalogKind(kind).IsValid() {
+		return BadRequest(c, "INVALID_KIND", "kind harus competition atau organizer")
+	}
+	params.Search = strings.TrimSpace(c.Query("search"))
+	if c.QueryBool("include_inactive") {
+		params.Filters["include_inactive"] = "true"
+	}
+
+	entries, total, err := h.catalogService.List(c.Context(), params)
+	if err != nil {
+		return InternalError(c)
+	}
+	if entries == nil {
+		entries = []domain.CatalogEntry{}
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, entries, meta)
+}
+
+func (h *CatalogHandler) GetByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	entry, err := h.catalogService.GetByID(c.Context(), id)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return Success(c, entry)
+}
+
+func (h *CatalogHandler) Create(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+
+	var req domain.CreateCatalogEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	entry, err := h.catalogService.Create(c.Context(), req, claims.UserID)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessCreated(c, entry, "Entri katalog berhasil ditambahkan")
+}
+
+func (h *CatalogHandler) Update(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.UpdateCatalogEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	entry, err := h.catalogService.Update(c.Context(), id, req)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessWithMessage(c, entry, "Entri katalog berhasil diperbarui")
+}
+
+func (h *CatalogHandler) AddAlias(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.AddCatalogAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	entry, err := h.catalogService.AddAlias(c.Context(), id, req.Alias)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessWithMessage(c, entry, "Alias berhasil ditambahkan")
+}
+
+func (h *CatalogHandler) DeleteAlias(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+	aliasID, err := uuid.Parse(c.Params("alias_id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID alias tidak valid")
+	}
+
+	if err := h.catalogService.DeleteAlias(c.Context(), id, aliasID); err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessNoContent(c)
+}
+
+// Merge folds the entry into target_id and deletes it.
+func (h *CatalogHandler) Merge(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.MergeCatalogEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	entry, err := h.catalogService.Merge(c.Context(), id, req.TargetID)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessWithMessage(c, entry, "Entri katalog berhasil digabungkan")
+}
+
+// ListQueue lists submitted names the catalog does not know, pending ones
+// unless status says otherwise.
+func (h *CatalogHandler) ListQueue(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+	if kind := params.Filters["kind"]; kind != "" && !domain.CatalogKind(kind).IsValid() {
+		return BadRequest(c, "INVALID_KIND", "kind harus competition atau organizer")
+	}
+	params.Filters["status"] = c.Query("status", string(domain.CatalogQueuePending))
+	if !domain.CatalogQueueStatus(params.Filters["status"]).IsValid() {
+		return BadRequest(c, "INVALID_STATUS", "status harus pending, resolved, atau dismissed")
+	}
+
+	items, total, err := h.catalogService.ListQueue(c.Context(), params)
+	if err != nil {
+		return InternalError(c)
+	}
+	if items == nil {
+		items = []domain.CatalogQueueItem{}
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, items, meta)
+}
+
+func (h *CatalogHandler) ResolveQueueItem(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.ResolveCatalogQueueRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	claims := GetClaims(c)
+	item, err := h.catalogService.ResolveQueueItem(c.Context(), id, req, claims.UserID)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessWithMessage(c, item, "Nama berhasil dipetakan ke katalog")
+}
+
+func (h *CatalogHandler) DismissQueueItem(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	item, err := h.catalogService.DismissQueueItem(c.Context(), id, claims.UserID)
+	if err != nil {
+		return catalogError(c, err)
+	}
+	return SuccessWithMessage(c, item, "Nama dibiarkan sebagai teks bebas")
+}
+
+func (h *CatalogHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Filters: map[string]string{
+			"kind": c.Query("kind"),
+		},
+	}
+}
+
+// catalogError answers the errors shared by the catalog endpoints.
+func catalogError(c *fiber.Ctx, err error) error {
+	if errs, ok := err.(service.ValidationErrors); ok {
+		return ValidationError(c, errs)
+	}
+	switch err {
+	case service.ErrCatalogEntryNotFound:
+		return NotFound(c, "Entri katalog tidak ditemukan")
+	case service.ErrCatalogAliasNotFound:
+		return NotFound(c, "Alias tidak ditemukan")
+	case service.ErrCatalogQueueNotFound:
+		return NotFound(c, "Antrean normalisasi tidak ditemukan")
+	case service.ErrCatalogAliasTaken:
+		return Conflict(c, "ALIAS_TAKEN", "Nama atau alias sudah dipakai entri katalog lain")
+	case service.ErrCatalogAliasIsName:
+		return BadRequest(c, "ALIAS_IS_NAME", "Alias yang sama dengan nama entri tidak dapat dihapus")
+	case service.ErrCatalogKindMismatch:
+		return BadRequest(c, "KIND_MISMATCH", "Lomba dan penyelenggara tidak dapat dicampur")
+	case service.ErrCatalogQueueNotPending:
+		return BadRequest(c, "NOT_PENDING", "Nama ini sudah ditangani")
+	}
+	return InternalError(c)
+}
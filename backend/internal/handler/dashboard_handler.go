@@ -85,7 +85,7 @@ func (h *DashboardHandler) GetTalentsStatistics(c *fiber.Ctx) error {
 		schoolID = &sid
 	}
 
-	stats, err := h.dashboardService.GetTalentsStatistics(c.Context(), groupBy, schoolID, c.Query("date_from"), c.Query("date_to"))
+	stats, err := h.dashboardService.GetTalentsStatistics(c.Context(), groupBy, schoolID, c.Query("date_from"), c.Query("date_to"), c.QueryBool("exclude_expired"))
 	if err != nil {
 		return InternalError(c)
 	}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type LeaderboardHandler struct {
+	scoringService *service.ScoringService
+}
+
+func NewLeaderboardHandler(scoringService *service.ScoringService) *LeaderboardHandler {
+	return &LeaderboardHandler{scoringService: scoringService}
+}
+
+func (h *LeaderboardHandler) GetScheme(c *fiber.Ctx) error {
+	settings, err := h.scoringService.GetScheme(c.Context())
+	if err != nil {
+		if err == service.ErrScoringSchemeNotFound {
+			return NotFound(c, "Skema penilaian belum diatur")
+		}
+		return InternalError(c)
+	}
+	return Success(c, settings)
+}
+
+func (h *LeaderboardHandler) UpdateScheme(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+
+	var scheme domain.ScoringScheme
+	if err := c.BodyParser(&scheme); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	settings, err := h.scoringService.UpdateScheme(c.Context(), scheme, claims.UserID)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+	return SuccessWithMessage(c, settings, "Skema penilaian berhasil diperbarui")
+}
+
+func (h *LeaderboardHandler) GTK(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+	entries, total, err := h.scoringService.GTKLeaderboard(c.Context(), params)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+	if entries == nil {
+		entries = []domain.GTKLeaderboardEntry{}
+	}
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, entries, meta)
+}
+
+// Schools ranks schools by total points, or by points per GTK with
+// rank_by=average so small schools can compete with large ones.
+func (h *LeaderboardHandler) Schools(c *fiber.Ctx) error {
+	rankBy := c.Query("rank_by", "total")
+	if rankBy != "total" && rankBy != "average" {
+		return BadRequest(c, "INVALID_RANK_BY", "rank_by harus total atau average")
+	}
+
+	params := h.parseListParams(c)
+	entries, total, err := h.scoringService.SchoolLeaderboard(c.Context(), params, rankBy == "average")
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+	if entries == nil {
+		entries = []domain.SchoolLeaderboardEntry{}
+	}
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, entries, meta)
+}
+
+func (h *LeaderboardHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Filters: map[string]string{
+			"year":          c.Query("year"),
+			"field":         c.Query("field"),
+			"talent_type":   c.Query("talent_type"),
+			"school_status": c.Query("school_status"),
+			"school_id":     c.Query("school_id"),
+		},
+	}
+}
@@ -1,7 +1,13 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -9,12 +15,17 @@ import (
 	"github.com/sipodi/backend/internal/service"
 )
 
+// streamBatch is how many notifications the stream loads per query.
+const streamBatch = 100
+
 type NotificationHandler struct {
 	notificationService *service.NotificationService
+	hub                 *service.NotificationHub
+	heartbeat           time.Duration
 }
 
-func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
-	return &NotificationHandler{notificationService: notificationService}
+func NewNotificationHandler(notificationService *service.NotificationService, hub *service.NotificationHub, heartbeat time.Duration) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService, hub: hub, heartbeat: heartbeat}
 }
 
 func (h *NotificationHandler) List(c *fiber.Ctx) error {
@@ -28,14 +39,7 @@ func (h *NotificationHandler) List(c *fiber.Ctx) error {
 
 	var resp []domain.NotificationResponse
 	for _, n := range notifications {
-		resp = append(resp, domain.NotificationResponse{
-			ID:        n.ID,
-			Type:      n.Type,
-			Message:   n.Message,
-			TalentID:  n.TalentID,
-			IsRead:    n.IsRead,
-			CreatedAt: n.CreatedAt,
-		})
+		resp = append(resp, toNotificationResponse(n))
 	}
 
 	unreadCount, _ := h.notificationService.CountUnread(c.Context(), claims.UserID)
@@ -101,6 +105,140 @@ func (h *NotificationHandler) MarkAllAsRead(c *fiber.Ctx) error {
 	return SuccessWithMessage(c, fiber.Map{"marked_count": count}, "Semua notifikasi ditandai sudah dibaca")
 }
 
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	prefs, err := h.notificationService.GetPreferences(c.Context(), claims.UserID)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	return Success(c, prefs)
+}
+
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	var req domain.UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	claims := GetClaims(c)
+	prefs, err := h.notificationService.UpdatePreferences(c.Context(), claims.UserID, &req)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+
+	return SuccessWithMessage(c, prefs, "Preferensi notifikasi berhasil diperbarui")
+}
+
+// Stream pushes new notifications and unread count changes as Server-Sent
+// Events. A notification event carries its sequence number as the event ID,
+// so a client reconnecting with Last-Event-ID gets what it missed; without
+// it the stream starts from now. The stream ends when the client goes away,
+// which the next write or heartbeat notices.
+func (h *NotificationHandler) Stream(c *fiber.Ctx) error {
+	userID := GetClaims(c).UserID
+
+	lastSeq, err := strconv.ParseInt(c.Get("Last-Event-ID"), 10, 64)
+	if err != nil || lastSeq < 0 {
+		lastSeq, err = h.notificationService.LatestSeq(c.Context(), userID)
+		if err != nil {
+			return InternalError(c)
+		}
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	sub := h.hub.Subscribe(userID)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Unsubscribe(sub)
+
+		// The request context is gone once the handler returns
+		ctx := context.Background()
+		unread := -1
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		if err := h.pushChanges(ctx, w, userID, &lastSeq, &unread); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case _, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := h.pushChanges(ctx, w, userID, &lastSeq, &unread); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// pushChanges writes the notifications after lastSeq and, when it changed,
+// the unread count. A write error means the client is gone.
+func (h *NotificationHandler) pushChanges(ctx context.Context, w *bufio.Writer, userID uuid.UUID, lastSeq *int64, unread *int) error {
+	for {
+		notifications, err := h.notificationService.ListSince(ctx, userID, *lastSeq, streamBatch)
+		if err != nil {
+			log.Printf("failed to load notifications for stream of user %s: %v", userID, err)
+			return err
+		}
+		for _, n := range notifications {
+			writeEvent(w, strconv.FormatInt(n.Seq, 10), "notification", toNotificationResponse(n))
+			*lastSeq = n.Seq
+		}
+		if len(notifications) < streamBatch {
+			break
+		}
+	}
+
+	count, err := h.notificationService.CountUnread(ctx, userID)
+	if err != nil {
+		log.Printf("failed to count notifications for stream of user %s: %v", userID, err)
+		return err
+	}
+	if count != *unread {
+		writeEvent(w, "", "unread_count", fiber.Map{"unread_count": count})
+		*unread = count
+	}
+	return w.Flush()
+}
+
+// writeEvent buffers one SSE event; write errors surface on the next Flush.
+func writeEvent(w *bufio.Writer, id string, event string, data interface{}) {
+	payload, _ := json.Marshal(data)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
+func toNotificationResponse(n domain.Notification) domain.NotificationResponse {
+	return domain.NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		Message:   n.Message,
+		TalentID:  n.TalentID,
+		IsRead:    n.IsRead,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
 func (h *NotificationHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
@@ -113,6 +251,7 @@ func (h *NotificationHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
 		Limit: limit,
 		Filters: map[string]string{
 			"is_read": c.Query("is_read"),
+			"type":    c.Query("type"),
 		},
 	}
 }
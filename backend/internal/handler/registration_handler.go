@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type RegistrationHandler struct {
+	registrationService *service.RegistrationService
+}
+
+func NewRegistrationHandler(registrationService *service.RegistrationService) *RegistrationHandler {
+	return &RegistrationHandler{registrationService: registrationService}
+}
+
+func (h *RegistrationHandler) Register(c *fiber.Ctx) error {
+	var req domain.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	// Validation
+	var errors []domain.FieldError
+	if req.NPSN == "" {
+		errors = append(errors, domain.FieldError{Field: "npsn", Message: "NPSN sekolah wajib diisi"})
+	}
+	if req.Email == "" {
+		errors = append(errors, domain.FieldError{Field: "email", Message: "Email wajib diisi"})
+	}
+	if len(req.Password) < 8 {
+		errors = append(errors, domain.FieldError{Field: "password", Message: "Password minimal 8 karakter"})
+	}
+	if req.FullName == "" {
+		errors = append(errors, domain.FieldError{Field: "full_name", Message: "Nama lengkap wajib diisi"})
+	}
+	if (req.NUPTK == nil || *req.NUPTK == "") && (req.NIP == nil || *req.NIP == "") {
+		errors = append(errors, domain.FieldError{Field: "nuptk", Message: "NUPTK atau NIP wajib diisi"})
+	}
+	if req.GTKType == nil {
+		errors = append(errors, domain.FieldError{Field: "gtk_type", Message: "Jenis GTK wajib diisi"})
+	}
+	if len(errors) > 0 {
+		return ValidationError(c, errors)
+	}
+
+	registration, err := h.registrationService.Register(c.Context(), req)
+	if err != nil {
+		switch err {
+		case service.ErrSchoolNotFound:
+			return NotFound(c, "Sekolah dengan NPSN tersebut tidak ditemukan")
+		case service.ErrEmailTaken:
+			return Conflict(c, "EMAIL_TAKEN", "Email sudah terdaftar")
+		case service.ErrNUPTKTaken:
+			return Conflict(c, "NUPTK_TAKEN", "NUPTK sudah terdaftar")
+		case service.ErrNIPTaken:
+			return Conflict(c, "NIP_TAKEN", "NIP sudah terdaftar")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	resp := h.toRegistrationResponse(c, registration)
+	return SuccessCreated(c, resp, "Pendaftaran berhasil dan menunggu persetujuan admin sekolah")
+}
+
+func (h *RegistrationHandler) LookupSchool(c *fiber.Ctx) error {
+	school, err := h.registrationService.GetSchoolByNPSN(c.Context(), c.Params("npsn"))
+	if err != nil {
+		if err == service.ErrSchoolNotFound {
+			return NotFound(c, "Sekolah dengan NPSN tersebut tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	return Success(c, domain.SchoolRef{
+		ID:   school.ID,
+		Name: school.Name,
+		NPSN: school.NPSN,
+	})
+}
+
+func (h *RegistrationHandler) List(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+
+	// Admin sekolah can only review registrations for their school
+	claims := GetClaims(c)
+	if claims.Role == domain.RoleAdminSekolah && claims.SchoolID != nil {
+		params.Filters["school_id"] = claims.SchoolID.String()
+	}
+
+	registrations, total, err := h.registrationService.List(c.Context(), params)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	var resp []domain.RegistrationResponse
+	for _, registration := range registrations {
+		resp = append(resp, h.toRegistrationResponse(c, &registration))
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+
+	return SuccessList(c, resp, meta)
+}
+
+func (h *RegistrationHandler) GetByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	registration, err := h.registrationService.GetByID(c.Context(), id)
+	if err != nil {
+		if err == service.ErrRegistrationNotFound {
+			return NotFound(c, "Pendaftaran tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if !h.canReview(c, registration) {
+		return Forbidden(c, "Anda hanya dapat melihat pendaftaran di sekolah Anda")
+	}
+
+	resp := h.toRegistrationResponse(c, registration)
+	return Success(c, resp)
+}
+
+func (h *RegistrationHandler) Approve(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	registration, err := h.registrationService.GetByID(c.Context(), id)
+	if err != nil {
+		if err == service.ErrRegistrationNotFound {
+			return NotFound(c, "Pendaftaran tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if !h.canReview(c, registration) {
+		return Forbidden(c, "Anda hanya dapat memproses pendaftaran di sekolah Anda")
+	}
+
+	claims := GetClaims(c)
+	registration, err = h.registrationService.Approve(c.Context(), id, claims.UserID)
+	if err != nil {
+		switch err {
+		case service.ErrRegistrationNotFound:
+			return NotFound(c, "Pendaftaran tidak ditemukan")
+		case service.ErrRegistrationReviewed:
+			return BadRequest(c, "ALREADY_REVIEWED", "Pendaftaran sudah diproses sebelumnya")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"id":          registration.ID,
+		"status":      registration.Status,
+		"reviewed_at": registration.ReviewedAt,
+	}, "Pendaftaran berhasil disetujui dan akun telah diaktifkan")
+}
+
+func (h *RegistrationHandler) Reject(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.RejectRegistrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	if req.RejectionReason == "" {
+		return ValidationError(c, []domain.FieldError{
+			{Field: "rejection_reason", Message: "Alasan penolakan wajib diisi"},
+		})
+	}
+
+	registration, err := h.registrationService.GetByID(c.Context(), id)
+	if err != nil {
+		if err == service.ErrRegistrationNotFound {
+			return NotFound(c, "Pendaftaran tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if !h.canReview(c, registration) {
+		return Forbidden(c, "Anda hanya dapat memproses pendaftaran di sekolah Anda")
+	}
+
+	claims := GetClaims(c)
+	registration, err = h.registrationService.Reject(c.Context(), id, claims.UserID, req.RejectionReason)
+	if err != nil {
+		switch err {
+		case service.ErrRegistrationNotFound:
+			return NotFound(c, "Pendaftaran tidak ditemukan")
+		case service.ErrRegistrationReviewed:
+			return BadRequest(c, "ALREADY_REVIEWED", "Pendaftaran sudah diproses sebelumnya")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"id":               registration.ID,
+		"status":           registration.Status,
+		"rejection_reason": registration.RejectionReason,
+		"reviewed_at":      registration.ReviewedAt,
+	}, "Pendaftaran ditolak")
+}
+
+func (h *RegistrationHandler) canReview(c *fiber.Ctx, registration *domain.UserRegistration) bool {
+	claims := GetClaims(c)
+	if claims.Role == domain.RoleSuperAdmin {
+		return true
+	}
+	return claims.SchoolID != nil && *claims.SchoolID == registration.SchoolID
+}
+
+func (h *RegistrationHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Sort:  c.Query("sort"),
+		Filters: map[string]string{
+			"status":    c.Query("status", string(domain.RegistrationStatusPending)),
+			"school_id": c.Query("school_id"),
+		},
+	}
+}
+
+func (h *RegistrationHandler) toRegistrationResponse(c *fiber.Ctx, registration *domain.UserRegistration) domain.RegistrationResponse {
+	resp := domain.RegistrationResponse{
+		ID:              registration.ID,
+		Email:           registration.Email,
+		FullName:        registration.FullName,
+		Status:          registration.Status,
+		ReviewedAt:      registration.ReviewedAt,
+		RejectionReason: registration.RejectionReason,
+		CreatedAt:       registration.CreatedAt,
+	}
+
+	var user *domain.User
+	if registration.UserID != nil {
+		user, _ = h.registrationService.GetUser(c.Context(), *registration.UserID)
+	}
+	if user != nil {
+		userResp := domain.UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Role:      user.Role,
+			FullName:  user.FullName,
+			NUPTK:     user.NUPTK,
+			NIP:       user.NIP,
+			Gender:    user.Gender,
+			GTKType:   user.GTKType,
+			Position:  user.Position,
+			IsActive:  user.IsActive,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		}
+		if user.BirthDate != nil {
+			str := user.BirthDate.Format("2006-01-02")
+			userResp.BirthDate = &str
+		}
+		resp.User = &userResp
+	}
+
+	school, _ := h.registrationService.GetSchool(c.Context(), registration.SchoolID)
+	if school != nil {
+		resp.School = &domain.SchoolRef{
+			ID:   school.ID,
+			Name: school.Name,
+			NPSN: school.NPSN,
+		}
+	}
+
+	if registration.ReviewedBy != nil {
+		reviewer, _ := h.registrationService.GetUser(c.Context(), *registration.ReviewedBy)
+		if reviewer != nil {
+			resp.ReviewedBy = &domain.UserRef{
+				ID:       reviewer.ID,
+				FullName: reviewer.FullName,
+			}
+		}
+	}
+
+	return resp
+}
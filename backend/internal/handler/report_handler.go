@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+	"github.com/xuri/excelize/v2"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// PKB lists each GTK's professional development hours for a year.
+func (h *ReportHandler) PKB(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+	entries, total, err := h.reportService.PKB(c.Context(), params)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+	if entries == nil {
+		entries = []domain.PKBReportEntry{}
+	}
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+	return SuccessList(c, entries, meta)
+}
+
+// ExportPKB downloads the PKB report as an Excel file.
+func (h *ReportHandler) ExportPKB(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+	entries, err := h.reportService.ExportPKB(c.Context(), params)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		return InternalError(c)
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Laporan PKB"
+	f.SetSheetName("Sheet1", sheet)
+
+	// Headers
+	headers := []string{"No", "Nama Lengkap", "NIP", "Sekolah", "NPSN", "Tahun", "Jumlah Pelatihan", "Total Hari", "Total JP", "Target JP", "Keterangan"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	// Data
+	for i, entry := range entries {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), i+1)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), entry.User.FullName)
+		if entry.User.NIP != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("C%d", row), *entry.User.NIP)
+		}
+		if entry.School != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), entry.School.Name)
+			f.SetCellValue(sheet, fmt.Sprintf("E%d", row), entry.School.NPSN)
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), entry.Year)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), entry.TrainingCount)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), entry.TotalDays)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), entry.TotalJP)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), entry.TargetJP)
+		status := "Memenuhi target"
+		if entry.BelowTarget {
+			status = "Di bawah target"
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("K%d", row), status)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return InternalError(c)
+	}
+
+	filename := fmt.Sprintf("laporan_pkb_%s_%s.xlsx", params.Filters["year"], time.Now().Format("20060102_150405"))
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(buf.Bytes())
+}
+
+func (h *ReportHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	claims := GetClaims(c)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	params := domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Filters: map[string]string{
+			"year":         c.Query("year"),
+			"below_target": c.Query("below_target"),
+		},
+	}
+
+	// Admin sekolah only sees their school's GTK
+	if claims.Role == domain.RoleAdminSekolah && claims.SchoolID != nil {
+		params.Filters["school_id"] = claims.SchoolID.String()
+	} else if c.Query("school_id") != "" {
+		params.Filters["school_id"] = c.Query("school_id")
+	}
+	return params
+}
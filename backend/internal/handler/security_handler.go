@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type SecurityHandler struct {
+	securityService *service.SecurityService
+}
+
+func NewSecurityHandler(securityService *service.SecurityService) *SecurityHandler {
+	return &SecurityHandler{securityService: securityService}
+}
+
+func (h *SecurityHandler) ListEvents(c *fiber.Ctx) error {
+	params := h.parseListParams(c)
+
+	events, total, err := h.securityService.ListEvents(c.Context(), params)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	var resp []domain.SecurityEventResponse
+	for _, event := range events {
+		item := domain.SecurityEventResponse{
+			ID:        event.ID,
+			EventType: event.EventType,
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			Detail:    event.Detail,
+			CreatedAt: event.CreatedAt,
+		}
+
+		user, _ := h.securityService.GetUser(c.Context(), event.UserID)
+		if user != nil {
+			item.User = &domain.UserRef{ID: user.ID, FullName: user.FullName}
+		}
+
+		if event.ActorID != nil {
+			actor, _ := h.securityService.GetUser(c.Context(), *event.ActorID)
+			if actor != nil {
+				item.Actor = &domain.UserRef{ID: actor.ID, FullName: actor.FullName}
+			}
+		}
+
+		resp = append(resp, item)
+	}
+
+	meta := domain.PaginationMeta{
+		CurrentPage: params.Page,
+		PerPage:     params.Limit,
+		TotalCount:  total,
+		TotalPages:  (total + params.Limit - 1) / params.Limit,
+	}
+
+	return SuccessList(c, resp, meta)
+}
+
+func (h *SecurityHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	return domain.ListParams{
+		Page:  page,
+		Limit: limit,
+		Filters: map[string]string{
+			"user_id":    c.Query("user_id"),
+			"event_type": c.Query("event_type"),
+			"date_from":  c.Query("date_from"),
+			"date_to":    c.Query("date_to"),
+		},
+	}
+}
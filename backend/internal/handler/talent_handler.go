@@ -2,6 +2,8 @@ package handler
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -23,6 +25,13 @@ func NewTalentHandler(talentService *service.TalentService, uploadService *servi
 
 func (h *TalentHandler) List(c *fiber.Ctx) error {
 	params := h.parseListParams(c)
+	if code, message := checkTalentFilters(params.Filters); code != "" {
+		return BadRequest(c, code, message)
+	}
+	include, ok := parseTalentInclude(c)
+	if !ok {
+		return BadRequest(c, "INVALID_INCLUDE", "Include hanya boleh berisi detail, user, dan school")
+	}
 
 	// Admin sekolah can only see talents from their school
 	claims := GetClaims(c)
@@ -30,14 +39,14 @@ func (h *TalentHandler) List(c *fiber.Ctx) error {
 		params.Filters["school_id"] = claims.SchoolID.String()
 	}
 
-	talents, total, err := h.talentService.List(c.Context(), params)
+	talents, total, err := h.talentService.ListIncluding(c.Context(), params, include)
 	if err != nil {
 		return InternalError(c)
 	}
 
 	var resp []domain.TalentListResponse
 	for _, talent := range talents {
-		resp = append(resp, h.toTalentListResponse(c, &talent))
+		resp = append(resp, toTalentListResponse(&talent))
 	}
 
 	meta := domain.PaginationMeta{
@@ -54,15 +63,23 @@ func (h *TalentHandler) ListMyTalents(c *fiber.Ctx) error {
 	claims := GetClaims(c)
 	params := h.parseListParams(c)
 	params.Filters["user_id"] = claims.UserID.String()
+	params.Filters["include_drafts"] = "true"
+	if code, message := checkTalentFilters(params.Filters); code != "" {
+		return BadRequest(c, code, message)
+	}
+	include, ok := parseTalentInclude(c)
+	if !ok {
+		return BadRequest(c, "INVALID_INCLUDE", "Include hanya boleh berisi detail, user, dan school")
+	}
 
-	talents, total, err := h.talentService.List(c.Context(), params)
+	talents, total, err := h.talentService.ListIncluding(c.Context(), params, include)
 	if err != nil {
 		return InternalError(c)
 	}
 
 	var resp []domain.TalentListResponse
 	for _, talent := range talents {
-		resp = append(resp, h.toTalentListResponse(c, &talent))
+		resp = append(resp, toTalentListResponse(&talent))
 	}
 
 	meta := domain.PaginationMeta{
@@ -81,7 +98,7 @@ func (h *TalentHandler) GetByID(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_ID", "ID tidak valid")
 	}
 
-	talent, err := h.talentService.GetByID(c.Context(), id)
+	talent, err := h.talentService.GetVisible(c.Context(), id, GetClaims(c).UserID)
 	if err != nil {
 		if err == service.ErrTalentNotFound {
 			return NotFound(c, "Talenta tidak ditemukan")
@@ -93,6 +110,149 @@ func (h *TalentHandler) GetByID(c *fiber.Ctx) error {
 	return Success(c, resp)
 }
 
+func (h *TalentHandler) History(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	talent, err := h.talentService.GetVisible(c.Context(), id, GetClaims(c).UserID)
+	if err != nil {
+		if err == service.ErrTalentNotFound {
+			return NotFound(c, "Talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if message := h.viewDeniedMessage(c, talent); message != "" {
+		return Forbidden(c, message)
+	}
+
+	entries, err := h.talentService.GetHistory(c.Context(), talent.ID)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	resp := []domain.TalentHistoryResponse{}
+	for _, entry := range entries {
+		item := domain.TalentHistoryResponse{
+			ID:             entry.ID,
+			Action:         entry.Action,
+			FromStatus:     entry.FromStatus,
+			ToStatus:       entry.ToStatus,
+			Reason:         entry.Reason,
+			DetailSnapshot: entry.DetailSnapshot,
+			BatchID:        entry.BatchID,
+			CreatedAt:      entry.CreatedAt,
+		}
+		if entry.ActorID != nil {
+			actor, _ := h.talentService.GetUser(c.Context(), *entry.ActorID)
+			if actor != nil {
+				item.Actor = &domain.UserRef{ID: actor.ID, FullName: actor.FullName}
+			}
+		}
+		resp = append(resp, item)
+	}
+
+	return Success(c, resp)
+}
+
+func (h *TalentHandler) ListVersions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	talent, err := h.talentService.GetVisible(c.Context(), id, GetClaims(c).UserID)
+	if err != nil {
+		if err == service.ErrTalentNotFound {
+			return NotFound(c, "Talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if message := h.viewDeniedMessage(c, talent); message != "" {
+		return Forbidden(c, message)
+	}
+
+	versions, err := h.talentService.ListVersions(c.Context(), talent.ID)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	resp := []domain.TalentVersionResponse{}
+	for _, version := range versions {
+		item := domain.TalentVersionResponse{
+			Version:    version.Version,
+			Detail:     version.Detail,
+			ApprovedAt: version.ApprovedAt,
+			CreatedAt:  version.CreatedAt,
+		}
+		if version.CreatedBy != nil {
+			author, _ := h.talentService.GetUser(c.Context(), *version.CreatedBy)
+			if author != nil {
+				item.CreatedBy = &domain.UserRef{ID: author.ID, FullName: author.FullName}
+			}
+		}
+		resp = append(resp, item)
+	}
+
+	return Success(c, resp)
+}
+
+// DiffVersions compares two versions of a talent. Versions are given by
+// number or as "latest" / "approved".
+func (h *TalentHandler) DiffVersions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	talent, err := h.talentService.GetVisible(c.Context(), id, GetClaims(c).UserID)
+	if err != nil {
+		if err == service.ErrTalentNotFound {
+			return NotFound(c, "Talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	if message := h.viewDeniedMessage(c, talent); message != "" {
+		return Forbidden(c, message)
+	}
+
+	diff, err := h.talentService.DiffVersions(c.Context(), talent.ID, c.Params("a"), c.Params("b"))
+	if err != nil {
+		if err == service.ErrVersionNotFound {
+			return NotFound(c, "Versi talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	return Success(c, diff)
+}
+
+// viewDeniedMessage returns why the caller may not see the history or versions
+// of a talent, or "" when allowed. Access is limited to the talent owner and
+// to verifiers responsible for the owner's school.
+func (h *TalentHandler) viewDeniedMessage(c *fiber.Ctx, talent *domain.Talent) string {
+	claims := GetClaims(c)
+	switch claims.Role {
+	case domain.RoleSuperAdmin:
+		return ""
+	case domain.RoleAdminSekolah:
+		user, _ := h.talentService.GetUser(c.Context(), talent.UserID)
+		if user == nil || user.SchoolID == nil || claims.SchoolID == nil || *user.SchoolID != *claims.SchoolID {
+			return "Anda hanya dapat melihat riwayat talenta GTK di sekolah Anda"
+		}
+		return ""
+	default:
+		if talent.UserID != claims.UserID {
+			return "Anda hanya dapat melihat riwayat talenta milik sendiri"
+		}
+		return ""
+	}
+}
+
 func (h *TalentHandler) Create(c *fiber.Ctx) error {
 	claims := GetClaims(c)
 	var req domain.CreateTalentRequest
@@ -100,27 +260,32 @@ func (h *TalentHandler) Create(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
 	}
 
-	// Validate detail based on talent type
-	if err := h.validateTalentDetail(req.TalentType, req.Detail); err != nil {
-		return ValidationError(c, err)
+	// The legacy upload_id is stored as a certificate attachment
+	attachmentReqs := req.Attachments
+	if req.UploadID != nil {
+		attachmentReqs = append([]domain.AttachmentRequest{
+			{UploadID: *req.UploadID, Kind: domain.AttachmentCertificate},
+		}, attachmentReqs...)
 	}
 
-	// Get certificate URL if upload_id provided
-	var certURL *string
-	if req.UploadID != nil {
-		info, ok := h.uploadService.GetUploadInfo(*req.UploadID)
-		if ok {
-			url := h.uploadService.GetFileURL(info.ObjectName)
-			certURL = &url
-		}
+	attachments, errs := h.resolveAttachments(claims.UserID, attachmentReqs)
+	if len(errs) > 0 {
+		return ValidationError(c, errs)
 	}
 
-	talent, err := h.talentService.Create(c.Context(), claims.UserID, req, certURL)
+	talent, err := h.talentService.Create(c.Context(), claims.UserID, req, attachments)
 	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
 		return InternalError(c)
 	}
+	h.releaseUploads(attachmentReqs)
 
 	resp := h.toTalentResponse(c, talent)
+	if talent.Status == domain.TalentStatusDraft {
+		return SuccessCreated(c, resp, "Draf talenta berhasil disimpan")
+	}
 	return SuccessCreated(c, resp, "Talenta berhasil ditambahkan dan menunggu verifikasi")
 }
 
@@ -136,18 +301,23 @@ func (h *TalentHandler) Update(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
 	}
 
-	// Get certificate URL if upload_id provided
-	var certURL *string
+	// A new upload_id replaces the certificate attachments
+	var certificate *domain.TalentAttachment
+	var attachmentReqs []domain.AttachmentRequest
 	if req.UploadID != nil {
-		info, ok := h.uploadService.GetUploadInfo(*req.UploadID)
-		if ok {
-			url := h.uploadService.GetFileURL(info.ObjectName)
-			certURL = &url
+		attachmentReqs = []domain.AttachmentRequest{{UploadID: *req.UploadID, Kind: domain.AttachmentCertificate}}
+		attachments, errs := h.resolveAttachments(claims.UserID, attachmentReqs)
+		if len(errs) > 0 {
+			return ValidationError(c, errs)
 		}
+		certificate = &attachments[0]
 	}
 
-	talent, err := h.talentService.Update(c.Context(), id, claims.UserID, req, certURL)
+	talent, err := h.talentService.Update(c.Context(), id, claims.UserID, req, certificate)
 	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
 		switch err {
 		case service.ErrTalentNotFound:
 			return NotFound(c, "Talenta tidak ditemukan")
@@ -158,10 +328,184 @@ func (h *TalentHandler) Update(c *fiber.Ctx) error {
 		}
 	}
 
+	h.releaseUploads(attachmentReqs)
+
 	resp := h.toTalentResponse(c, talent)
+	if talent.Status == domain.TalentStatusDraft {
+		return SuccessWithMessage(c, resp, "Draf talenta berhasil diperbarui")
+	}
 	return SuccessWithMessage(c, resp, "Talenta berhasil diperbarui dan menunggu verifikasi ulang")
 }
 
+// Submit sends a draft talent to verification.
+func (h *TalentHandler) Submit(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	talent, err := h.talentService.Submit(c.Context(), id, claims.UserID)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrForbidden:
+			return Forbidden(c, "Anda hanya dapat mengajukan talenta milik sendiri")
+		case service.ErrNotDraft:
+			return BadRequest(c, "NOT_DRAFT", "Talenta sudah diajukan")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	resp := h.toTalentResponse(c, talent)
+	return SuccessWithMessage(c, resp, "Talenta berhasil diajukan dan menunggu verifikasi")
+}
+
+// Withdraw pulls a pending talent back to draft.
+func (h *TalentHandler) Withdraw(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	talent, err := h.talentService.Withdraw(c.Context(), id, claims.UserID)
+	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrForbidden:
+			return Forbidden(c, "Anda hanya dapat menarik talenta milik sendiri")
+		case service.ErrNotWithdrawable:
+			return BadRequest(c, "NOT_WITHDRAWABLE", "Hanya talenta yang menunggu verifikasi sekolah yang dapat ditarik")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	resp := h.toTalentResponse(c, talent)
+	return SuccessWithMessage(c, resp, "Talenta berhasil ditarik menjadi draf")
+}
+
+func (h *TalentHandler) AddAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	var req domain.AttachmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	attachmentReqs := []domain.AttachmentRequest{req}
+	attachments, errs := h.resolveAttachments(claims.UserID, attachmentReqs)
+	if len(errs) > 0 {
+		return ValidationError(c, errs)
+	}
+
+	talent, err := h.talentService.AddAttachment(c.Context(), id, claims.UserID, &attachments[0])
+	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrForbidden:
+			return Forbidden(c, "Anda hanya dapat mengubah talenta milik sendiri")
+		default:
+			return InternalError(c)
+		}
+	}
+	h.releaseUploads(attachmentReqs)
+
+	resp := h.toTalentResponse(c, talent)
+	return SuccessCreated(c, resp, "Lampiran berhasil ditambahkan dan talenta menunggu verifikasi ulang")
+}
+
+func (h *TalentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachment_id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID lampiran tidak valid")
+	}
+
+	claims := GetClaims(c)
+	talent, err := h.talentService.DeleteAttachment(c.Context(), id, attachmentID, claims.UserID)
+	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrAttachmentNotFound:
+			return NotFound(c, "Lampiran tidak ditemukan")
+		case service.ErrForbidden:
+			return Forbidden(c, "Anda hanya dapat mengubah talenta milik sendiri")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	resp := h.toTalentResponse(c, talent)
+	return SuccessWithMessage(c, resp, "Lampiran berhasil dihapus dan talenta menunggu verifikasi ulang")
+}
+
+// resolveAttachments turns confirmed uploads of the user into attachments.
+func (h *TalentHandler) resolveAttachments(userID uuid.UUID, reqs []domain.AttachmentRequest) ([]domain.TalentAttachment, []domain.FieldError) {
+	var errors []domain.FieldError
+	var attachments []domain.TalentAttachment
+
+	for i, req := range reqs {
+		prefix := "attachments[" + strconv.Itoa(i) + "]"
+		if req.Kind == "" {
+			req.Kind = domain.AttachmentOther
+		}
+		if !req.Kind.IsValid() {
+			errors = append(errors, domain.FieldError{Field: prefix + ".kind", Message: "Jenis lampiran harus certificate, photo, sk atau other"})
+			continue
+		}
+
+		info, err := h.uploadService.GetConfirmedUpload(req.UploadID, userID)
+		if err != nil {
+			message := "Upload tidak ditemukan atau sudah expired"
+			if err == service.ErrFileNotUploaded {
+				message = "Upload belum dikonfirmasi"
+			}
+			errors = append(errors, domain.FieldError{Field: prefix + ".upload_id", Message: message})
+			continue
+		}
+
+		filename, contentType, size := info.Filename, info.ContentType, info.FileSize
+		attachment := domain.TalentAttachment{
+			Kind:        req.Kind,
+			Caption:     req.Caption,
+			FileURL:     h.uploadService.GetFileURL(info.ObjectName),
+			Filename:    &filename,
+			ContentType: &contentType,
+			FileSize:    &size,
+		}
+		if info.FileHash != "" {
+			hash := info.FileHash
+			attachment.FileHash = &hash
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, errors
+}
+
+func (h *TalentHandler) releaseUploads(reqs []domain.AttachmentRequest) {
+	for _, req := range reqs {
+		h.uploadService.Release(req.UploadID)
+	}
+}
+
 func (h *TalentHandler) Delete(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
@@ -192,17 +536,93 @@ func (h *TalentHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
 	}
 
 	return domain.ListParams{
-		Page:   page,
-		Limit:  limit,
-		Search: c.Query("search"),
-		Sort:   c.Query("sort"),
-		Filters: map[string]string{
-			"user_id":     c.Query("user_id"),
-			"school_id":   c.Query("school_id"),
-			"talent_type": c.Query("talent_type"),
-			"status":      c.Query("status"),
-		},
+		Page:    page,
+		Limit:   limit,
+		Search:  strings.TrimSpace(c.Query("q", c.Query("search"))),
+		Sort:    c.Query("sort"),
+		Filters: parseTalentFilters(func(key string) string { return c.Query(key) }),
+	}
+}
+
+// talentFilterKeys are the filters of GET /talents, besides the q search.
+var talentFilterKeys = []string{
+	"user_id", "school_id", "talent_type", "status", "created_from", "created_to",
+	"competition_id", "organizer_id", "certificate_status", "expiring_within", "exclude_expired",
+}
+
+// parseTalentFilters reads the GET /talents filters through get, which
+// returns "" for a filter that is not set. Batch selections use it for their
+// filters object, so they match exactly what the list would show.
+func parseTalentFilters(get func(key string) string) map[string]string {
+	filters := make(map[string]string, len(talentFilterKeys))
+	for _, key := range talentFilterKeys {
+		filters[key] = get(key)
+	}
+
+	// Certificates expiring within the first reminder by default
+	if filters["expiring_within"] == "" {
+		filters["expiring_within"] = "60"
+	}
+	excludeExpired, _ := strconv.ParseBool(filters["exclude_expired"])
+	filters["exclude_expired"] = strconv.FormatBool(excludeExpired)
+	return filters
+}
+
+// checkTalentFilters validates filters parsed by parseTalentFilters and
+// returns the error code and message of the first invalid one, or an empty
+// code when all are valid.
+func checkTalentFilters(filters map[string]string) (string, string) {
+	switch {
+	case !validDateFilters(filters):
+		return "INVALID_DATE", "Format tanggal harus YYYY-MM-DD"
+	case !validIDFilters(filters, "user_id", "school_id"):
+		return "INVALID_ID", "ID tidak valid"
+	case !validIDFilters(filters, "competition_id", "organizer_id"):
+		return "INVALID_ID", "ID katalog tidak valid"
+	case !validCertificateFilters(filters):
+		return "INVALID_CERTIFICATE_FILTER", "certificate_status harus expiring atau expired, expiring_within berupa jumlah hari"
 	}
+	return "", ""
+}
+
+// validIDFilters checks filters holding an ID.
+func validIDFilters(filters map[string]string, keys ...string) bool {
+	for _, key := range keys {
+		if value := filters[key]; value != "" {
+			if _, err := uuid.Parse(value); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validCertificateFilters checks the certificate_status and expiring_within
+// filters.
+func validCertificateFilters(filters map[string]string) bool {
+	switch filters["certificate_status"] {
+	case "", "expired":
+	case "expiring":
+		days, err := strconv.Atoi(filters["expiring_within"])
+		if err != nil || days < 0 || days > 3650 {
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// validDateFilters checks the created_from and created_to filters.
+func validDateFilters(filters map[string]string) bool {
+	for _, key := range []string{"created_from", "created_to"} {
+		if value := filters[key]; value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 func (h *TalentHandler) toTalentResponse(c *fiber.Ctx, talent *domain.Talent) domain.TalentResponse {
@@ -225,10 +645,38 @@ func (h *TalentHandler) toTalentResponse(c *fiber.Ctx, talent *domain.Talent) do
 		}
 	}
 
-	// Get detail and certificate URL
-	detail, certURL, _ := h.talentService.GetDetail(c.Context(), talent)
+	// Get detail and attachments; certificate_url keeps pointing at the
+	// latest certificate for older clients
+	detail, _ := h.talentService.GetDetail(c.Context(), talent)
 	resp.Detail = detail
-	resp.CertificateURL = certURL
+
+	attachments, _ := h.talentService.GetAttachments(c.Context(), talent.ID)
+	resp.Attachments = toAttachmentResponses(attachments)
+	for _, attachment := range attachments {
+		if attachment.Kind == domain.AttachmentCertificate {
+			url := attachment.FileURL
+			resp.CertificateURL = &url
+		}
+	}
+
+	if talent.SchoolVerifiedBy != nil {
+		verifier, _ := h.talentService.GetUser(c.Context(), *talent.SchoolVerifiedBy)
+		if verifier != nil {
+			resp.SchoolVerifiedBy = &domain.UserRef{
+				ID:       verifier.ID,
+				FullName: verifier.FullName,
+			}
+		}
+	}
+	resp.SchoolVerifiedAt = talent.SchoolVerifiedAt
+
+	links, _ := h.talentService.GetCatalogLinks(c.Context(), talent.ID)
+	if ref, ok := links[domain.CatalogCompetition]; ok {
+		resp.Competition = &ref
+	}
+	if ref, ok := links[domain.CatalogOrganizer]; ok {
+		resp.Organizer = &ref
+	}
 
 	// Get verifier info
 	if talent.VerifiedBy != nil {
@@ -241,117 +689,131 @@ func (h *TalentHandler) toTalentResponse(c *fiber.Ctx, talent *domain.Talent) do
 		}
 	}
 
+	// Review comments from revision requests
+	comments, _ := h.talentService.GetReviewComments(c.Context(), talent.ID)
+	for _, comment := range comments {
+		item := domain.ReviewCommentResponse{
+			ID:         comment.ID,
+			Field:      comment.Field,
+			Comment:    comment.Comment,
+			IsResolved: comment.IsResolved,
+			ResolvedAt: comment.ResolvedAt,
+			CreatedAt:  comment.CreatedAt,
+		}
+		if comment.AuthorID != nil {
+			author, _ := h.talentService.GetUser(c.Context(), *comment.AuthorID)
+			if author != nil {
+				item.Author = &domain.UserRef{ID: author.ID, FullName: author.FullName}
+			}
+		}
+		resp.ReviewComments = append(resp.ReviewComments, item)
+	}
+
+	// Suspected duplicates point at other GTKs' talents, so only verifiers see them
+	if claims := GetClaims(c); claims.Role == domain.RoleSuperAdmin || claims.Role == domain.RoleAdminSekolah {
+		matches, _ := h.talentService.GetDuplicateMatches(c.Context(), talent.ID)
+		resp.SuspectedDuplicate = len(matches) > 0
+		resp.DuplicateMatches = toDuplicateMatchResponses(c, h.talentService, matches)
+	}
+
 	return resp
 }
 
-func (h *TalentHandler) toTalentListResponse(c *fiber.Ctx, talent *domain.Talent) domain.TalentListResponse {
+func toTalentListResponse(talent *domain.TalentListItem) domain.TalentListResponse {
 	resp := domain.TalentListResponse{
 		ID:         talent.ID,
 		TalentType: talent.TalentType,
 		Status:     talent.Status,
+		Detail:     talent.Detail,
+		Highlight:  talent.Highlight,
 		CreatedAt:  talent.CreatedAt,
 		UpdatedAt:  talent.UpdatedAt,
 	}
 
-	// Get user info
-	user, _ := h.talentService.GetUser(c.Context(), talent.UserID)
-	if user != nil {
-		schoolName := ""
-		if user.SchoolID != nil {
-			// Would need school service here, simplified for now
-		}
+	if talent.Owner != nil {
 		resp.User = &domain.TalentUser{
-			ID:         user.ID,
-			FullName:   user.FullName,
-			SchoolName: schoolName,
+			ID:       talent.Owner.ID,
+			FullName: talent.Owner.FullName,
+			NIP:      talent.Owner.NIP,
+			PhotoURL: talent.Owner.PhotoURL,
+		}
+		if talent.School != nil {
+			resp.User.SchoolName = talent.School.Name
 		}
 	}
-
-	// Get detail
-	detail, _, _ := h.talentService.GetDetail(c.Context(), talent)
-	resp.Detail = detail
+	resp.School = talent.School
 
 	return resp
 }
 
-func (h *TalentHandler) validateTalentDetail(talentType domain.TalentType, detail interface{}) []domain.FieldError {
-	var errors []domain.FieldError
-
-	if detail == nil {
-		errors = append(errors, domain.FieldError{Field: "detail", Message: "Detail wajib diisi"})
-		return errors
-	}
-
-	// Convert detail to map for validation
-	detailMap, ok := detail.(map[string]interface{})
+// parseTalentInclude reads the include query parameter of talent lists, a
+// comma separated subset of detail, user and school. Without it lists embed
+// the detail and owner, as they always have.
+func parseTalentInclude(c *fiber.Ctx) (domain.TalentInclude, bool) {
+	value, ok := c.Queries()["include"]
 	if !ok {
-		errors = append(errors, domain.FieldError{Field: "detail", Message: "Format detail tidak valid"})
-		return errors
+		return domain.TalentInclude{Detail: true, User: true}, true
 	}
 
-	switch talentType {
-	case domain.TalentTypePesertaPelatihan:
-		if detailMap["activity_name"] == nil || detailMap["activity_name"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.activity_name", Message: "Nama kegiatan wajib diisi"})
-		}
-		if detailMap["organizer"] == nil || detailMap["organizer"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.organizer", Message: "Penyelenggara wajib diisi"})
-		}
-		if detailMap["start_date"] == nil || detailMap["start_date"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.start_date", Message: "Tanggal mulai wajib diisi"})
-		}
-		if detailMap["duration_days"] == nil {
-			errors = append(errors, domain.FieldError{Field: "detail.duration_days", Message: "Jangka waktu wajib diisi"})
+	var include domain.TalentInclude
+	for _, part := range strings.Split(value, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "detail":
+			include.Detail = true
+		case "user":
+			include.User = true
+		case "school":
+			include.School = true
+		default:
+			return include, false
 		}
+	}
+	return include, true
+}
 
-	case domain.TalentTypePembimbingLomba:
-		if detailMap["competition_name"] == nil || detailMap["competition_name"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.competition_name", Message: "Nama lomba wajib diisi"})
-		}
-		if detailMap["level"] == nil || detailMap["level"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.level", Message: "Jenjang wajib diisi"})
-		}
-		if detailMap["organizer"] == nil || detailMap["organizer"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.organizer", Message: "Penyelenggara wajib diisi"})
-		}
-		if detailMap["field"] == nil || detailMap["field"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.field", Message: "Bidang wajib diisi"})
-		}
-		if detailMap["achievement"] == nil || detailMap["achievement"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.achievement", Message: "Prestasi wajib diisi"})
+func toDuplicateMatchResponses(c *fiber.Ctx, talentService *service.TalentService, matches []domain.TalentDuplicateMatch) []domain.DuplicateMatchResponse {
+	var resp []domain.DuplicateMatchResponse
+	for _, match := range matches {
+		other, err := talentService.GetByID(c.Context(), match.MatchID)
+		if err != nil || other.Status == domain.TalentStatusDraft {
+			continue
 		}
 
-	case domain.TalentTypePesertaLomba:
-		if detailMap["competition_name"] == nil || detailMap["competition_name"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.competition_name", Message: "Nama lomba wajib diisi"})
-		}
-		if detailMap["level"] == nil || detailMap["level"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.level", Message: "Jenjang wajib diisi"})
-		}
-		if detailMap["organizer"] == nil || detailMap["organizer"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.organizer", Message: "Penyelenggara wajib diisi"})
-		}
-		if detailMap["field"] == nil || detailMap["field"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.field", Message: "Bidang wajib diisi"})
-		}
-		if detailMap["start_date"] == nil || detailMap["start_date"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.start_date", Message: "Tanggal mulai wajib diisi"})
-		}
-		if detailMap["competition_field"] == nil || detailMap["competition_field"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.competition_field", Message: "Bidang lomba wajib diisi"})
-		}
-		if detailMap["achievement"] == nil || detailMap["achievement"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.achievement", Message: "Prestasi wajib diisi"})
+		var owner *domain.UserRef
+		if user, _ := talentService.GetUser(c.Context(), other.UserID); user != nil {
+			owner = &domain.UserRef{ID: user.ID, FullName: user.FullName}
 		}
+		resp = append(resp, duplicateMatchResponse(match, other.TalentType, other.Status, owner))
+	}
+	return resp
+}
 
-	case domain.TalentTypeMinatBakat:
-		if detailMap["interest_name"] == nil || detailMap["interest_name"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.interest_name", Message: "Nama minat/bakat wajib diisi"})
-		}
-		if detailMap["description"] == nil || detailMap["description"] == "" {
-			errors = append(errors, domain.FieldError{Field: "detail.description", Message: "Deskripsi wajib diisi"})
-		}
+func duplicateMatchResponse(match domain.TalentDuplicateMatch, talentType domain.TalentType, status domain.TalentStatus, owner *domain.UserRef) domain.DuplicateMatchResponse {
+	return domain.DuplicateMatchResponse{
+		TalentID:   match.MatchID,
+		TalentType: talentType,
+		Status:     status,
+		User:       owner,
+		Reasons:    match.Reasons,
+		Score:      match.Score,
+		Link:       "/talents/" + match.MatchID.String(),
 	}
+}
 
-	return errors
+func toAttachmentResponses(attachments []domain.TalentAttachment) []domain.AttachmentResponse {
+	resp := []domain.AttachmentResponse{}
+	for _, attachment := range attachments {
+		resp = append(resp, domain.AttachmentResponse{
+			ID:          attachment.ID,
+			Kind:        attachment.Kind,
+			Caption:     attachment.Caption,
+			FileURL:     attachment.FileURL,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			FileSize:    attachment.FileSize,
+			CreatedAt:   attachment.CreatedAt,
+		})
+	}
+	return resp
 }
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/service"
+)
+
+type TalentTypeHandler struct {
+	talentTypeService *service.TalentTypeService
+}
+
+func NewTalentTypeHandler(talentTypeService *service.TalentTypeService) *TalentTypeHandler {
+	return &TalentTypeHandler{talentTypeService: talentTypeService}
+}
+
+// List returns the active talent types. A super_admin can pass
+// include_inactive=true to see deactivated ones too.
+func (h *TalentTypeHandler) List(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	activeOnly := !(claims.Role == domain.RoleSuperAdmin && c.QueryBool("include_inactive"))
+
+	defs, err := h.talentTypeService.List(c.Context(), activeOnly)
+	if err != nil {
+		return InternalError(c)
+	}
+	if defs == nil {
+		defs = []domain.TalentTypeDefinition{}
+	}
+	return Success(c, defs)
+}
+
+func (h *TalentTypeHandler) GetByCode(c *fiber.Ctx) error {
+	def, err := h.talentTypeService.GetByCode(c.Context(), domain.TalentType(c.Params("code")))
+	if err != nil {
+		if err == service.ErrTalentTypeNotFound {
+			return NotFound(c, "Jenis talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+	return Success(c, def)
+}
+
+func (h *TalentTypeHandler) Create(c *fiber.Ctx) error {
+	var req domain.CreateTalentTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	def, err := h.talentTypeService.Create(c.Context(), req)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		if err == service.ErrDuplicateTalentType {
+			return Conflict(c, "DUPLICATE_TALENT_TYPE", "Kode jenis talenta sudah terdaftar")
+		}
+		return InternalError(c)
+	}
+	return SuccessCreated(c, def, "Jenis talenta berhasil ditambahkan")
+}
+
+func (h *TalentTypeHandler) Update(c *fiber.Ctx) error {
+	var req domain.UpdateTalentTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	def, err := h.talentTypeService.Update(c.Context(), domain.TalentType(c.Params("code")), req)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		if err == service.ErrTalentTypeNotFound {
+			return NotFound(c, "Jenis talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+	return SuccessWithMessage(c, def, "Jenis talenta berhasil diperbarui")
+}
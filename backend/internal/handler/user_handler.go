@@ -37,11 +37,74 @@ func (h *UserHandler) UpdateMe(c *fiber.Ctx) error {
 
 	user, err := h.userService.UpdateProfile(c.Context(), claims.UserID, req)
 	if err != nil {
+		if err == service.ErrEmailTaken {
+			return Conflict(c, "EMAIL_TAKEN", "Email sudah terdaftar")
+		}
 		return InternalError(c)
 	}
 
 	resp := h.toUserResponse(c, user)
-	return SuccessWithMessage(c, resp, "Profil berhasil diperbarui")
+	message := "Profil berhasil diperbarui"
+	if resp.PendingEmail != nil {
+		message = "Profil berhasil diperbarui. Cek email baru Anda untuk mengonfirmasi perubahan email"
+	}
+	return SuccessWithMessage(c, resp, message)
+}
+
+func (h *UserHandler) ResendVerification(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	if err := h.userService.ResendVerification(c.Context(), claims.UserID); err != nil {
+		if err == service.ErrEmailAlreadyVerified {
+			return BadRequest(c, "EMAIL_ALREADY_VERIFIED", "Email sudah terverifikasi")
+		}
+		return InternalError(c)
+	}
+
+	return Message(c, "Link verifikasi telah dikirim ke email Anda")
+}
+
+func (h *UserHandler) CancelEmailChange(c *fiber.Ctx) error {
+	claims := GetClaims(c)
+	if err := h.userService.CancelEmailChange(c.Context(), claims.UserID); err != nil {
+		return InternalError(c)
+	}
+
+	return Message(c, "Perubahan email dibatalkan")
+}
+
+func (h *UserHandler) ConfirmEmail(c *fiber.Ctx) error {
+	var req domain.ConfirmEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+
+	if req.Token == "" {
+		return ValidationError(c, []domain.FieldError{
+			{Field: "token", Message: "Token wajib diisi"},
+		})
+	}
+
+	verification, err := h.userService.ConfirmEmail(c.Context(), req.Token)
+	if err != nil {
+		switch err {
+		case service.ErrVerificationTokenInvalid:
+			return BadRequest(c, "INVALID_TOKEN", "Token verifikasi tidak valid atau sudah kadaluarsa")
+		case service.ErrEmailTaken:
+			return Conflict(c, "EMAIL_TAKEN", "Email sudah terdaftar")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	message := "Email berhasil diverifikasi"
+	if verification.Purpose == domain.EmailTokenChange {
+		message = "Email berhasil diubah"
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"email":        verification.Email,
+		"confirmed_at": verification.ConfirmedAt,
+	}, message)
 }
 
 func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
@@ -66,7 +129,7 @@ func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
 		return ValidationError(c, errors)
 	}
 
-	err := h.userService.ChangePassword(c.Context(), claims.UserID, req)
+	err := h.userService.ChangePassword(c.Context(), claims.UserID, req, RequestMeta(c))
 	if err != nil {
 		if err == service.ErrInvalidPassword {
 			return BadRequest(c, "INVALID_PASSWORD", "Password lama tidak sesuai")
@@ -146,7 +209,7 @@ func (h *UserHandler) Create(c *fiber.Ctx) error {
 		}
 	}
 
-	user, err := h.userService.Create(c.Context(), req)
+	user, err := h.userService.Create(c.Context(), req, claims.UserID)
 	if err != nil {
 		switch err {
 		case service.ErrEmailTaken:
@@ -175,12 +238,26 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
 	}
 
-	user, err := h.userService.Update(c.Context(), id, req)
+	claims := GetClaims(c)
+	if req.Role != nil {
+		if claims.Role != domain.RoleSuperAdmin {
+			return Forbidden(c, "Hanya super admin yang dapat mengubah role user")
+		}
+	}
+
+	user, err := h.userService.Update(c.Context(), id, req, claims.UserID, RequestMeta(c))
 	if err != nil {
-		if err == service.ErrUserNotFound {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
+		switch err {
+		case service.ErrUserNotFound:
 			return NotFound(c, "User tidak ditemukan")
+		case service.ErrEmailTaken:
+			return Conflict(c, "EMAIL_TAKEN", "Email sudah terdaftar")
+		default:
+			return InternalError(c)
 		}
-		return InternalError(c)
 	}
 
 	resp := h.toUserResponse(c, user)
@@ -215,7 +292,8 @@ func (h *UserHandler) Activate(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_ID", "ID tidak valid")
 	}
 
-	if err := h.userService.Activate(c.Context(), id); err != nil {
+	claims := GetClaims(c)
+	if err := h.userService.Activate(c.Context(), id, claims.UserID, RequestMeta(c)); err != nil {
 		if err == service.ErrUserNotFound {
 			return NotFound(c, "User tidak ditemukan")
 		}
@@ -231,7 +309,8 @@ func (h *UserHandler) Deactivate(c *fiber.Ctx) error {
 		return BadRequest(c, "INVALID_ID", "ID tidak valid")
 	}
 
-	if err := h.userService.Deactivate(c.Context(), id); err != nil {
+	claims := GetClaims(c)
+	if err := h.userService.Deactivate(c.Context(), id, claims.UserID, RequestMeta(c)); err != nil {
 		if err == service.ErrUserNotFound {
 			return NotFound(c, "User tidak ditemukan")
 		}
@@ -254,30 +333,34 @@ func (h *UserHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
 		Search: c.Query("search"),
 		Sort:   c.Query("sort"),
 		Filters: map[string]string{
-			"role":      c.Query("role"),
-			"school_id": c.Query("school_id"),
-			"gtk_type":  c.Query("gtk_type"),
-			"is_active": c.Query("is_active"),
+			"role":           c.Query("role"),
+			"school_id":      c.Query("school_id"),
+			"gtk_type":       c.Query("gtk_type"),
+			"is_active":      c.Query("is_active"),
+			"email_verified": c.Query("email_verified"),
 		},
 	}
 }
 
 func (h *UserHandler) toUserResponse(c *fiber.Ctx, user *domain.User) domain.UserResponse {
 	resp := domain.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Role:      user.Role,
-		FullName:  user.FullName,
-		PhotoURL:  user.PhotoURL,
-		NUPTK:     user.NUPTK,
-		NIP:       user.NIP,
-		Gender:    user.Gender,
-		GTKType:   user.GTKType,
-		Position:  user.Position,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
+		ID:              user.ID,
+		Email:           user.Email,
+		Role:            user.Role,
+		FullName:        user.FullName,
+		PhotoURL:        user.PhotoURL,
+		NUPTK:           user.NUPTK,
+		NIP:             user.NIP,
+		Gender:          user.Gender,
+		GTKType:         user.GTKType,
+		Position:        user.Position,
+		IsActive:        user.IsActive,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+
+	resp.PendingEmail, _ = h.userService.GetPendingEmail(c.Context(), user.ID)
 
 	if user.BirthDate != nil {
 		str := user.BirthDate.Format("2006-01-02")
@@ -300,17 +383,18 @@ func (h *UserHandler) toUserResponse(c *fiber.Ctx, user *domain.User) domain.Use
 
 func (h *UserHandler) toUserListResponse(c *fiber.Ctx, user *domain.User) domain.UserListResponse {
 	resp := domain.UserListResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Role:      user.Role,
-		FullName:  user.FullName,
-		PhotoURL:  user.PhotoURL,
-		NUPTK:     user.NUPTK,
-		NIP:       user.NIP,
-		GTKType:   user.GTKType,
-		Position:  user.Position,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
+		ID:              user.ID,
+		Email:           user.Email,
+		Role:            user.Role,
+		FullName:        user.FullName,
+		PhotoURL:        user.PhotoURL,
+		NUPTK:           user.NUPTK,
+		NIP:             user.NIP,
+		GTKType:         user.GTKType,
+		Position:        user.Position,
+		IsActive:        user.IsActive,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
 	}
 
 	if user.SchoolID != nil {
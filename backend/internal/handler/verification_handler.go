@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -19,8 +22,18 @@ func NewVerificationHandler(talentService *service.TalentService) *VerificationH
 
 func (h *VerificationHandler) ListPending(c *fiber.Ctx) error {
 	params := h.parseListParams(c)
-	if params.Filters["status"] == "" {
-		params.Filters["status"] = "pending"
+
+	// Each verification stage has its own queue; an explicit status without a
+	// stage is still honoured
+	stage := domain.VerificationStage(c.Query("stage"))
+	switch {
+	case stage == "" && params.Filters["status"] != "":
+	case stage == "" || stage == domain.VerificationStageSchool:
+		params.Filters["status"] = string(domain.TalentStatusPending)
+	case stage == domain.VerificationStageEndorsement:
+		params.Filters["status"] = string(domain.TalentStatusSchoolApproved)
+	default:
+		return BadRequest(c, "INVALID_STAGE", "Stage harus school atau endorsement")
 	}
 
 	// Admin sekolah can only see talents from their school
@@ -29,29 +42,98 @@ func (h *VerificationHandler) ListPending(c *fiber.Ctx) error {
 		params.Filters["school_id"] = claims.SchoolID.String()
 	}
 
-	talents, total, err := h.talentService.List(c.Context(), params)
+	switch assignee := params.Filters["assignee"]; assignee {
+	case "", "none":
+	case "me":
+		params.Filters["assignee"] = claims.UserID.String()
+	default:
+		if _, err := uuid.Parse(assignee); err != nil {
+			return BadRequest(c, "INVALID_ASSIGNEE", "Assignee harus me, none, atau ID pengguna")
+		}
+	}
+	if level := params.Filters["level"]; level != "" && !domain.CompetitionLevel(level).IsValid() {
+		return BadRequest(c, "INVALID_LEVEL", "Jenjang harus kota, provinsi, nasional, atau internasional")
+	}
+	include, ok := parseTalentInclude(c)
+	if !ok {
+		return BadRequest(c, "INVALID_INCLUDE", "Include hanya boleh berisi detail, user, dan school")
+	}
+
+	// Oldest first, so items nearest to their SLA come up on top
+	if params.Sort == "" {
+		params.Sort = "queued_at"
+	}
+
+	talents, total, err := h.talentService.ListIncluding(c.Context(), params, include)
+	if err != nil {
+		return InternalError(c)
+	}
+
+	ids := make([]uuid.UUID, 0, len(talents))
+	for _, talent := range talents {
+		ids = append(ids, talent.ID)
+	}
+	details, err := h.talentService.GetQueuePageDetails(c.Context(), ids)
 	if err != nil {
 		return InternalError(c)
 	}
 
 	var resp []fiber.Map
 	for _, talent := range talents {
-		user, _ := h.talentService.GetUser(c.Context(), talent.UserID)
-		detail, _, _ := h.talentService.GetDetail(c.Context(), &talent)
+		entry := details.Entries[talent.ID]
 
 		item := fiber.Map{
 			"id":          talent.ID,
 			"talent_type": talent.TalentType,
 			"status":      talent.Status,
-			"detail":      detail,
+			"attachments": toAttachmentResponses(details.Attachments[talent.ID]),
+			"queued_at":   talent.QueuedAt,
+			"age_days":    int(time.Since(talent.QueuedAt).Hours() / 24),
+			"sla_status":  h.talentService.SLAStatus(&talent.Talent),
 			"created_at":  talent.CreatedAt,
 		}
+		if talent.Detail != nil {
+			item["detail"] = talent.Detail
+		}
+		if talent.School != nil {
+			item["school"] = talent.School
+		}
+		if talent.SchoolVerifiedAt != nil {
+			item["school_verified_at"] = talent.SchoolVerifiedAt
+		}
+		if assigneeID := entry.ActiveAssignee(time.Now()); assigneeID != nil {
+			assignee := fiber.Map{"id": assigneeID}
+			if u := details.Assignees[*assigneeID]; u != nil {
+				assignee["full_name"] = u.FullName
+			}
+			item["assignee"] = assignee
+			item["claim_expires_at"] = entry.ClaimExpiresAt
+		}
 
-		if user != nil {
+		matches := details.Duplicates[talent.ID]
+		item["suspected_duplicate"] = len(matches) > 0
+		if len(matches) > 0 {
+			var duplicates []domain.DuplicateMatchResponse
+			for _, match := range matches {
+				if match.MatchStatus == domain.TalentStatusDraft {
+					continue
+				}
+				owner := match.MatchOwner
+				duplicates = append(duplicates, duplicateMatchResponse(match.TalentDuplicateMatch, match.MatchType, match.MatchStatus, &owner))
+			}
+			item["duplicate_matches"] = duplicates
+		}
+
+		// Re-submitted talents open on what changed since the last approval
+		if changes := details.Changes[talent.ID]; changes != nil {
+			item["changes_since_approval"] = changes
+		}
+
+		if talent.Owner != nil {
 			item["user"] = fiber.Map{
-				"id":        user.ID,
-				"full_name": user.FullName,
-				"photo_url": user.PhotoURL,
+				"id":        talent.Owner.ID,
+				"full_name": talent.Owner.FullName,
+				"photo_url": talent.Owner.PhotoURL,
 			}
 		}
 
@@ -92,18 +174,32 @@ func (h *VerificationHandler) Approve(c *fiber.Ctx) error {
 		}
 	}
 
-	talent, err := h.talentService.Approve(c.Context(), id, claims.UserID)
+	talent, err := h.talentService.Approve(c.Context(), id, claims.UserID, claims.Role)
 	if err != nil {
 		switch err {
 		case service.ErrTalentNotFound:
 			return NotFound(c, "Talenta tidak ditemukan")
 		case service.ErrAlreadyVerified:
 			return BadRequest(c, "ALREADY_VERIFIED", "Talenta sudah diverifikasi sebelumnya")
+		case service.ErrEndorsementRequired:
+			return Forbidden(c, "Talenta sudah disetujui sekolah dan menunggu pengesahan dinas")
+		case service.ErrRevisionPending:
+			return BadRequest(c, "REVISION_PENDING", "Talenta sedang menunggu perbaikan dari GTK")
+		case service.ErrClaimedByOther:
+			return Conflict(c, "CLAIMED_BY_OTHER", "Talenta sedang ditangani verifikator lain")
 		default:
 			return InternalError(c)
 		}
 	}
 
+	if talent.Status == domain.TalentStatusSchoolApproved {
+		return SuccessWithMessage(c, fiber.Map{
+			"id":                 talent.ID,
+			"status":             talent.Status,
+			"school_verified_at": talent.SchoolVerifiedAt,
+		}, "Talenta disetujui sekolah dan diteruskan untuk pengesahan dinas")
+	}
+
 	return SuccessWithMessage(c, fiber.Map{
 		"id":          talent.ID,
 		"status":      talent.Status,
@@ -146,13 +242,19 @@ func (h *VerificationHandler) Reject(c *fiber.Ctx) error {
 		}
 	}
 
-	talent, err := h.talentService.Reject(c.Context(), id, claims.UserID, req.RejectionReason)
+	talent, err := h.talentService.Reject(c.Context(), id, claims.UserID, claims.Role, req.RejectionReason)
 	if err != nil {
 		switch err {
 		case service.ErrTalentNotFound:
 			return NotFound(c, "Talenta tidak ditemukan")
 		case service.ErrAlreadyVerified:
 			return BadRequest(c, "ALREADY_VERIFIED", "Talenta sudah diverifikasi sebelumnya")
+		case service.ErrEndorsementRequired:
+			return Forbidden(c, "Talenta sudah disetujui sekolah dan menunggu pengesahan dinas")
+		case service.ErrRevisionPending:
+			return BadRequest(c, "REVISION_PENDING", "Talenta sedang menunggu perbaikan dari GTK")
+		case service.ErrClaimedByOther:
+			return Conflict(c, "CLAIMED_BY_OTHER", "Talenta sedang ditangani verifikator lain")
 		default:
 			return InternalError(c)
 		}
@@ -166,30 +268,246 @@ func (h *VerificationHandler) Reject(c *fiber.Ctx) error {
 	}, "Talenta ditolak")
 }
 
-func (h *VerificationHandler) BatchApprove(c *fiber.Ctx) error {
-	var req domain.BatchApproveRequest
+func (h *VerificationHandler) RequestRevision(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.RequestRevisionRequest
 	if err := c.BodyParser(&req); err != nil {
 		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
 	}
 
+	talent, err := h.talentService.GetByID(c.Context(), id)
+	if err != nil {
+		if err == service.ErrTalentNotFound {
+			return NotFound(c, "Talenta tidak ditemukan")
+		}
+		return InternalError(c)
+	}
+
+	var errors []domain.FieldError
+	if len(req.Comments) == 0 {
+		errors = append(errors, domain.FieldError{Field: "comments", Message: "Minimal satu catatan perbaikan wajib diisi"})
+	}
+	for i, comment := range req.Comments {
+		prefix := "comments[" + strconv.Itoa(i) + "]"
+		known, err := h.talentService.IsReviewField(c.Context(), talent.TalentType, comment.Field)
+		if err != nil {
+			return InternalError(c)
+		}
+		if !known {
+			errors = append(errors, domain.FieldError{Field: prefix + ".field", Message: "Field tidak dikenal untuk jenis talenta ini"})
+		}
+		if comment.Comment == "" {
+			errors = append(errors, domain.FieldError{Field: prefix + ".comment", Message: "Catatan wajib diisi"})
+		}
+	}
+	if len(errors) > 0 {
+		return ValidationError(c, errors)
+	}
+
+	claims := GetClaims(c)
+
+	// Check if admin sekolah can verify this talent
+	if claims.Role == domain.RoleAdminSekolah {
+		user, _ := h.talentService.GetUser(c.Context(), talent.UserID)
+		if user == nil || user.SchoolID == nil || *user.SchoolID != *claims.SchoolID {
+			return Forbidden(c, "Anda hanya dapat memverifikasi talenta GTK di sekolah Anda")
+		}
+	}
+
+	talent, err = h.talentService.RequestRevision(c.Context(), id, claims.UserID, claims.Role, req.Comments)
+	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrAlreadyVerified:
+			return BadRequest(c, "ALREADY_VERIFIED", "Talenta sudah diverifikasi sebelumnya")
+		case service.ErrEndorsementRequired:
+			return Forbidden(c, "Talenta sudah disetujui sekolah dan menunggu pengesahan dinas")
+		case service.ErrRevisionPending:
+			return BadRequest(c, "REVISION_PENDING", "Talenta sedang menunggu perbaikan dari GTK")
+		case service.ErrClaimedByOther:
+			return Conflict(c, "CLAIMED_BY_OTHER", "Talenta sedang ditangani verifikator lain")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"id":          talent.ID,
+		"status":      talent.Status,
+		"verified_at": talent.VerifiedAt,
+	}, "Talenta dikembalikan ke GTK untuk diperbaiki")
+}
+
+func (h *VerificationHandler) Claim(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
 	claims := GetClaims(c)
-	result, err := h.talentService.BatchApprove(c.Context(), req.IDs, claims.UserID)
+
+	// Check if admin sekolah can verify this talent
+	if claims.Role == domain.RoleAdminSekolah {
+		talent, err := h.talentService.GetByID(c.Context(), id)
+		if err != nil {
+			if err == service.ErrTalentNotFound {
+				return NotFound(c, "Talenta tidak ditemukan")
+			}
+			return InternalError(c)
+		}
+
+		user, _ := h.talentService.GetUser(c.Context(), talent.UserID)
+		if user == nil || user.SchoolID == nil || *user.SchoolID != *claims.SchoolID {
+			return Forbidden(c, "Anda hanya dapat memverifikasi talenta GTK di sekolah Anda")
+		}
+	}
+
+	entry, err := h.talentService.Claim(c.Context(), id, claims.UserID, claims.Role)
 	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrAlreadyVerified:
+			return BadRequest(c, "ALREADY_VERIFIED", "Talenta sudah diverifikasi sebelumnya")
+		case service.ErrEndorsementRequired:
+			return Forbidden(c, "Talenta sudah disetujui sekolah dan menunggu pengesahan dinas")
+		case service.ErrRevisionPending:
+			return BadRequest(c, "REVISION_PENDING", "Talenta sedang menunggu perbaikan dari GTK")
+		case service.ErrClaimedByOther:
+			return Conflict(c, "CLAIMED_BY_OTHER", "Talenta sedang ditangani verifikator lain")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"id":               entry.TalentID,
+		"assignee_id":      entry.AssigneeID,
+		"claim_expires_at": entry.ClaimExpiresAt,
+	}, "Talenta berhasil diambil untuk diverifikasi")
+}
+
+func (h *VerificationHandler) ReleaseClaim(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	claims := GetClaims(c)
+	if err := h.talentService.ReleaseClaim(c.Context(), id, claims.UserID, claims.Role); err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrClaimedByOther:
+			return Conflict(c, "CLAIMED_BY_OTHER", "Talenta sedang ditangani verifikator lain")
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return Message(c, "Talenta dikembalikan ke antrean verifikasi")
+}
+
+func (h *VerificationHandler) Assign(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return BadRequest(c, "INVALID_ID", "ID tidak valid")
+	}
+
+	var req domain.AssignVerifierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+	if req.AssigneeID == uuid.Nil {
+		return ValidationError(c, []domain.FieldError{
+			{Field: "assignee_id", Message: "Verifikator wajib dipilih"},
+		})
+	}
+
+	claims := GetClaims(c)
+	entry, err := h.talentService.Assign(c.Context(), id, req.AssigneeID, claims.UserID)
+	if err != nil {
+		switch err {
+		case service.ErrTalentNotFound:
+			return NotFound(c, "Talenta tidak ditemukan")
+		case service.ErrAlreadyVerified:
+			return BadRequest(c, "ALREADY_VERIFIED", "Talenta sudah diverifikasi sebelumnya")
+		case service.ErrRevisionPending:
+			return BadRequest(c, "REVISION_PENDING", "Talenta sedang menunggu perbaikan dari GTK")
+		case service.ErrInvalidAssignee:
+			return ValidationError(c, []domain.FieldError{
+				{Field: "assignee_id", Message: "Verifikator harus admin sekolah GTK tersebut atau super admin yang aktif"},
+			})
+		default:
+			return InternalError(c)
+		}
+	}
+
+	return SuccessWithMessage(c, fiber.Map{
+		"id":          entry.TalentID,
+		"assignee_id": entry.AssigneeID,
+		"assigned_by": entry.AssignedBy,
+		"assigned_at": entry.AssignedAt,
+	}, "Verifikator berhasil ditugaskan")
+}
+
+// PreviewBatch reports how many talents a batch selection would touch.
+func (h *VerificationHandler) PreviewBatch(c *fiber.Ctx) error {
+	var req domain.BatchSelection
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+	if ok, err := parseBatchFilters(c, &req); !ok {
+		return err
+	}
+
+	claims := GetClaims(c)
+	scope, ok := batchScope(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+	preview, err := h.talentService.PreviewBatch(c.Context(), req, scope)
+	if err != nil {
+		if errs, ok := err.(service.ValidationErrors); ok {
+			return ValidationError(c, errs)
+		}
 		return InternalError(c)
 	}
+	return Success(c, preview)
+}
 
-	message := ""
-	if result.FailedCount > 0 {
-		message = strconv.Itoa(result.SuccessCount) + " talenta berhasil disetujui, " + strconv.Itoa(result.FailedCount) + " gagal"
-	} else {
-		message = strconv.Itoa(result.SuccessCount) + " talenta berhasil disetujui"
+func (h *VerificationHandler) BatchApprove(c *fiber.Ctx) error {
+	var req domain.BatchApproveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
+	}
+	if ok, err := parseBatchFilters(c, &req.BatchSelection); !ok {
+		return err
+	}
+
+	claims := GetClaims(c)
+	scope, ok := batchScope(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
+	}
+	result, err := h.talentService.BatchApprove(c.Context(), req, claims.UserID, claims.Role, scope)
+	if err != nil {
+		return h.batchError(c, err)
 	}
 
 	return SuccessWithMessage(c, fiber.Map{
+		"batch_id":       result.BatchID,
+		"mode":           result.Mode,
 		"approved_count": result.SuccessCount,
 		"failed_count":   result.FailedCount,
 		"failed_ids":     result.FailedIDs,
-	}, message)
+		"results":        result.Results,
+	}, batchMessage(result, "berhasil disetujui"))
 }
 
 func (h *VerificationHandler) BatchReject(c *fiber.Ctx) error {
@@ -197,6 +515,9 @@ func (h *VerificationHandler) BatchReject(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return BadRequest(c, "INVALID_REQUEST", "Request body tidak valid")
 	}
+	if ok, err := parseBatchFilters(c, &req.BatchSelection); !ok {
+		return err
+	}
 
 	if req.RejectionReason == "" {
 		return ValidationError(c, []domain.FieldError{
@@ -205,23 +526,97 @@ func (h *VerificationHandler) BatchReject(c *fiber.Ctx) error {
 	}
 
 	claims := GetClaims(c)
-	result, err := h.talentService.BatchReject(c.Context(), req.IDs, claims.UserID, req.RejectionReason)
-	if err != nil {
-		return InternalError(c)
+	scope, ok := batchScope(claims)
+	if !ok {
+		return Forbidden(c, "Admin sekolah belum terhubung dengan sekolah")
 	}
-
-	message := ""
-	if result.FailedCount > 0 {
-		message = strconv.Itoa(result.SuccessCount) + " talenta ditolak, " + strconv.Itoa(result.FailedCount) + " gagal"
-	} else {
-		message = strconv.Itoa(result.SuccessCount) + " talenta ditolak"
+	result, err := h.talentService.BatchReject(c.Context(), req, claims.UserID, claims.Role, scope)
+	if err != nil {
+		return h.batchError(c, err)
 	}
 
 	return SuccessWithMessage(c, fiber.Map{
+		"batch_id":       result.BatchID,
+		"mode":           result.Mode,
 		"rejected_count": result.SuccessCount,
 		"failed_count":   result.FailedCount,
 		"failed_ids":     result.FailedIDs,
-	}, message)
+		"results":        result.Results,
+	}, batchMessage(result, "ditolak"))
+}
+
+func (h *VerificationHandler) batchError(c *fiber.Ctx, err error) error {
+	if errs, ok := err.(service.ValidationErrors); ok {
+		return ValidationError(c, errs)
+	}
+	if err == service.ErrBatchTooLarge {
+		return BadRequest(c, "BATCH_TOO_LARGE", "Maksimal "+strconv.Itoa(h.talentService.BatchLimit())+" talenta per batch")
+	}
+	return InternalError(c)
+}
+
+// parseBatchFilters runs the filters of a batch selection through the
+// GET /talents filter parsing, so a batch takes the same filters, defaults
+// and search (q) as the list. When a filter is unknown or invalid it answers
+// the request and returns false.
+func parseBatchFilters(c *fiber.Ctx, sel *domain.BatchSelection) (bool, error) {
+	if len(sel.Filters) == 0 {
+		return true, nil
+	}
+
+	known := map[string]bool{"q": true, "search": true}
+	for _, key := range talentFilterKeys {
+		known[key] = true
+	}
+	var unknown []domain.FieldError
+	for _, key := range sortedFilterKeys(sel.Filters) {
+		if !known[key] {
+			unknown = append(unknown, domain.FieldError{Field: "filters." + key, Message: "Filter tidak dikenal"})
+		}
+	}
+	if len(unknown) > 0 {
+		return false, ValidationError(c, unknown)
+	}
+
+	filters := parseTalentFilters(func(key string) string { return sel.Filters[key] })
+	if code, message := checkTalentFilters(filters); code != "" {
+		return false, BadRequest(c, code, message)
+	}
+	filters["q"] = strings.TrimSpace(sel.Filters["q"])
+	if filters["q"] == "" {
+		filters["q"] = strings.TrimSpace(sel.Filters["search"])
+	}
+	sel.Filters = filters
+	return true, nil
+}
+
+func sortedFilterKeys(filters map[string]string) []string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// batchScope limits an admin_sekolah batch to talents of their school, or
+// returns nil for a super admin. It is not ok for a school admin without a
+// school.
+func batchScope(claims *service.JWTClaims) (*uuid.UUID, bool) {
+	if claims.Role != domain.RoleAdminSekolah {
+		return nil, true
+	}
+	return claims.SchoolID, claims.SchoolID != nil
+}
+
+func batchMessage(result *domain.BatchResult, verb string) string {
+	if result.Mode == domain.BatchModeAllOrNothing && result.FailedCount > 0 {
+		return "Batch dibatalkan, tidak ada talenta yang " + verb + ": " + strconv.Itoa(result.FailedCount) + " gagal"
+	}
+	if result.FailedCount > 0 {
+		return strconv.Itoa(result.SuccessCount) + " talenta " + verb + ", " + strconv.Itoa(result.FailedCount) + " gagal"
+	}
+	return strconv.Itoa(result.SuccessCount) + " talenta " + verb
 }
 
 func (h *VerificationHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
@@ -236,9 +631,13 @@ func (h *VerificationHandler) parseListParams(c *fiber.Ctx) domain.ListParams {
 		Limit: limit,
 		Sort:  c.Query("sort"),
 		Filters: map[string]string{
-			"status":      c.Query("status"),
-			"school_id":   c.Query("school_id"),
-			"talent_type": c.Query("talent_type"),
+			"status":              c.Query("status"),
+			"school_id":           c.Query("school_id"),
+			"talent_type":         c.Query("talent_type"),
+			"min_age_days":        c.Query("min_age_days"),
+			"assignee":            c.Query("assignee"),
+			"level":               c.Query("level"),
+			"suspected_duplicate": c.Query("suspected_duplicate"),
 		},
 	}
 }
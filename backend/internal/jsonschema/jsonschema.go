@@ -0,0 +1,354 @@
+// Package jsonschema validates talent details against the subset of JSON
+// Schema used by the talent type registry.
+//
+// Supported keywords: type (object, string, integer, number, boolean,
+// array), properties, required, additionalProperties (boolean), items,
+// minItems, maxItems, enum, minLength, maxLength, pattern, format (date,
+// email, uri), minimum, maximum, exclusiveMinimum, exclusiveMaximum, title
+// and description. Two extensions are understood: "x-not-future" rejects
+// dates after today, in the location of the time given to Validate, and
+// "x-messages" overrides the error message per keyword (including
+// "required" on the property itself).
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is a parsed schema node.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	NotFuture            bool               `json:"x-not-future,omitempty"`
+	Messages             map[string]string  `json:"x-messages,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Error is a single validation failure. Path is the dotted location of the
+// value, empty for the root.
+type Error struct {
+	Path    string
+	Keyword string
+	Message string
+}
+
+var supportedTypes = map[string]bool{
+	"object": true, "string": true, "integer": true, "number": true, "boolean": true, "array": true,
+}
+
+var supportedFormats = map[string]bool{
+	"": true, "date": true, "email": true, "uri": true,
+}
+
+// Parse decodes and checks a schema, rejecting keywords values this package
+// cannot enforce.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if s.Type != "object" {
+		return nil, errors.New("root schema must be of type object")
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	if !supportedTypes[s.Type] {
+		return fmt.Errorf("%s: unsupported type %q", displayPath(path), s.Type)
+	}
+	if !supportedFormats[s.Format] {
+		return fmt.Errorf("%s: unsupported format %q", displayPath(path), s.Format)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", displayPath(path), err)
+		}
+		s.pattern = re
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("%s: required property %q is not declared", displayPath(path), name)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s: property %q has no schema", displayPath(path), name)
+		}
+		if err := prop.compile(join(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(join(path, "[]")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PropertyNames returns the declared top-level properties in sorted order.
+func (s *Schema) PropertyNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Prune drops object properties the schema does not declare, unless
+// additionalProperties allows them. value must come from encoding/json.
+func (s *Schema) Prune(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.Type != "object" {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			prop, ok := s.Properties[key]
+			switch {
+			case ok:
+				out[key] = prop.Prune(item)
+			case s.AdditionalProperties != nil && *s.AdditionalProperties:
+				out[key] = item
+			}
+		}
+		return out
+	case []interface{}:
+		if s.Items == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = s.Items.Prune(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Validate checks value, which must come from encoding/json, and returns
+// every failure. Each value reports only its first failing keyword. Today
+// is the calendar date of now in its own location.
+func (s *Schema) Validate(value interface{}, now time.Time) []Error {
+	var errs []Error
+	s.validate("", value, now.Format("2006-01-02"), &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, value interface{}, today string, errs *[]Error) {
+	fail := func(keyword, message string) {
+		if custom, ok := s.Messages[keyword]; ok {
+			message = custom
+		}
+		*errs = append(*errs, Error{Path: path, Keyword: keyword, Message: message})
+	}
+
+	if !s.matchesType(value) {
+		fail("type", "Tipe data tidak valid")
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if item, ok := v[name]; !ok || item == nil {
+				prop := s.Properties[name]
+				message := "Wajib diisi"
+				if custom, ok := prop.Messages["required"]; ok {
+					message = custom
+				}
+				*errs = append(*errs, Error{Path: join(path, name), Keyword: "required", Message: message})
+			}
+		}
+		for _, name := range sortedKeys(v) {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, Error{Path: join(path, name), Keyword: "additionalProperties", Message: "Field tidak dikenal"})
+				}
+				continue
+			}
+			if v[name] == nil {
+				continue
+			}
+			prop.validate(join(path, name), v[name], today, errs)
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("minItems", fmt.Sprintf("Minimal %d item", *s.MinItems))
+			return
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("maxItems", fmt.Sprintf("Maksimal %d item", *s.MaxItems))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, today, errs)
+			}
+		}
+
+	case string:
+		length := len([]rune(v))
+		switch {
+		case s.MinLength != nil && length < *s.MinLength:
+			fail("minLength", fmt.Sprintf("Minimal %d karakter", *s.MinLength))
+		case s.MaxLength != nil && length > *s.MaxLength:
+			fail("maxLength", fmt.Sprintf("Maksimal %d karakter", *s.MaxLength))
+		case s.pattern != nil && !s.pattern.MatchString(v):
+			fail("pattern", "Format tidak valid")
+		case !validFormat(s.Format, v):
+			fail("format", formatMessage(s.Format))
+		case s.NotFuture && s.Format == "date" && isFutureDate(v, today):
+			fail("x-not-future", "Tanggal tidak boleh di masa depan")
+		case len(s.Enum) > 0 && !inEnum(s.Enum, v):
+			fail("enum", "Nilai tidak valid")
+		}
+
+	case float64:
+		switch {
+		case s.Minimum != nil && v < *s.Minimum:
+			fail("minimum", fmt.Sprintf("Nilai minimal %v", *s.Minimum))
+		case s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum:
+			fail("exclusiveMinimum", fmt.Sprintf("Nilai harus lebih dari %v", *s.ExclusiveMinimum))
+		case s.Maximum != nil && v > *s.Maximum:
+			fail("maximum", fmt.Sprintf("Nilai maksimal %v", *s.Maximum))
+		case s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum:
+			fail("exclusiveMaximum", fmt.Sprintf("Nilai harus kurang dari %v", *s.ExclusiveMaximum))
+		case len(s.Enum) > 0 && !inEnum(s.Enum, v):
+			fail("enum", "Nilai tidak valid")
+		}
+
+	case bool:
+		if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+			fail("enum", "Nilai tidak valid")
+		}
+	}
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	switch s.Type {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}
+	return true
+}
+
+func formatMessage(format string) string {
+	switch format {
+	case "date":
+		return "Format tanggal harus YYYY-MM-DD"
+	case "email":
+		return "Format email tidak valid"
+	case "uri":
+		return "Format URL tidak valid"
+	}
+	return "Format tidak valid"
+}
+
+// isFutureDate reports whether a date that passed the date format check
+// falls after today. Dates in that format compare as strings.
+func isFutureDate(value, today string) bool {
+	return value > today
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if strings.HasPrefix(name, "[") {
+		return path + name
+	}
+	return path + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "schema"
+	}
+	return path
+}
@@ -0,0 +1,515 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is 20:00 UTC, already the next day in UTC+7.
+var now = time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func mustParse(t *testing.T, schema string) *Schema {
+	t.Helper()
+	s, err := Parse([]byte(schema))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", schema, err)
+	}
+	return s
+}
+
+func decode(t *testing.T, value string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		t.Fatalf("decode %s: %v", value, err)
+	}
+	return v
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"minimal object", `{"type": "object"}`, ""},
+		{"every keyword", `{
+			"type": "object",
+			"title": "Detail",
+			"description": "Detail talenta",
+			"required": ["name"],
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string", "minLength": 1, "maxLength": 10, "pattern": "^[a-z]+$", "x-messages": {"required": "Nama wajib diisi"}},
+				"date": {"type": "string", "format": "date", "x-not-future": true},
+				"email": {"type": "string", "format": "email"},
+				"url": {"type": "string", "format": "uri"},
+				"level": {"type": "string", "enum": ["kota", "nasional"]},
+				"score": {"type": "number", "minimum": 0, "maximum": 100, "exclusiveMinimum": -1, "exclusiveMaximum": 101},
+				"count": {"type": "integer"},
+				"active": {"type": "boolean"},
+				"tags": {"type": "array", "minItems": 1, "maxItems": 3, "items": {"type": "string"}}
+			}
+		}`, ""},
+		{"invalid json", `{"type": `, "invalid schema"},
+		{"root not object", `{"type": "string"}`, "root schema must be of type object"},
+		{"missing type", `{"type": "object", "properties": {"a": {}}}`, `a: unsupported type ""`},
+		{"unsupported type", `{"type": "object", "properties": {"a": {"type": "null"}}}`, `a: unsupported type "null"`},
+		{"unsupported format", `{"type": "object", "properties": {"a": {"type": "string", "format": "uuid"}}}`, `a: unsupported format "uuid"`},
+		{"invalid pattern", `{"type": "object", "properties": {"a": {"type": "string", "pattern": "("}}}`, "a: invalid pattern"},
+		{"undeclared required", `{"type": "object", "required": ["a"]}`, `schema: required property "a" is not declared`},
+		{"null property schema", `{"type": "object", "properties": {"a": null}}`, `schema: property "a" has no schema`},
+		{"nested path", `{"type": "object", "properties": {"a": {"type": "object", "properties": {"b": {"type": "date"}}}}}`, `a.b: unsupported type "date"`},
+		{"items path", `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "tuple"}}}}`, `a[]: unsupported type "tuple"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("expected error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		now    time.Time
+		want   []Error
+	}{
+		// type
+		{
+			name:   "root must be an object",
+			schema: `{"type": "object"}`,
+			value:  `"text"`,
+			want:   []Error{{Path: "", Keyword: "type", Message: "Tipe data tidak valid"}},
+		},
+		{
+			name:   "string type mismatch",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": 1}`,
+			want:   []Error{{Path: "a", Keyword: "type"}},
+		},
+		{
+			name:   "boolean type mismatch",
+			schema: `{"type": "object", "properties": {"a": {"type": "boolean"}}}`,
+			value:  `{"a": "true"}`,
+			want:   []Error{{Path: "a", Keyword: "type"}},
+		},
+		{
+			name:   "array type mismatch",
+			schema: `{"type": "object", "properties": {"a": {"type": "array"}}}`,
+			value:  `{"a": {}}`,
+			want:   []Error{{Path: "a", Keyword: "type"}},
+		},
+
+		// integer detection
+		{
+			name:   "integer accepts whole number",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer"}}}`,
+			value:  `{"a": 3}`,
+		},
+		{
+			name:   "integer accepts whole number written with a fraction",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer"}}}`,
+			value:  `{"a": 3.0}`,
+		},
+		{
+			name:   "integer rejects fraction",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer"}}}`,
+			value:  `{"a": 3.5}`,
+			want:   []Error{{Path: "a", Keyword: "type"}},
+		},
+		{
+			name:   "integer rejects numeric string",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer"}}}`,
+			value:  `{"a": "3"}`,
+			want:   []Error{{Path: "a", Keyword: "type"}},
+		},
+		{
+			name:   "number accepts fraction",
+			schema: `{"type": "object", "properties": {"a": {"type": "number"}}}`,
+			value:  `{"a": 3.5}`,
+		},
+
+		// required
+		{
+			name:   "required missing",
+			schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string"}}}`,
+			value:  `{}`,
+			want:   []Error{{Path: "a", Keyword: "required", Message: "Wajib diisi"}},
+		},
+		{
+			name:   "required null counts as missing",
+			schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": null}`,
+			want:   []Error{{Path: "a", Keyword: "required"}},
+		},
+		{
+			name:   "required present",
+			schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": ""}`,
+		},
+		{
+			name:   "optional null is skipped",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "minLength": 1}}}`,
+			value:  `{"a": null}`,
+		},
+		{
+			// Drafts drop the required errors by keyword and keep the rest
+			name:   "required reported apart from other failures",
+			schema: `{"type": "object", "required": ["a", "b"], "properties": {"a": {"type": "string"}, "b": {"type": "string"}, "c": {"type": "string", "format": "date"}}}`,
+			value:  `{"a": "x", "c": "19-10-2026"}`,
+			want: []Error{
+				{Path: "b", Keyword: "required"},
+				{Path: "c", Keyword: "format"},
+			},
+		},
+		{
+			name:   "required in nested object",
+			schema: `{"type": "object", "properties": {"a": {"type": "object", "required": ["b"], "properties": {"b": {"type": "string"}}}}}`,
+			value:  `{"a": {}}`,
+			want:   []Error{{Path: "a.b", Keyword: "required"}},
+		},
+
+		// additionalProperties
+		{
+			name:   "additional properties allowed by default",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": "x", "b": 1}`,
+		},
+		{
+			name:   "additional properties rejected",
+			schema: `{"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": "x", "b": 1}`,
+			want:   []Error{{Path: "b", Keyword: "additionalProperties", Message: "Field tidak dikenal"}},
+		},
+
+		// strings
+		{
+			name:   "minLength",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "minLength": 3}}}`,
+			value:  `{"a": "ab"}`,
+			want:   []Error{{Path: "a", Keyword: "minLength", Message: "Minimal 3 karakter"}},
+		},
+		{
+			name:   "maxLength counts characters, not bytes",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "maxLength": 3}}}`,
+			value:  `{"a": "éèê"}`,
+		},
+		{
+			name:   "maxLength",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "maxLength": 3}}}`,
+			value:  `{"a": "abcd"}`,
+			want:   []Error{{Path: "a", Keyword: "maxLength", Message: "Maksimal 3 karakter"}},
+		},
+		{
+			name:   "pattern",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "pattern": "^[0-9]+$"}}}`,
+			value:  `{"a": "12a"}`,
+			want:   []Error{{Path: "a", Keyword: "pattern"}},
+		},
+		{
+			name:   "only the first failing keyword is reported",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "minLength": 5, "pattern": "^[0-9]+$"}}}`,
+			value:  `{"a": "ab"}`,
+			want:   []Error{{Path: "a", Keyword: "minLength"}},
+		},
+		{
+			name:   "string enum",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "enum": ["kota", "nasional"]}}}`,
+			value:  `{"a": "dunia"}`,
+			want:   []Error{{Path: "a", Keyword: "enum"}},
+		},
+		{
+			name:   "string enum match",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "enum": ["kota", "nasional"]}}}`,
+			value:  `{"a": "kota"}`,
+		},
+
+		// formats
+		{
+			name:   "date format",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date"}}}`,
+			value:  `{"a": "2026-10-19"}`,
+		},
+		{
+			name:   "date format rejects impossible date",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date"}}}`,
+			value:  `{"a": "2026-02-30"}`,
+			want:   []Error{{Path: "a", Keyword: "format", Message: "Format tanggal harus YYYY-MM-DD"}},
+		},
+		{
+			name:   "date format rejects timestamp",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date"}}}`,
+			value:  `{"a": "2026-10-19T00:00:00Z"}`,
+			want:   []Error{{Path: "a", Keyword: "format"}},
+		},
+		{
+			name:   "email format",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "email"}}}`,
+			value:  `{"a": "guru@sekolah.sch.id"}`,
+		},
+		{
+			name:   "email format rejects missing domain",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "email"}}}`,
+			value:  `{"a": "guru"}`,
+			want:   []Error{{Path: "a", Keyword: "format", Message: "Format email tidak valid"}},
+		},
+		{
+			name:   "uri format",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "uri"}}}`,
+			value:  `{"a": "https://sipodi.go.id/lomba"}`,
+		},
+		{
+			name:   "uri format rejects relative reference",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "uri"}}}`,
+			value:  `{"a": "/lomba"}`,
+			want:   []Error{{Path: "a", Keyword: "format", Message: "Format URL tidak valid"}},
+		},
+
+		// x-not-future
+		{
+			name:   "x-not-future accepts today",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date", "x-not-future": true}}}`,
+			value:  `{"a": "2026-10-19"}`,
+		},
+		{
+			name:   "x-not-future rejects tomorrow",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date", "x-not-future": true}}}`,
+			value:  `{"a": "2026-10-20"}`,
+			want:   []Error{{Path: "a", Keyword: "x-not-future", Message: "Tanggal tidak boleh di masa depan"}},
+		},
+		{
+			name:   "x-not-future uses the date in the location of now",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date", "x-not-future": true}}}`,
+			value:  `{"a": "2026-10-20"}`,
+			now:    now.In(wib),
+		},
+		{
+			name:   "x-not-future needs the date format",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "x-not-future": true}}}`,
+			value:  `{"a": "2999-01-01"}`,
+		},
+		{
+			name:   "format is checked before x-not-future",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "date", "x-not-future": true}}}`,
+			value:  `{"a": "2999-13-01"}`,
+			want:   []Error{{Path: "a", Keyword: "format"}},
+		},
+
+		// numbers
+		{
+			name:   "minimum",
+			schema: `{"type": "object", "properties": {"a": {"type": "number", "minimum": 1}}}`,
+			value:  `{"a": 0.5}`,
+			want:   []Error{{Path: "a", Keyword: "minimum", Message: "Nilai minimal 1"}},
+		},
+		{
+			name:   "minimum is inclusive",
+			schema: `{"type": "object", "properties": {"a": {"type": "number", "minimum": 1}}}`,
+			value:  `{"a": 1}`,
+		},
+		{
+			name:   "exclusiveMinimum",
+			schema: `{"type": "object", "properties": {"a": {"type": "number", "exclusiveMinimum": 1}}}`,
+			value:  `{"a": 1}`,
+			want:   []Error{{Path: "a", Keyword: "exclusiveMinimum"}},
+		},
+		{
+			name:   "maximum",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer", "maximum": 10}}}`,
+			value:  `{"a": 11}`,
+			want:   []Error{{Path: "a", Keyword: "maximum", Message: "Nilai maksimal 10"}},
+		},
+		{
+			name:   "maximum is inclusive",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer", "maximum": 10}}}`,
+			value:  `{"a": 10}`,
+		},
+		{
+			name:   "exclusiveMaximum",
+			schema: `{"type": "object", "properties": {"a": {"type": "number", "exclusiveMaximum": 10}}}`,
+			value:  `{"a": 10}`,
+			want:   []Error{{Path: "a", Keyword: "exclusiveMaximum"}},
+		},
+		{
+			name:   "number enum",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer", "enum": [1, 2]}}}`,
+			value:  `{"a": 3}`,
+			want:   []Error{{Path: "a", Keyword: "enum"}},
+		},
+
+		// booleans
+		{
+			name:   "boolean enum",
+			schema: `{"type": "object", "properties": {"a": {"type": "boolean", "enum": [true]}}}`,
+			value:  `{"a": false}`,
+			want:   []Error{{Path: "a", Keyword: "enum"}},
+		},
+
+		// arrays
+		{
+			name:   "minItems",
+			schema: `{"type": "object", "properties": {"a": {"type": "array", "minItems": 2, "items": {"type": "string"}}}}`,
+			value:  `{"a": ["x"]}`,
+			want:   []Error{{Path: "a", Keyword: "minItems", Message: "Minimal 2 item"}},
+		},
+		{
+			name:   "maxItems",
+			schema: `{"type": "object", "properties": {"a": {"type": "array", "maxItems": 1, "items": {"type": "string"}}}}`,
+			value:  `{"a": ["x", "y"]}`,
+			want:   []Error{{Path: "a", Keyword: "maxItems", Message: "Maksimal 1 item"}},
+		},
+		{
+			name:   "items report their index",
+			schema: `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "string", "minLength": 2}}}}`,
+			value:  `{"a": ["xy", "z", 1]}`,
+			want: []Error{
+				{Path: "a[1]", Keyword: "minLength"},
+				{Path: "a[2]", Keyword: "type"},
+			},
+		},
+		{
+			name:   "items of objects",
+			schema: `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "object", "required": ["b"], "properties": {"b": {"type": "string"}}}}}}`,
+			value:  `{"a": [{"b": "x"}, {}]}`,
+			want:   []Error{{Path: "a[1].b", Keyword: "required"}},
+		},
+
+		// x-messages
+		{
+			name:   "x-messages overrides a keyword",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "minLength": 16, "x-messages": {"minLength": "NUPTK harus 16 digit"}}}}`,
+			value:  `{"a": "123"}`,
+			want:   []Error{{Path: "a", Keyword: "minLength", Message: "NUPTK harus 16 digit"}},
+		},
+		{
+			name:   "x-messages overrides type",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer", "x-messages": {"type": "Harus bilangan bulat"}}}}`,
+			value:  `{"a": 1.5}`,
+			want:   []Error{{Path: "a", Keyword: "type", Message: "Harus bilangan bulat"}},
+		},
+		{
+			name:   "x-messages on the property overrides required",
+			schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string", "x-messages": {"required": "Nama lomba wajib diisi"}}}}`,
+			value:  `{}`,
+			want:   []Error{{Path: "a", Keyword: "required", Message: "Nama lomba wajib diisi"}},
+		},
+		{
+			name:   "x-messages leaves other keywords alone",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "maxLength": 2, "x-messages": {"minLength": "Terlalu pendek"}}}}`,
+			value:  `{"a": "abc"}`,
+			want:   []Error{{Path: "a", Keyword: "maxLength", Message: "Maksimal 2 karakter"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.now
+			if at.IsZero() {
+				at = now
+			}
+			got := mustParse(t, tt.schema).Validate(decode(t, tt.value), at)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d errors %+v, want %+v", len(got), got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Path != want.Path || got[i].Keyword != want.Keyword {
+					t.Errorf("error %d: got %s/%s, want %s/%s", i, got[i].Path, got[i].Keyword, want.Path, want.Keyword)
+				}
+				if want.Message != "" && got[i].Message != want.Message {
+					t.Errorf("error %d: got message %q, want %q", i, got[i].Message, want.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		want   string
+	}{
+		{
+			name:   "drops undeclared properties",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": "x", "b": 1}`,
+			want:   `{"a": "x"}`,
+		},
+		{
+			name:   "keeps undeclared properties when allowed",
+			schema: `{"type": "object", "additionalProperties": true, "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": "x", "b": 1}`,
+			want:   `{"a": "x", "b": 1}`,
+		},
+		{
+			name:   "drops undeclared properties when rejected",
+			schema: `{"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": "x", "b": 1}`,
+			want:   `{"a": "x"}`,
+		},
+		{
+			name:   "keeps null values of declared properties",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": null}`,
+			want:   `{"a": null}`,
+		},
+		{
+			name:   "prunes nested objects",
+			schema: `{"type": "object", "properties": {"a": {"type": "object", "properties": {"b": {"type": "string"}}}}}`,
+			value:  `{"a": {"b": "x", "c": "y"}}`,
+			want:   `{"a": {"b": "x"}}`,
+		},
+		{
+			name:   "prunes array items",
+			schema: `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "object", "properties": {"b": {"type": "string"}}}}}}`,
+			value:  `{"a": [{"b": "x", "c": 1}, {"c": 2}]}`,
+			want:   `{"a": [{"b": "x"}, {}]}`,
+		},
+		{
+			name:   "leaves values of the wrong type for Validate",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `["x"]`,
+			want:   `["x"]`,
+		},
+		{
+			name:   "leaves an object where a string is declared",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+			value:  `{"a": {"b": 1}}`,
+			want:   `{"a": {"b": 1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParse(t, tt.schema).Prune(decode(t, tt.value))
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPropertyNames(t *testing.T) {
+	s := mustParse(t, `{"type": "object", "properties": {"c": {"type": "string"}, "a": {"type": "string"}, "b": {"type": "string"}}}`)
+	if got, want := s.PropertyNames(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
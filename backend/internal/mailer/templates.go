@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.txt templates/*.html
+var templateFS embed.FS
+
+// TemplateData is what email templates render. Details are extra lines
+// shown under the message; Items are only used by the digest.
+type TemplateData struct {
+	AppName     string
+	FrontendURL string
+	FullName    string
+	Message     string
+	Details     []string
+	Items       []DigestEntry
+}
+
+type DigestEntry struct {
+	Message string
+	Time    string
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer turns the embedded templates into messages. Every template
+// name has a text version defining "subject" and "body" and an HTML
+// version defining "content", each wrapped in its own layout.
+type Renderer struct {
+	templates map[string]emailTemplate
+}
+
+func NewRenderer() (*Renderer, error) {
+	files, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{templates: make(map[string]emailTemplate)}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		if name == "layout" {
+			continue
+		}
+
+		text, err := texttemplate.ParseFS(templateFS, "templates/layout.txt", file)
+		if err != nil {
+			return nil, fmt.Errorf("parse email template %s: %w", file, err)
+		}
+		html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("parse email template %s.html: %w", name, err)
+		}
+		r.templates[name] = emailTemplate{text: text, html: html}
+	}
+	return r, nil
+}
+
+// Render builds the message for the template name. The recipient is left
+// for the caller to fill in.
+func (r *Renderer) Render(name string, data TemplateData) (Message, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "layout", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
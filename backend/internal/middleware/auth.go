@@ -45,6 +45,31 @@ func AuthMiddleware(authService *service.AuthService) fiber.Handler {
 	}
 }
 
+// StreamAuthMiddleware authenticates like AuthMiddleware, or with a stream
+// ticket in the ticket query parameter for clients that cannot set headers.
+func StreamAuthMiddleware(authService *service.AuthService) fiber.Handler {
+	bearer := AuthMiddleware(authService)
+	return func(c *fiber.Ctx) error {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			return bearer(c)
+		}
+
+		claims, err := authService.ValidateStreamTicket(ticket)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(domain.ErrorResponse{
+				Error: domain.ErrorDetail{
+					Code:    "UNAUTHORIZED",
+					Message: "Tiket stream tidak valid atau sudah expired",
+				},
+			})
+		}
+
+		c.Locals("claims", claims)
+		return c.Next()
+	}
+}
+
 func RoleMiddleware(roles ...domain.UserRole) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		claims, ok := c.Locals("claims").(*service.JWTClaims)
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type AnnouncementRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAnnouncementRepository(db *pgxpool.Pool) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+// Enum arrays are read and written as text[] so pgx needs no knowledge of
+// the enum types.
+const announcementColumns = `a.id, a.title, a.body, a.author_id, au.full_name, a.school_id,
+	a.target_roles::text[], a.target_school_ids, a.target_school_statuses::text[], a.target_gtk_types::text[],
+	a.is_pinned, a.publish_at, a.expires_at,
+	CASE WHEN a.publish_at > CURRENT_TIMESTAMP THEN 'scheduled'
+		WHEN a.expires_at IS NOT NULL AND a.expires_at <= CURRENT_TIMESTAMP THEN 'expired'
+		ELSE 'active' END,
+	a.created_at, a.updated_at`
+
+const announcementFrom = `announcements a LEFT JOIN users au ON au.id = a.author_id`
+
+// announcementActive matches announcements inside their publish window.
+const announcementActive = `a.publish_at <= CURRENT_TIMESTAMP
+	AND (a.expires_at IS NULL OR a.expires_at > CURRENT_TIMESTAMP)`
+
+// announcementAudience matches announcements whose audience includes user
+// u, joined with its school s.
+const announcementAudience = `(a.school_id IS NULL OR u.school_id = a.school_id)
+	AND (cardinality(a.target_roles) = 0 OR u.role = ANY(a.target_roles))
+	AND (cardinality(a.target_school_ids) = 0 OR u.school_id = ANY(a.target_school_ids))
+	AND (cardinality(a.target_school_statuses) = 0 OR s.status = ANY(a.target_school_statuses))
+	AND (cardinality(a.target_gtk_types) = 0 OR u.gtk_type = ANY(a.target_gtk_types))`
+
+func scanAnnouncement(row pgx.Row, extra ...interface{}) (*domain.Announcement, error) {
+	a := &domain.Announcement{}
+	var roles, statuses, gtkTypes []string
+	dest := []interface{}{
+		&a.ID, &a.Title, &a.Body, &a.AuthorID, &a.AuthorName, &a.SchoolID,
+		&roles, &a.Audience.SchoolIDs, &statuses, &gtkTypes,
+		&a.IsPinned, &a.PublishAt, &a.ExpiresAt, &a.Status,
+		&a.CreatedAt, &a.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, r := range roles {
+		a.Audience.Roles = append(a.Audience.Roles, domain.UserRole(r))
+	}
+	for _, st := range statuses {
+		a.Audience.SchoolStatuses = append(a.Audience.SchoolStatuses, domain.SchoolStatus(st))
+	}
+	for _, t := range gtkTypes {
+		a.Audience.GTKTypes = append(a.Audience.GTKTypes, domain.GTKType(t))
+	}
+	return a, nil
+}
+
+func audienceArgs(audience domain.AnnouncementAudience) (roles, schoolIDs, statuses, gtkTypes interface{}) {
+	r := make([]string, 0, len(audience.Roles))
+	for _, role := range audience.Roles {
+		r = append(r, string(role))
+	}
+	st := make([]string, 0, len(audience.SchoolStatuses))
+	for _, status := range audience.SchoolStatuses {
+		st = append(st, string(status))
+	}
+	g := make([]string, 0, len(audience.GTKTypes))
+	for _, t := range audience.GTKTypes {
+		g = append(g, string(t))
+	}
+	ids := audience.SchoolIDs
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return r, ids, st, g
+}
+
+func (r *AnnouncementRepository) Create(ctx context.Context, a *domain.Announcement) error {
+	query := `
+		INSERT INTO announcements (id, title, body, author_id, school_id,
+			target_roles, target_school_ids, target_school_statuses, target_gtk_types,
+			is_pinned, publish_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6::text[]::user_role[], $7, $8::text[]::school_status[],
+			$9::text[]::gtk_type[], $10, $11, $12)
+		RETURNING created_at, updated_at`
+
+	roles, schoolIDs, statuses, gtkTypes := audienceArgs(a.Audience)
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		a.ID, a.Title, a.Body, a.AuthorID, a.SchoolID,
+		roles, schoolIDs, statuses, gtkTypes,
+		a.IsPinned, a.PublishAt, a.ExpiresAt,
+	).Scan(&a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *AnnouncementRepository) Update(ctx context.Context, a *domain.Announcement) error {
+	query := `
+		UPDATE announcements
+		SET title = $2, body = $3, target_roles = $4::text[]::user_role[], target_school_ids = $5,
+			target_school_statuses = $6::text[]::school_status[], target_gtk_types = $7::text[]::gtk_type[],
+			is_pinned = $8, publish_at = $9, expires_at = $10
+		WHERE id = $1`
+
+	roles, schoolIDs, statuses, gtkTypes := audienceArgs(a.Audience)
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		a.ID, a.Title, a.Body, roles, schoolIDs, statuses, gtkTypes,
+		a.IsPinned, a.PublishAt, a.ExpiresAt,
+	)
+	return err
+}
+
+func (r *AnnouncementRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM announcements WHERE id = $1`, id)
+	return err
+}
+
+// GetByID returns an announcement with its read count.
+func (r *AnnouncementRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, (SELECT COUNT(*) FROM announcement_reads ar WHERE ar.announcement_id = a.id)
+		FROM %s WHERE a.id = $1`, announcementColumns, announcementFrom)
+
+	var readCount int
+	a, err := scanAnnouncement(conn(ctx, r.db).QueryRow(ctx, query, id), &readCount)
+	if a != nil {
+		a.ReadCount = &readCount
+	}
+	return a, err
+}
+
+// ListManaged returns announcements for their authors, newest first,
+// filtered by school_id and status.
+func (r *AnnouncementRepository) ListManaged(ctx context.Context, params domain.ListParams) ([]domain.Announcement, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
+		conditions = append(conditions, fmt.Sprintf("a.school_id = $%d", argIndex))
+		args = append(args, schoolID)
+		argIndex++
+	}
+
+	switch domain.AnnouncementStatus(params.Filters["status"]) {
+	case domain.AnnouncementScheduled:
+		conditions = append(conditions, "a.publish_at > CURRENT_TIMESTAMP")
+	case domain.AnnouncementActive:
+		conditions = append(conditions, announcementActive)
+	case domain.AnnouncementExpired:
+		conditions = append(conditions, "a.expires_at <= CURRENT_TIMESTAMP")
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM announcements a %s", whereClause)
+	if err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	args = append(args, params.Limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s, (SELECT COUNT(*) FROM announcement_reads ar WHERE ar.announcement_id = a.id)
+		FROM %s %s
+		ORDER BY a.publish_at DESC
+		LIMIT $%d OFFSET $%d`,
+		announcementColumns, announcementFrom, whereClause, argIndex, argIndex+1,
+	)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var announcements []domain.Announcement
+	for rows.Next() {
+		var readCount int
+		a, err := scanAnnouncement(rows, &readCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		a.ReadCount = &readCount
+		announcements = append(announcements, *a)
+	}
+	return announcements, total, rows.Err()
+}
+
+// ListFeed returns the active announcements addressed to the user, pinned
+// ones first, then newest first. The is_read filter narrows it to read or
+// unread announcements.
+func (r *AnnouncementRepository) ListFeed(ctx context.Context, userID uuid.UUID, params domain.ListParams) ([]domain.Announcement, int, error) {
+	conditions := []string{"u.id = $1", announcementActive, announcementAudience}
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if isRead, ok := params.Filters["is_read"]; ok && isRead != "" {
+		conditions = append(conditions, fmt.Sprintf("(ar.user_id IS NOT NULL) = $%d", argIndex))
+		args = append(args, isRead == "true")
+		argIndex++
+	}
+
+	fromClause := fmt.Sprintf(`%s
+		CROSS JOIN users u
+		LEFT JOIN schools s ON s.id = u.school_id
+		LEFT JOIN announcement_reads ar ON ar.announcement_id = a.id AND ar.user_id = u.id`, announcementFrom)
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", fromClause, whereClause)
+	if err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	args = append(args, params.Limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s, ar.user_id IS NOT NULL
+		FROM %s %s
+		ORDER BY a.is_pinned DESC, a.publish_at DESC
+		LIMIT $%d OFFSET $%d`,
+		announcementColumns, fromClause, whereClause, argIndex, argIndex+1,
+	)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var announcements []domain.Announcement
+	for rows.Next() {
+		var isRead bool
+		a, err := scanAnnouncement(rows, &isRead)
+		if err != nil {
+			return nil, 0, err
+		}
+		a.IsRead = isRead
+		announcements = append(announcements, *a)
+	}
+	return announcements, total, rows.Err()
+}
+
+// GetForUser returns an active announcement addressed to the user with
+// whether the user read it, or nil when the user may not see it.
+func (r *AnnouncementRepository) GetForUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Announcement, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, ar.user_id IS NOT NULL
+		FROM %s
+		CROSS JOIN users u
+		LEFT JOIN schools s ON s.id = u.school_id
+		LEFT JOIN announcement_reads ar ON ar.announcement_id = a.id AND ar.user_id = u.id
+		WHERE a.id = $1 AND u.id = $2 AND %s AND %s`,
+		announcementColumns, announcementFrom, announcementActive, announcementAudience)
+
+	var isRead bool
+	a, err := scanAnnouncement(conn(ctx, r.db).QueryRow(ctx, query, id, userID), &isRead)
+	if a != nil {
+		a.IsRead = isRead
+	}
+	return a, err
+}
+
+func (r *AnnouncementRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM announcements a
+		CROSS JOIN users u
+		LEFT JOIN schools s ON s.id = u.school_id
+		WHERE u.id = $1 AND %s AND %s
+			AND NOT EXISTS (
+				SELECT 1 FROM announcement_reads ar
+				WHERE ar.announcement_id = a.id AND ar.user_id = u.id
+			)`, announcementActive, announcementAudience)
+
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+func (r *AnnouncementRepository) MarkRead(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO announcement_reads (announcement_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, userID)
+	return err
+}
+
+// ClaimUnnotified locks an active announcement whose audience has not been
+// notified yet, so concurrent publishers skip it. It returns nil when there
+// is none.
+func (r *AnnouncementRepository) ClaimUnnotified(ctx context.Context) (*domain.Announcement, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE a.notified_at IS NULL AND %s
+		ORDER BY a.publish_at
+		LIMIT 1
+		FOR UPDATE OF a SKIP LOCKED`, announcementColumns, announcementFrom, announcementActive)
+
+	return scanAnnouncement(conn(ctx, r.db).QueryRow(ctx, query))
+}
+
+// ListRecipientIDs returns the active users in the audience of an
+// announcement, except its author, in the order their notifications must be
+// created to take the per-user sequence locks without deadlocking.
+func (r *AnnouncementRepository) ListRecipientIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`
+		SELECT u.id
+		FROM announcements a
+		CROSS JOIN users u
+		LEFT JOIN schools s ON s.id = u.school_id
+		WHERE a.id = $1 AND u.is_active AND u.id IS DISTINCT FROM a.author_id AND %s
+		ORDER BY u.id`, announcementAudience)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, userID)
+	}
+	return ids, rows.Err()
+}
+
+func (r *AnnouncementRepository) MarkNotified(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE announcements SET notified_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
+	return err
+}
+
+func (r *AnnouncementRepository) AddAttachment(ctx context.Context, attachment *domain.AnnouncementAttachment) error {
+	query := `
+		INSERT INTO announcement_attachments (id, announcement_id, caption, file_url, filename,
+			content_type, file_size, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		attachment.ID, attachment.AnnouncementID, attachment.Caption, attachment.FileURL,
+		attachment.Filename, attachment.ContentType, attachment.FileSize, attachment.UploadedBy,
+	).Scan(&attachment.CreatedAt)
+}
+
+// ListAttachments returns the attachments of the given announcements,
+// grouped by announcement.
+func (r *AnnouncementRepository) ListAttachments(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]domain.AnnouncementAttachment, error) {
+	query := `
+		SELECT id, announcement_id, caption, file_url, filename, content_type, file_size,
+			uploaded_by, created_at
+		FROM announcement_attachments
+		WHERE announcement_id = ANY($1)
+		ORDER BY created_at`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make(map[uuid.UUID][]domain.AnnouncementAttachment)
+	for rows.Next() {
+		var att domain.AnnouncementAttachment
+		err := rows.Scan(
+			&att.ID, &att.AnnouncementID, &att.Caption, &att.FileURL, &att.Filename,
+			&att.ContentType, &att.FileSize, &att.UploadedBy, &att.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments[att.AnnouncementID] = append(attachments[att.AnnouncementID], att)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteAttachment removes an attachment of the announcement and reports
+// whether there was one.
+func (r *AnnouncementRepository) DeleteAttachment(ctx context.Context, announcementID uuid.UUID, attachmentID uuid.UUID) (bool, error) {
+	query := `DELETE FROM announcement_attachments WHERE id = $1 AND announcement_id = $2`
+	result, err := conn(ctx, r.db).Exec(ctx, query, attachmentID, announcementID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
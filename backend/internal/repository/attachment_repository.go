@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type AttachmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAttachmentRepository(db *pgxpool.Pool) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *domain.TalentAttachment) error {
+	query := `
+		INSERT INTO talent_attachments (id, talent_id, kind, caption, file_url, filename, content_type, file_size, file_hash, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		attachment.ID, attachment.TalentID, attachment.Kind, attachment.Caption, attachment.FileURL,
+		attachment.Filename, attachment.ContentType, attachment.FileSize, attachment.FileHash, attachment.UploadedBy,
+	).Scan(&attachment.CreatedAt)
+}
+
+func (r *AttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.TalentAttachment, error) {
+	query := `
+		SELECT id, talent_id, kind, caption, file_url, filename, content_type, file_size, file_hash, uploaded_by, created_at
+		FROM talent_attachments WHERE id = $1`
+
+	attachment := &domain.TalentAttachment{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&attachment.ID, &attachment.TalentID, &attachment.Kind, &attachment.Caption, &attachment.FileURL,
+		&attachment.Filename, &attachment.ContentType, &attachment.FileSize, &attachment.FileHash, &attachment.UploadedBy, &attachment.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return attachment, err
+}
+
+func (r *AttachmentRepository) ListByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentAttachment, error) {
+	query := `
+		SELECT id, talent_id, kind, caption, file_url, filename, content_type, file_size, file_hash, uploaded_by, created_at
+		FROM talent_attachments
+		WHERE talent_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.TalentAttachment
+	for rows.Next() {
+		var attachment domain.TalentAttachment
+		err := rows.Scan(
+			&attachment.ID, &attachment.TalentID, &attachment.Kind, &attachment.Caption, &attachment.FileURL,
+			&attachment.Filename, &attachment.ContentType, &attachment.FileSize, &attachment.FileHash, &attachment.UploadedBy, &attachment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// ListByTalentIDs returns the attachments of the given talents, grouped by
+// talent.
+func (r *AttachmentRepository) ListByTalentIDs(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID][]domain.TalentAttachment, error) {
+	query := `
+		SELECT id, talent_id, kind, caption, file_url, filename, content_type, file_size, file_hash, uploaded_by, created_at
+		FROM talent_attachments
+		WHERE talent_id = ANY($1)
+		ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make(map[uuid.UUID][]domain.TalentAttachment)
+	for rows.Next() {
+		var attachment domain.TalentAttachment
+		err := rows.Scan(
+			&attachment.ID, &attachment.TalentID, &attachment.Kind, &attachment.Caption, &attachment.FileURL,
+			&attachment.Filename, &attachment.ContentType, &attachment.FileSize, &attachment.FileHash, &attachment.UploadedBy, &attachment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments[attachment.TalentID] = append(attachments[attachment.TalentID], attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM talent_attachments WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
+	return err
+}
+
+func (r *AttachmentRepository) DeleteByKind(ctx context.Context, talentID uuid.UUID, kind domain.AttachmentKind) error {
+	query := `DELETE FROM talent_attachments WHERE talent_id = $1 AND kind = $2`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID, kind)
+	return err
+}
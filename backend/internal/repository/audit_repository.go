@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record keeps an event in the audit log. A redelivered event is ignored.
+func (r *AuditRepository) Record(ctx context.Context, event domain.OutboxEvent) error {
+	query := `
+		INSERT INTO audit_log (event_id, event_type, aggregate_id, actor_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id) DO NOTHING`
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		event.ID, event.EventType, event.AggregateID, event.ActorID, event.Payload, event.CreatedAt,
+	)
+	return err
+}
+
+func (r *AuditRepository) List(ctx context.Context, params domain.ListParams) ([]domain.AuditLogEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if eventType, ok := params.Filters["event_type"]; ok && eventType != "" {
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", argIndex))
+		args = append(args, eventType)
+		argIndex++
+	}
+
+	if aggregateID, ok := params.Filters["aggregate_id"]; ok && aggregateID != "" {
+		conditions = append(conditions, fmt.Sprintf("aggregate_id = $%d", argIndex))
+		args = append(args, aggregateID)
+		argIndex++
+	}
+
+	if actorID, ok := params.Filters["actor_id"]; ok && actorID != "" {
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", argIndex))
+		args = append(args, actorID)
+		argIndex++
+	}
+
+	if dateFrom, ok := params.Filters["date_from"]; ok && dateFrom != "" {
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", argIndex))
+		args = append(args, dateFrom)
+		argIndex++
+	}
+
+	if dateTo, ok := params.Filters["date_to"]; ok && dateTo != "" {
+		conditions = append(conditions, fmt.Sprintf("occurred_at <= $%d", argIndex))
+		args = append(args, dateTo)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_log %s", whereClause)
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	args = append(args, params.Limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT event_id, event_type, aggregate_id, actor_id, payload, occurred_at, recorded_at
+		FROM audit_log %s
+		ORDER BY occurred_at DESC, event_id DESC
+		LIMIT $%d OFFSET $%d`,
+		whereClause, argIndex, argIndex+1,
+	)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditLogEntry
+	for rows.Next() {
+		var entry domain.AuditLogEntry
+		err := rows.Scan(
+			&entry.EventID, &entry.EventType, &entry.AggregateID, &entry.ActorID,
+			&entry.Payload, &entry.OccurredAt, &entry.RecordedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
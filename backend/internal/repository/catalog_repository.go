@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+// CatalogRepository stores the competition and organizer catalog. Linking
+// talents to entries is done by database triggers whenever a talent or an
+// alias changes; see catalog_match() in db.sql.
+type CatalogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCatalogRepository(db *pgxpool.Pool) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+const catalogEntryColumns = `e.id, e.kind, e.name, e.default_level, e.default_field, e.is_active,
+	(SELECT COUNT(*) FROM talent_catalog_links l JOIN talents t ON t.id = l.talent_id
+		WHERE l.entry_id = e.id AND t.status <> 'draft'),
+	e.created_by, e.created_at, e.updated_at`
+
+func scanCatalogEntry(row pgx.Row) (*domain.CatalogEntry, error) {
+	entry := &domain.CatalogEntry{}
+	err := row.Scan(
+		&entry.ID, &entry.Kind, &entry.Name, &entry.DefaultLevel, &entry.DefaultField, &entry.IsActive,
+		&entry.TalentCount, &entry.CreatedBy, &entry.CreatedAt, &entry.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Normalize returns the matching key of a name, or nil when the name has no
+// letters or digits.
+func (r *CatalogRepository) Normalize(ctx context.Context, name string) (*string, error) {
+	var normalized *string
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT catalog_normalize($1)`, name).Scan(&normalized)
+	return normalized, err
+}
+
+// List returns catalog entries filtered by kind and is_active, searched by
+// name or alias.
+func (r *CatalogRepository) List(ctx context.Context, params domain.ListParams) ([]domain.CatalogEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if kind, ok := params.Filters["kind"]; ok && kind != "" {
+		conditions = append(conditions, fmt.Sprintf("e.kind = $%d", argIndex))
+		args = append(args, kind)
+		argIndex++
+	}
+
+	if params.Filters["include_inactive"] != "true" {
+		conditions = append(conditions, "e.is_active")
+	}
+
+	if params.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM catalog_aliases a
+			WHERE a.entry_id = e.id AND a.normalized LIKE '%%' || catalog_normalize($%d) || '%%')`, argIndex))
+		args = append(args, params.Search)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM catalog_entries e " + whereClause
+	if err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM catalog_entries e
+		%s
+		ORDER BY e.kind, e.name
+		LIMIT $%d OFFSET $%d`, catalogEntryColumns, whereClause, argIndex, argIndex+1)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.CatalogEntry
+	for rows.Next() {
+		entry, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// GetByID returns an entry with its aliases.
+func (r *CatalogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error) {
+	query := `SELECT ` + catalogEntryColumns + ` FROM catalog_entries e WHERE e.id = $1`
+	entry, err := scanCatalogEntry(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	entry.Aliases, err = r.ListAliases(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *CatalogRepository) Create(ctx context.Context, entry *domain.CatalogEntry) error {
+	query := `
+		INSERT INTO catalog_entries (id, kind, name, default_level, default_field, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		entry.ID, entry.Kind, entry.Name, entry.DefaultLevel, entry.DefaultField, entry.IsActive, entry.CreatedBy,
+	).Scan(&entry.CreatedAt, &entry.UpdatedAt)
+}
+
+func (r *CatalogRepository) Update(ctx context.Context, entry *domain.CatalogEntry) error {
+	query := `
+		UPDATE catalog_entries SET name = $2, default_level = $3, default_field = $4, is_active = $5
+		WHERE id = $1
+		RETURNING updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		entry.ID, entry.Name, entry.DefaultLevel, entry.DefaultField, entry.IsActive,
+	).Scan(&entry.UpdatedAt)
+}
+
+// Merge folds source into target: its aliases, talent links and resolved
+// queue items move over and source is deleted.
+func (r *CatalogRepository) Merge(ctx context.Context, sourceID, targetID uuid.UUID) error {
+	queries := []string{
+		`UPDATE catalog_aliases SET entry_id = $2 WHERE entry_id = $1`,
+		`UPDATE talent_catalog_links SET entry_id = $2 WHERE entry_id = $1`,
+		`UPDATE catalog_queue SET entry_id = $2 WHERE entry_id = $1`,
+		`DELETE FROM catalog_entries WHERE id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := conn(ctx, r.db).Exec(ctx, query, sourceID, targetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *CatalogRepository) ListAliases(ctx context.Context, entryID uuid.UUID) ([]domain.CatalogAlias, error) {
+	query := `
+		SELECT id, entry_id, alias, created_at
+		FROM catalog_aliases WHERE entry_id = $1
+		ORDER BY created_at, alias`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []domain.CatalogAlias
+	for rows.Next() {
+		var alias domain.CatalogAlias
+		if err := rows.Scan(&alias.ID, &alias.EntryID, &alias.Alias, &alias.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// FindAlias returns the alias of the given kind with a normalized form, or
+// nil when no entry claims it.
+func (r *CatalogRepository) FindAlias(ctx context.Context, kind domain.CatalogKind, normalized string) (*domain.CatalogAlias, error) {
+	query := `
+		SELECT id, entry_id, alias, created_at
+		FROM catalog_aliases WHERE kind = $1 AND normalized = $2`
+
+	alias := &domain.CatalogAlias{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, kind, normalized).Scan(&alias.ID, &alias.EntryID, &alias.Alias, &alias.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+func (r *CatalogRepository) GetAlias(ctx context.Context, id uuid.UUID) (*domain.CatalogAlias, error) {
+	query := `SELECT id, entry_id, alias, created_at FROM catalog_aliases WHERE id = $1`
+
+	alias := &domain.CatalogAlias{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(&alias.ID, &alias.EntryID, &alias.Alias, &alias.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// AddAlias adds a spelling to an entry. Talents already carrying it are
+// linked and its queue item is resolved by trigger.
+func (r *CatalogRepository) AddAlias(ctx context.Context, kind domain.CatalogKind, alias *domain.CatalogAlias) error {
+	query := `
+		INSERT INTO catalog_aliases (id, entry_id, kind, alias, normalized)
+		VALUES ($1, $2, $3, $4, catalog_normalize($4))
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query, alias.ID, alias.EntryID, kind, alias.Alias).Scan(&alias.CreatedAt)
+}
+
+func (r *CatalogRepository) DeleteAlias(ctx context.Context, id uuid.UUID) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM catalog_aliases WHERE id = $1`, id)
+	return err
+}
+
+// Suggest returns active entries of a kind with an alias containing query,
+// exact and prefix matches first, then the most used. The alias that
+// matched is returned alongside each entry. An empty query lists the most
+// used entries.
+func (r *CatalogRepository) Suggest(ctx context.Context, kind domain.CatalogKind, query string, limit int) ([]domain.CatalogSuggestion, error) {
+	sql := `
+		WITH q AS (SELECT catalog_normalize($2) AS n)
+		SELECT e.id, e.kind, e.name, e.default_level, e.default_field, m.alias
+		FROM catalog_entries e
+		CROSS JOIN q
+		JOIN LATERAL (
+			SELECT a.alias,
+				CASE WHEN q.n IS NULL THEN 2
+					WHEN a.normalized = q.n THEN 0
+					WHEN a.normalized LIKE q.n || '%' THEN 1
+					ELSE 2 END AS rank
+			FROM catalog_aliases a
+			WHERE a.entry_id = e.id AND (q.n IS NULL OR a.normalized LIKE '%' || q.n || '%')
+			ORDER BY rank, length(a.alias)
+			LIMIT 1
+		) m ON TRUE
+		WHERE e.kind = $1 AND e.is_active AND ($2 = '' OR q.n IS NOT NULL)
+		ORDER BY m.rank,
+			(SELECT COUNT(*) FROM talent_catalog_links l WHERE l.entry_id = e.id) DESC,
+			e.name
+		LIMIT $3`
+
+	rows, err := conn(ctx, r.db).Query(ctx, sql, kind, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []domain.CatalogSuggestion
+	for rows.Next() {
+		var s domain.CatalogSuggestion
+		if err := rows.Scan(&s.ID, &s.Kind, &s.Name, &s.DefaultLevel, &s.DefaultField, &s.MatchedAlias); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+const catalogQueueColumns = `q.id, q.kind, q.raw_name, q.status, q.entry_id, q.resolved_by, q.resolved_at,
+	(SELECT COUNT(*) FROM talents t JOIN talent_type_definitions d ON d.code = t.talent_type
+		WHERE t.status <> 'draft'
+			AND catalog_normalize(t.detail->>CASE q.kind
+				WHEN 'competition' THEN d.catalog_competition_field
+				ELSE d.match_organizer_field END) = q.normalized),
+	q.created_at`
+
+func scanCatalogQueueItem(row pgx.Row) (*domain.CatalogQueueItem, error) {
+	item := &domain.CatalogQueueItem{}
+	err := row.Scan(
+		&item.ID, &item.Kind, &item.RawName, &item.Status, &item.EntryID, &item.ResolvedBy, &item.ResolvedAt,
+		&item.TalentCount, &item.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListQueue returns normalization queue items filtered by kind and status,
+// oldest first.
+func (r *CatalogRepository) ListQueue(ctx context.Context, params domain.ListParams) ([]domain.CatalogQueueItem, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if kind, ok := params.Filters["kind"]; ok && kind != "" {
+		conditions = append(conditions, fmt.Sprintf("q.kind = $%d", argIndex))
+		args = append(args, kind)
+		argIndex++
+	}
+
+	if status, ok := params.Filters["status"]; ok && status != "" {
+		conditions = append(conditions, fmt.Sprintf("q.status = $%d", argIndex))
+		args = append(args, status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM catalog_queue q " + whereClause
+	if err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM catalog_queue q
+		%s
+		ORDER BY q.created_at
+		LIMIT $%d OFFSET $%d`, catalogQueueColumns, whereClause, argIndex, argIndex+1)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []domain.CatalogQueueItem
+	for rows.Next() {
+		item, err := scanCatalogQueueItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, *item)
+	}
+	return items, total, rows.Err()
+}
+
+func (r *CatalogRepository) GetQueueItem(ctx context.Context, id uuid.UUID) (*domain.CatalogQueueItem, error) {
+	query := `SELECT ` + catalogQueueColumns + ` FROM catalog_queue q WHERE q.id = $1`
+	return scanCatalogQueueItem(conn(ctx, r.db).QueryRow(ctx, query, id))
+}
+
+func (r *CatalogRepository) UpdateQueueItem(ctx context.Context, item *domain.CatalogQueueItem) error {
+	query := `
+		UPDATE catalog_queue SET status = $2, entry_id = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $1`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, item.ID, item.Status, item.EntryID, item.ResolvedBy, item.ResolvedAt)
+	return err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type CertificateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCertificateRepository(db *pgxpool.Pool) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+// ListDue returns the approved talents of active GTK whose certificate
+// expires within one of reminderDays and who have not been reminded at that
+// threshold for the current expiry date. Only the nearest threshold counts,
+// so a certificate entered 20 days before expiry gets the 30 day notice alone.
+func (r *CertificateRepository) ListDue(ctx context.Context, reminderDays []int) ([]domain.ExpiringCertificate, error) {
+	query := `
+		SELECT t.id, t.user_id, u.school_id, COALESCE(t.detail->>d.match_name_field, d.name),
+			e.expiry, e.expiry - CURRENT_DATE, n.days_before
+		FROM talents t
+		JOIN users u ON u.id = t.user_id
+		JOIN talent_type_definitions d ON d.code = t.talent_type
+		CROSS JOIN LATERAL (SELECT certificate_expiry(t.detail) AS expiry) e
+		CROSS JOIN LATERAL (
+			SELECT MIN(days) AS days_before FROM unnest($1::int[]) AS days
+			WHERE days >= e.expiry - CURRENT_DATE
+		) n
+		WHERE t.status = 'approved' AND u.is_active
+			AND e.expiry >= CURRENT_DATE AND n.days_before IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM certificate_expiry_notices c
+				WHERE c.talent_id = t.id AND c.expiry_date = e.expiry AND c.days_before <= n.days_before
+			)
+		ORDER BY e.expiry`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, reminderDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certs []domain.ExpiringCertificate
+	for rows.Next() {
+		var cert domain.ExpiringCertificate
+		err := rows.Scan(
+			&cert.TalentID, &cert.UserID, &cert.SchoolID, &cert.Name,
+			&cert.ExpiryDate, &cert.DaysLeft, &cert.DaysBefore,
+		)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, rows.Err()
+}
+
+// MarkNotified records that the reminder at daysBefore went out for the
+// certificate expiring on expiryDate.
+func (r *CertificateRepository) MarkNotified(ctx context.Context, talentID uuid.UUID, expiryDate time.Time, daysBefore int) error {
+	query := `
+		INSERT INTO certificate_expiry_notices (talent_id, expiry_date, days_before)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID, expiryDate, daysBefore)
+	return err
+}
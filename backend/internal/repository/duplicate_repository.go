@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type DuplicateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDuplicateRepository(db *pgxpool.Pool) *DuplicateRepository {
+	return &DuplicateRepository{db: db}
+}
+
+// FindSimilar returns other talents of the same owner and type whose name is
+// above the pg_trgm similarity threshold, most similar first. Rejected
+// talents and drafts are ignored.
+func (r *DuplicateRepository) FindSimilar(ctx context.Context, talentID uuid.UUID, limit int) ([]domain.DuplicateCandidate, error) {
+	query := `
+		SELECT k.talent_id,
+			similarity(k.name, me.name),
+			COALESCE(similarity(k.organizer, me.organizer), 0),
+			COALESCE(k.event_date = me.event_date, FALSE)
+		FROM talent_match_keys me
+		JOIN talents mt ON mt.id = me.talent_id
+		JOIN talents t ON t.user_id = mt.user_id AND t.talent_type = mt.talent_type
+			AND t.id <> mt.id AND t.status NOT IN ('rejected', 'draft')
+		JOIN talent_match_keys k ON k.talent_id = t.id
+		WHERE me.talent_id = $1 AND k.name % me.name
+		ORDER BY 2 DESC
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []domain.DuplicateCandidate
+	for rows.Next() {
+		var candidate domain.DuplicateCandidate
+		var nameScore, organizerScore float32
+		if err := rows.Scan(&candidate.TalentID, &nameScore, &organizerScore, &candidate.SameDate); err != nil {
+			return nil, err
+		}
+		candidate.NameScore = float64(nameScore)
+		candidate.OrganizerScore = float64(organizerScore)
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// FindSameFile returns talents of any owner that carry a file with the same
+// content hash as one of the talent's attachments.
+func (r *DuplicateRepository) FindSameFile(ctx context.Context, talentID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT other.talent_id
+		FROM talent_attachments mine
+		JOIN talent_attachments other ON other.file_hash = mine.file_hash AND other.talent_id <> mine.talent_id
+		JOIN talents t ON t.id = other.talent_id
+		WHERE mine.talent_id = $1 AND mine.file_hash IS NOT NULL AND t.status NOT IN ('rejected', 'draft')`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Replace swaps the stored matches of a talent for a new set.
+func (r *DuplicateRepository) Replace(ctx context.Context, talentID uuid.UUID, matches []domain.TalentDuplicateMatch) error {
+	if _, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM talent_duplicate_matches WHERE talent_id = $1`, talentID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO talent_duplicate_matches (talent_id, match_id, reasons, score)
+		VALUES ($1, $2, $3, $4)`
+	for _, match := range matches {
+		reasons := make([]string, len(match.Reasons))
+		for i, reason := range match.Reasons {
+			reasons[i] = string(reason)
+		}
+		if _, err := conn(ctx, r.db).Exec(ctx, query, talentID, match.MatchID, reasons, match.Score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListByTalentIDs returns the suspected duplicates of the given talents,
+// grouped by talent, each with the matched talent and its owner.
+func (r *DuplicateRepository) ListByTalentIDs(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID][]domain.TalentDuplicateMatchDetail, error) {
+	query := `
+		SELECT d.talent_id, d.match_id, d.reasons, d.score, d.created_at,
+			m.talent_type, m.status, u.id, u.full_name
+		FROM talent_duplicate_matches d
+		JOIN talents m ON m.id = d.match_id
+		JOIN users u ON u.id = m.user_id
+		WHERE d.talent_id = ANY($1)
+		ORDER BY d.score DESC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make(map[uuid.UUID][]domain.TalentDuplicateMatchDetail)
+	for rows.Next() {
+		var match domain.TalentDuplicateMatchDetail
+		var reasons []string
+		var score float32
+		err := rows.Scan(
+			&match.TalentID, &match.MatchID, &reasons, &score, &match.CreatedAt,
+			&match.MatchType, &match.MatchStatus, &match.MatchOwner.ID, &match.MatchOwner.FullName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, reason := range reasons {
+			match.Reasons = append(match.Reasons, domain.DuplicateReason(reason))
+		}
+		match.Score = float64(score)
+		matches[match.TalentID] = append(matches[match.TalentID], match)
+	}
+	return matches, rows.Err()
+}
+
+func (r *DuplicateRepository) ListByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentDuplicateMatch, error) {
+	query := `
+		SELECT talent_id, match_id, reasons, score, created_at
+		FROM talent_duplicate_matches
+		WHERE talent_id = $1
+		ORDER BY score DESC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []domain.TalentDuplicateMatch
+	for rows.Next() {
+		var match domain.TalentDuplicateMatch
+		var reasons []string
+		var score float32
+		if err := rows.Scan(&match.TalentID, &match.MatchID, &reasons, &score, &match.CreatedAt); err != nil {
+			return nil, err
+		}
+		for _, reason := range reasons {
+			match.Reasons = append(match.Reasons, domain.DuplicateReason(reason))
+		}
+		match.Score = float64(score)
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
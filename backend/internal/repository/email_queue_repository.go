@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type EmailQueueRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailQueueRepository(db *pgxpool.Pool) *EmailQueueRepository {
+	return &EmailQueueRepository{db: db}
+}
+
+func (r *EmailQueueRepository) Enqueue(ctx context.Context, job *domain.EmailJob) error {
+	query := `
+		INSERT INTO email_queue (id, user_id, to_address, subject, text_body, html_body)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		job.ID, job.UserID, job.To, job.Subject, job.Text, job.HTML,
+	).Scan(&job.CreatedAt)
+}
+
+// ClaimDue locks the oldest unsent email that is due and has attempts left,
+// so concurrent senders skip it. It returns nil when nothing is due.
+func (r *EmailQueueRepository) ClaimDue(ctx context.Context, maxAttempts int) (*domain.EmailJob, error) {
+	query := `
+		SELECT id, user_id, to_address, subject, text_body, html_body, attempts, created_at
+		FROM email_queue
+		WHERE sent_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP AND attempts < $1
+		ORDER BY next_attempt_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`
+
+	job := &domain.EmailJob{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, maxAttempts).Scan(
+		&job.ID, &job.UserID, &job.To, &job.Subject, &job.Text, &job.HTML,
+		&job.Attempts, &job.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (r *EmailQueueRepository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE email_queue
+		SET attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, last_error = NULL
+		WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
+	return err
+}
+
+// MarkFailed records a failed attempt and schedules the next one with
+// exponential backoff, starting at 30 seconds and capped at a day.
+func (r *EmailQueueRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE email_queue
+		SET attempts = attempts + 1, last_error = $2,
+			next_attempt_at = CURRENT_TIMESTAMP
+				+ LEAST(power(2, attempts) * INTERVAL '30 seconds', INTERVAL '1 day')
+		WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, reason)
+	return err
+}
+
+func (r *EmailQueueRepository) AddDigestItem(ctx context.Context, item *domain.DigestItem) error {
+	query := `
+		INSERT INTO notification_digest_items (id, user_id, type, message, talent_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		item.ID, item.UserID, item.Type, item.Message, item.TalentID,
+	).Scan(&item.CreatedAt)
+}
+
+// ListDigestUsers returns the users with digest items collected before
+// cutoff that have not been sent yet.
+func (r *EmailQueueRepository) ListDigestUsers(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT user_id FROM notification_digest_items
+		WHERE sent_at IS NULL AND created_at < $1`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ClaimDigestItems locks the user's pending digest items collected before
+// cutoff, oldest first, skipping items another sender holds.
+func (r *EmailQueueRepository) ClaimDigestItems(ctx context.Context, userID uuid.UUID, cutoff time.Time) ([]domain.DigestItem, error) {
+	query := `
+		SELECT id, user_id, type, message, talent_id, created_at
+		FROM notification_digest_items
+		WHERE user_id = $1 AND sent_at IS NULL AND created_at < $2
+		ORDER BY created_at
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.DigestItem
+	for rows.Next() {
+		var item domain.DigestItem
+		err := rows.Scan(&item.ID, &item.UserID, &item.Type, &item.Message, &item.TalentID, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *EmailQueueRepository) MarkDigestSent(ctx context.Context, ids []uuid.UUID) error {
+	query := `UPDATE notification_digest_items SET sent_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`
+	_, err := conn(ctx, r.db).Exec(ctx, query, ids)
+	return err
+}
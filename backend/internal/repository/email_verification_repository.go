@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type EmailVerificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailVerificationRepository(db *pgxpool.Pool) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *domain.EmailVerification) error {
+	query := `
+		INSERT INTO email_verifications (id, user_id, email, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`
+
+	return r.db.QueryRow(ctx, query,
+		verification.ID, verification.UserID, verification.Email,
+		verification.Purpose, verification.TokenHash, verification.ExpiresAt,
+	).Scan(&verification.CreatedAt)
+}
+
+// GetByHash returns an unconfirmed, unexpired token.
+func (r *EmailVerificationRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, email, purpose, token_hash, expires_at, confirmed_at, created_at
+		FROM email_verifications
+		WHERE token_hash = $1 AND confirmed_at IS NULL AND expires_at > $2`
+
+	verification := &domain.EmailVerification{}
+	err := r.db.QueryRow(ctx, query, tokenHash, time.Now()).Scan(
+		&verification.ID, &verification.UserID, &verification.Email, &verification.Purpose,
+		&verification.TokenHash, &verification.ExpiresAt, &verification.ConfirmedAt, &verification.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return verification, err
+}
+
+// GetPending returns the latest outstanding token of the given purpose.
+func (r *EmailVerificationRepository) GetPending(ctx context.Context, userID uuid.UUID, purpose domain.EmailTokenPurpose) (*domain.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, email, purpose, token_hash, expires_at, confirmed_at, created_at
+		FROM email_verifications
+		WHERE user_id = $1 AND purpose = $2 AND confirmed_at IS NULL AND expires_at > $3
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	verification := &domain.EmailVerification{}
+	err := r.db.QueryRow(ctx, query, userID, purpose, time.Now()).Scan(
+		&verification.ID, &verification.UserID, &verification.Email, &verification.Purpose,
+		&verification.TokenHash, &verification.ExpiresAt, &verification.ConfirmedAt, &verification.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return verification, err
+}
+
+func (r *EmailVerificationRepository) MarkConfirmed(ctx context.Context, id uuid.UUID, confirmedAt time.Time) error {
+	query := `UPDATE email_verifications SET confirmed_at = $2 WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, confirmedAt)
+	return err
+}
+
+// DeletePending invalidates outstanding tokens so only the most recent link works.
+func (r *EmailVerificationRepository) DeletePending(ctx context.Context, userID uuid.UUID, purpose domain.EmailTokenPurpose) error {
+	query := `DELETE FROM email_verifications WHERE user_id = $1 AND purpose = $2 AND confirmed_at IS NULL`
+	_, err := r.db.Exec(ctx, query, userID, purpose)
+	return err
+}
@@ -25,7 +25,7 @@ func (r *NotificationRepository) Create(ctx context.Context, notification *domai
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING created_at`
 
-	return r.db.QueryRow(ctx, query,
+	return conn(ctx, r.db).QueryRow(ctx, query,
 		notification.ID, notification.UserID, notification.TalentID,
 		notification.Type, notification.Message, notification.IsRead,
 	).Scan(&notification.CreatedAt)
@@ -37,7 +37,7 @@ func (r *NotificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 		FROM notifications WHERE id = $1`
 
 	notification := &domain.Notification{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&notification.ID, &notification.UserID, &notification.TalentID,
 		&notification.Type, &notification.Message, &notification.IsRead,
 		&notification.CreatedAt,
@@ -50,13 +50,13 @@ func (r *NotificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 
 func (r *NotificationRepository) MarkAsRead(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE notifications SET is_read = true WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	return err
 }
 
 func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
 	query := `UPDATE notifications SET is_read = true WHERE user_id = $1 AND is_read = false`
-	result, err := r.db.Exec(ctx, query, userID)
+	result, err := conn(ctx, r.db).Exec(ctx, query, userID)
 	if err != nil {
 		return 0, err
 	}
@@ -78,11 +78,17 @@ func (r *NotificationRepository) List(ctx context.Context, userID uuid.UUID, par
 		argIndex++
 	}
 
+	if notificationType, ok := params.Filters["type"]; ok && notificationType != "" {
+		conditions = append(conditions, fmt.Sprintf("type = $%d", argIndex))
+		args = append(args, notificationType)
+		argIndex++
+	}
+
 	whereClause := "WHERE " + strings.Join(conditions, " AND ")
 
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM notifications %s", whereClause)
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -98,7 +104,7 @@ func (r *NotificationRepository) List(ctx context.Context, userID uuid.UUID, par
 		whereClause, argIndex, argIndex+1,
 	)
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -124,6 +130,160 @@ func (r *NotificationRepository) List(ctx context.Context, userID uuid.UUID, par
 func (r *NotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND is_read = false`
-	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&count)
 	return count, err
 }
+
+// ListSince returns the user's notifications created after seq, oldest first.
+// seq is assigned under a per-user lock held until commit, so no notification
+// with a lower seq can still show up later.
+func (r *NotificationRepository) ListSince(ctx context.Context, userID uuid.UUID, seq int64, limit int) ([]domain.Notification, error) {
+	query := `
+		SELECT id, user_id, talent_id, type, message, is_read, seq, created_at
+		FROM notifications
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []domain.Notification
+	for rows.Next() {
+		var notification domain.Notification
+		err := rows.Scan(
+			&notification.ID, &notification.UserID, &notification.TalentID,
+			&notification.Type, &notification.Message, &notification.IsRead,
+			&notification.Seq, &notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+// LatestSeq returns the sequence number of the user's newest notification,
+// or 0 when there is none.
+func (r *NotificationRepository) LatestSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var seq int64
+	query := `SELECT COALESCE(MAX(seq), 0) FROM notifications WHERE user_id = $1`
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&seq)
+	return seq, err
+}
+
+// Listen holds a dedicated connection on LISTEN notification_changes and
+// calls changed with the user named by each notification until ctx ends or
+// the connection fails. ready runs once the listener is in place.
+func (r *NotificationRepository) Listen(ctx context.Context, ready func(), changed func(userID uuid.UUID)) error {
+	pooled, err := r.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection keeps listening, so it must not go back to the pool
+	listener := pooled.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err := listener.Exec(ctx, "LISTEN notification_changes"); err != nil {
+		return err
+	}
+	ready()
+
+	for {
+		notification, err := listener.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		userID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			continue
+		}
+		changed(userID)
+	}
+}
+
+// GetPreference returns the user's channels for a notification type, or nil
+// when the user kept the defaults.
+func (r *NotificationRepository) GetPreference(ctx context.Context, userID uuid.UUID, notifType domain.NotificationType) (*domain.NotificationPreference, error) {
+	query := `
+		SELECT type, in_app, email, digest
+		FROM notification_preferences WHERE user_id = $1 AND type = $2`
+
+	pref := &domain.NotificationPreference{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID, notifType).Scan(
+		&pref.Type, &pref.InApp, &pref.Email, &pref.Digest,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return pref, err
+}
+
+func (r *NotificationRepository) ListPreferences(ctx context.Context, userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	query := `
+		SELECT type, in_app, email, digest
+		FROM notification_preferences WHERE user_id = $1`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []domain.NotificationPreference
+	for rows.Next() {
+		var pref domain.NotificationPreference
+		if err := rows.Scan(&pref.Type, &pref.InApp, &pref.Email, &pref.Digest); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, rows.Err()
+}
+
+func (r *NotificationRepository) UpsertPreference(ctx context.Context, userID uuid.UUID, pref domain.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, type, in_app, email, digest)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, type) DO UPDATE
+		SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, digest = EXCLUDED.digest,
+			updated_at = CURRENT_TIMESTAMP`
+	_, err := conn(ctx, r.db).Exec(ctx, query, userID, pref.Type, pref.InApp, pref.Email, pref.Digest)
+	return err
+}
+
+// ClaimKey records that the notification identified by key went out to the
+// user. It reports false when the key was already claimed.
+func (r *NotificationRepository) ClaimKey(ctx context.Context, userID uuid.UUID, key string) (bool, error) {
+	query := `
+		INSERT INTO notification_keys (user_id, key) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	result, err := conn(ctx, r.db).Exec(ctx, query, userID, key)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ListKeyClaimants returns the users who already claimed the key.
+func (r *NotificationRepository) ListKeyClaimants(ctx context.Context, key string) (map[uuid.UUID]bool, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT user_id FROM notification_keys WHERE key = $1`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	m := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		m[id] = true
+	}
+	return m, rows.Err()
+}
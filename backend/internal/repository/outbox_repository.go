@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Insert stores an event with one pending delivery per consumer. Callers
+// run it in the transaction of the change the event describes.
+func (r *OutboxRepository) Insert(ctx context.Context, event *domain.OutboxEvent, consumers []string) error {
+	query := `
+		WITH e AS (
+			INSERT INTO outbox_events (event_type, aggregate_id, actor_id, payload)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		), d AS (
+			INSERT INTO outbox_deliveries (event_id, consumer)
+			SELECT e.id, c FROM e, unnest($5::text[]) AS c
+		)
+		SELECT id, created_at FROM e`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		event.EventType, event.AggregateID, event.ActorID, event.Payload, consumers,
+	).Scan(&event.ID, &event.CreatedAt)
+}
+
+// ClaimDue locks the oldest delivery that is due and has attempts left, so
+// concurrent dispatchers skip it. It returns nil when nothing is due. The
+// lock lasts until the surrounding transaction ends.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, maxAttempts int) (*domain.OutboxDelivery, error) {
+	query := `
+		SELECT e.id, e.event_type, e.aggregate_id, e.actor_id, e.payload, e.created_at,
+			d.consumer, d.attempts
+		FROM outbox_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.delivered_at IS NULL AND d.next_attempt_at <= CURRENT_TIMESTAMP
+			AND d.attempts < $1
+		ORDER BY d.next_attempt_at, d.event_id
+		LIMIT 1
+		FOR UPDATE OF d SKIP LOCKED`
+
+	delivery := &domain.OutboxDelivery{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, maxAttempts).Scan(
+		&delivery.Event.ID, &delivery.Event.EventType, &delivery.Event.AggregateID,
+		&delivery.Event.ActorID, &delivery.Event.Payload, &delivery.Event.CreatedAt,
+		&delivery.Consumer, &delivery.Attempts,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return delivery, err
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64, consumer string) error {
+	query := `
+		UPDATE outbox_deliveries
+		SET attempts = attempts + 1, delivered_at = CURRENT_TIMESTAMP, last_error = NULL
+		WHERE event_id = $1 AND consumer = $2`
+	_, err := conn(ctx, r.db).Exec(ctx, query, eventID, consumer)
+	return err
+}
+
+// MarkFailed records a failed attempt and schedules the next one with
+// exponential backoff, starting at 30 seconds and capped at a day.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID int64, consumer string, reason string) error {
+	query := `
+		UPDATE outbox_deliveries
+		SET attempts = attempts + 1, last_error = $3,
+			next_attempt_at = CURRENT_TIMESTAMP
+				+ LEAST(power(2, attempts) * INTERVAL '30 seconds', INTERVAL '1 day')
+		WHERE event_id = $1 AND consumer = $2`
+	_, err := conn(ctx, r.db).Exec(ctx, query, eventID, consumer, reason)
+	return err
+}
+
+// Prune deletes events older than before whose deliveries are all done,
+// either delivered or out of attempts.
+func (r *OutboxRepository) Prune(ctx context.Context, before time.Time, maxAttempts int) (int64, error) {
+	query := `
+		DELETE FROM outbox_events e
+		WHERE e.created_at < $1
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_deliveries d
+				WHERE d.event_id = e.id AND d.delivered_at IS NULL AND d.attempts < $2
+			)`
+	result, err := conn(ctx, r.db).Exec(ctx, query, before, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type RegistrationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRegistrationRepository(db *pgxpool.Pool) *RegistrationRepository {
+	return &RegistrationRepository{db: db}
+}
+
+const registrationColumns = `id, user_id, school_id, email, full_name, status, reviewed_by, reviewed_at,
+	rejection_reason, created_at, updated_at`
+
+func scanRegistration(row pgx.Row) (*domain.UserRegistration, error) {
+	registration := &domain.UserRegistration{}
+	err := row.Scan(
+		&registration.ID, &registration.UserID, &registration.SchoolID, &registration.Email,
+		&registration.FullName, &registration.Status, &registration.ReviewedBy, &registration.ReviewedAt,
+		&registration.RejectionReason, &registration.CreatedAt, &registration.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return registration, nil
+}
+
+func (r *RegistrationRepository) Create(ctx context.Context, registration *domain.UserRegistration) error {
+	query := `
+		INSERT INTO user_registrations (id, user_id, school_id, email, full_name, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		registration.ID, registration.UserID, registration.SchoolID, registration.Email,
+		registration.FullName, registration.Status,
+	).Scan(&registration.CreatedAt, &registration.UpdatedAt)
+}
+
+func (r *RegistrationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.UserRegistration, error) {
+	query := `SELECT ` + registrationColumns + ` FROM user_registrations WHERE id = $1`
+	return scanRegistration(conn(ctx, r.db).QueryRow(ctx, query, id))
+}
+
+func (r *RegistrationRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.UserRegistration, error) {
+	query := `SELECT ` + registrationColumns + ` FROM user_registrations WHERE user_id = $1`
+	return scanRegistration(conn(ctx, r.db).QueryRow(ctx, query, userID))
+}
+
+// Update saves the review of a pending registration. It reports false when
+// the registration was reviewed in the meantime, so two reviewers acting at
+// once cannot both succeed. The user is not written: a rejection clears it
+// by deleting the account.
+func (r *RegistrationRepository) Update(ctx context.Context, registration *domain.UserRegistration) (bool, error) {
+	query := `
+		UPDATE user_registrations SET status = $2, reviewed_by = $3, reviewed_at = $4, rejection_reason = $5
+		WHERE id = $1 AND status = 'pending'
+		RETURNING updated_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		registration.ID, registration.Status, registration.ReviewedBy,
+		registration.ReviewedAt, registration.RejectionReason,
+	).Scan(&registration.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *RegistrationRepository) List(ctx context.Context, params domain.ListParams) ([]domain.UserRegistration, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if status, ok := params.Filters["status"]; ok && status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, status)
+		argIndex++
+	}
+
+	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
+		conditions = append(conditions, fmt.Sprintf("school_id = $%d", argIndex))
+		args = append(args, schoolID)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM user_registrations %s", whereClause)
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Oldest first so the queue is worked in submission order
+	orderBy := "created_at ASC"
+	if params.Sort == "-created_at" {
+		orderBy = "created_at DESC"
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	args = append(args, params.Limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_registrations %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`,
+		registrationColumns, whereClause, orderBy, argIndex, argIndex+1,
+	)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var registrations []domain.UserRegistration
+	for rows.Next() {
+		registration, err := scanRegistration(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		registrations = append(registrations, *registration)
+	}
+
+	return registrations, total, nil
+}
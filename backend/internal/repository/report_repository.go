@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type ReportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// PKB lists every active GTK with the days and lesson hours of their approved
+// trainings that started in the year filter, GTK without any included. The
+// filters are year (required), school_id and below_target.
+func (r *ReportRepository) PKB(ctx context.Context, params domain.ListParams, jpPerDay, targetJP int) ([]domain.PKBReportEntry, int, error) {
+	args := []interface{}{params.Filters["year"], jpPerDay, targetJP}
+	conditions := []string{"u.role = 'gtk'", "u.is_active"}
+	argIndex := len(args) + 1
+
+	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
+		conditions = append(conditions, fmt.Sprintf("u.school_id = $%d", argIndex))
+		args = append(args, schoolID)
+		argIndex++
+	}
+
+	report := `
+		WITH trainings AS (
+			SELECT user_id, COUNT(*) AS training_count, SUM(days) AS total_days
+			FROM (
+				SELECT t.user_id,
+					CASE WHEN t.detail->>'start_date' ~ '^\d{4}-\d{2}-\d{2}$'
+						THEN EXTRACT(YEAR FROM (t.detail->>'start_date')::date) END AS year,
+					CASE WHEN t.detail->>'duration_days' ~ '^\d+$'
+						THEN (t.detail->>'duration_days')::int ELSE 0 END AS days
+				FROM talents t
+				WHERE t.talent_type = 'peserta_pelatihan' AND t.status = 'approved'
+			) approved
+			WHERE year = $1::int
+			GROUP BY user_id
+		),
+		report AS (
+			SELECT u.id AS user_id, u.school_id, COALESCE(tr.training_count, 0) AS training_count,
+				COALESCE(tr.total_days, 0) AS total_days, COALESCE(tr.total_days, 0) * $2::int AS total_jp,
+				COALESCE(tr.total_days, 0) * $2::int < $3::int AS below_target
+			FROM users u
+			LEFT JOIN trainings tr ON tr.user_id = u.id
+			WHERE ` + strings.Join(conditions, " AND ") + `
+		)`
+
+	belowTarget := ""
+	switch params.Filters["below_target"] {
+	case "true":
+		belowTarget = " WHERE below_target"
+	case "false":
+		belowTarget = " WHERE NOT below_target"
+	}
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, report+` SELECT COUNT(*) FROM report`+belowTarget, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := report + fmt.Sprintf(`
+		SELECT u.id, u.full_name, u.nip, s.id, s.name, s.npsn,
+			r.training_count, r.total_days, r.total_jp, r.below_target
+		FROM (SELECT * FROM report%s) r
+		JOIN users u ON u.id = r.user_id
+		LEFT JOIN schools s ON s.id = r.school_id
+		ORDER BY s.name NULLS LAST, u.full_name, u.id
+		LIMIT $%d OFFSET $%d`, belowTarget, argIndex, argIndex+1)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.PKBReportEntry
+	for rows.Next() {
+		entry := domain.PKBReportEntry{TargetJP: targetJP}
+		var schoolID *uuid.UUID
+		var schoolName, schoolNPSN *string
+		err := rows.Scan(
+			&entry.User.ID, &entry.User.FullName, &entry.User.NIP, &schoolID, &schoolName, &schoolNPSN,
+			&entry.TrainingCount, &entry.TotalDays, &entry.TotalJP, &entry.BelowTarget,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if schoolID != nil {
+			entry.School = &domain.SchoolRef{ID: *schoolID, Name: *schoolName, NPSN: *schoolNPSN}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type ReviewCommentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReviewCommentRepository(db *pgxpool.Pool) *ReviewCommentRepository {
+	return &ReviewCommentRepository{db: db}
+}
+
+func (r *ReviewCommentRepository) Create(ctx context.Context, comment *domain.TalentReviewComment) error {
+	query := `
+		INSERT INTO talent_review_comments (id, talent_id, field, comment, author_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING is_resolved, created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		comment.ID, comment.TalentID, comment.Field, comment.Comment, comment.AuthorID,
+	).Scan(&comment.IsResolved, &comment.CreatedAt)
+}
+
+func (r *ReviewCommentRepository) ListByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentReviewComment, error) {
+	return r.list(ctx, `WHERE talent_id = $1`, talentID)
+}
+
+func (r *ReviewCommentRepository) ListUnresolvedByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentReviewComment, error) {
+	return r.list(ctx, `WHERE talent_id = $1 AND is_resolved = FALSE`, talentID)
+}
+
+func (r *ReviewCommentRepository) Resolve(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE talent_review_comments SET is_resolved = TRUE, resolved_at = $2 WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, time.Now())
+	return err
+}
+
+func (r *ReviewCommentRepository) list(ctx context.Context, whereClause string, args ...interface{}) ([]domain.TalentReviewComment, error) {
+	query := `
+		SELECT id, talent_id, field, comment, author_id, is_resolved, resolved_at, created_at
+		FROM talent_review_comments ` + whereClause + `
+		ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []domain.TalentReviewComment
+	for rows.Next() {
+		var comment domain.TalentReviewComment
+		err := rows.Scan(
+			&comment.ID, &comment.TalentID, &comment.Field, &comment.Comment,
+			&comment.AuthorID, &comment.IsResolved, &comment.ResolvedAt, &comment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
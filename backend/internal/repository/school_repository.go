@@ -213,7 +213,7 @@ func (r *SchoolRepository) GetStatistics(ctx context.Context, params domain.List
 			COUNT(DISTINCT CASE WHEN t.status = 'approved' THEN t.id END) as approved_count
 		FROM schools s
 		LEFT JOIN users u ON u.school_id = s.id AND u.role = 'gtk'
-		LEFT JOIN talents t ON t.user_id = u.id
+		LEFT JOIN talents t ON t.user_id = u.id AND t.status <> 'draft'
 		%s
 		GROUP BY s.id, s.name, s.npsn, s.status
 		ORDER BY %s
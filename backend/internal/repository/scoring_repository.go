@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type ScoringRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewScoringRepository(db *pgxpool.Pool) *ScoringRepository {
+	return &ScoringRepository{db: db}
+}
+
+// GetScheme returns the scoring scheme, or nil when none was seeded.
+func (r *ScoringRepository) GetScheme(ctx context.Context) (*domain.ScoringSchemeSettings, error) {
+	query := `SELECT scheme, updated_by, updated_at FROM scoring_scheme`
+
+	settings := &domain.ScoringSchemeSettings{}
+	err := conn(ctx, r.db).QueryRow(ctx, query).Scan(&settings.Scheme, &settings.UpdatedBy, &settings.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *ScoringRepository) SaveScheme(ctx context.Context, settings *domain.ScoringSchemeSettings) error {
+	query := `
+		INSERT INTO scoring_scheme (id, scheme, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET scheme = EXCLUDED.scheme, updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query, settings.Scheme, settings.UpdatedBy).Scan(&settings.UpdatedAt)
+}
+
+// IsValidPattern reports whether PostgreSQL accepts pattern as a regular
+// expression, which is how talent_score() evaluates achievement patterns.
+func (r *ScoringRepository) IsValidPattern(ctx context.Context, pattern string) (bool, error) {
+	var matched bool
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT '' ~* $1`, pattern).Scan(&matched)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "2201B" {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// scoredTalents selects the points of every approved talent of an active GTK
+// matching the leaderboard filters. A talent falls in the year of its event
+// date when its type has one, otherwise in the year it was approved.
+func scoredTalents(params domain.ListParams) (string, []interface{}) {
+	conditions := []string{"t.status = 'approved'", "u.is_active"}
+	var args []interface{}
+	argIndex := 1
+
+	if year, ok := params.Filters["year"]; ok && year != "" {
+		conditions = append(conditions, fmt.Sprintf(`EXTRACT(YEAR FROM COALESCE(
+			CASE WHEN t.detail->>d.match_date_field ~ '^\d{4}-\d{2}-\d{2}$'
+				THEN (t.detail->>d.match_date_field)::date END,
+			t.verified_at::date)) = $%d::int`, argIndex))
+		args = append(args, year)
+		argIndex++
+	}
+
+	if field, ok := params.Filters["field"]; ok && field != "" {
+		conditions = append(conditions, fmt.Sprintf("t.detail->>'field' = $%d", argIndex))
+		args = append(args, field)
+		argIndex++
+	}
+
+	if talentType, ok := params.Filters["talent_type"]; ok && talentType != "" {
+		conditions = append(conditions, fmt.Sprintf("t.talent_type = $%d", argIndex))
+		args = append(args, talentType)
+		argIndex++
+	}
+
+	if schoolStatus, ok := params.Filters["school_status"]; ok && schoolStatus != "" {
+		conditions = append(conditions, fmt.Sprintf("s.status = $%d", argIndex))
+		args = append(args, schoolStatus)
+		argIndex++
+	}
+
+	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
+		conditions = append(conditions, fmt.Sprintf("u.school_id = $%d", argIndex))
+		args = append(args, schoolID)
+		argIndex++
+	}
+
+	query := `
+		SELECT t.user_id, u.school_id, talent_score(sc.scheme, t.talent_type, t.detail) AS points
+		FROM talents t
+		JOIN users u ON t.user_id = u.id
+		LEFT JOIN schools s ON s.id = u.school_id
+		JOIN talent_type_definitions d ON d.code = t.talent_type
+		CROSS JOIN scoring_scheme sc
+		WHERE ` + strings.Join(conditions, " AND ")
+	return query, args
+}
+
+// GTKLeaderboard ranks GTK by the points of their approved talents. GTK with
+// equal points share a rank.
+func (r *ScoringRepository) GTKLeaderboard(ctx context.Context, params domain.ListParams) ([]domain.GTKLeaderboardEntry, int, error) {
+	scored, args := scoredTalents(params)
+	ranked := `
+		WITH scored AS (` + scored + `),
+		ranked AS (
+			SELECT user_id, SUM(points) AS points, COUNT(*) AS talent_count,
+				RANK() OVER (ORDER BY SUM(points) DESC) AS rank
+			FROM scored
+			GROUP BY user_id
+		)`
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, ranked+` SELECT COUNT(*) FROM ranked`, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	argIndex := len(args) + 1
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := ranked + fmt.Sprintf(`
+		SELECT r.rank, u.id, u.full_name, u.nip, s.id, s.name, s.npsn, ROUND(r.points, 2)::float8, r.talent_count
+		FROM ranked r
+		JOIN users u ON u.id = r.user_id
+		LEFT JOIN schools s ON s.id = u.school_id
+		ORDER BY r.rank, u.full_name
+		LIMIT $%d OFFSET $%d`, argIndex, argIndex+1)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.GTKLeaderboardEntry
+	for rows.Next() {
+		var entry domain.GTKLeaderboardEntry
+		var schoolID *uuid.UUID
+		var schoolName, schoolNPSN *string
+		err := rows.Scan(
+			&entry.Rank, &entry.User.ID, &entry.User.FullName, &entry.User.NIP,
+			&schoolID, &schoolName, &schoolNPSN, &entry.Points, &entry.TalentCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if schoolID != nil {
+			entry.School = &domain.SchoolRef{ID: *schoolID, Name: *schoolName, NPSN: *schoolNPSN}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// SchoolLeaderboard ranks schools by the points of their GTK, in total or,
+// with byAverage, per GTK with at least one approved talent.
+func (r *ScoringRepository) SchoolLeaderboard(ctx context.Context, params domain.ListParams, byAverage bool) ([]domain.SchoolLeaderboardEntry, int, error) {
+	scored, args := scoredTalents(params)
+	rankBy := "SUM(points)"
+	if byAverage {
+		rankBy = "SUM(points) / COUNT(DISTINCT user_id)"
+	}
+	ranked := `
+		WITH scored AS (` + scored + `),
+		ranked AS (
+			SELECT school_id, SUM(points) AS points, SUM(points) / COUNT(DISTINCT user_id) AS average_points,
+				COUNT(DISTINCT user_id) AS gtk_count, COUNT(*) AS talent_count,
+				RANK() OVER (ORDER BY ` + rankBy + ` DESC) AS rank
+			FROM scored
+			WHERE school_id IS NOT NULL
+			GROUP BY school_id
+		)`
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, ranked+` SELECT COUNT(*) FROM ranked`, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	argIndex := len(args) + 1
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := ranked + fmt.Sprintf(`
+		SELECT r.rank, s.id, s.name, s.npsn, s.status, ROUND(r.points, 2)::float8, ROUND(r.average_points, 2)::float8,
+			r.gtk_count, r.talent_count
+		FROM ranked r
+		JOIN schools s ON s.id = r.school_id
+		ORDER BY r.rank, s.name
+		LIMIT $%d OFFSET $%d`, argIndex, argIndex+1)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.SchoolLeaderboardEntry
+	for rows.Next() {
+		var entry domain.SchoolLeaderboardEntry
+		err := rows.Scan(
+			&entry.Rank, &entry.School.ID, &entry.School.Name, &entry.School.NPSN, &entry.Status,
+			&entry.Points, &entry.AveragePoints, &entry.GTKCount, &entry.TalentCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
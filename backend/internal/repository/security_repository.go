@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type SecurityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSecurityRepository(db *pgxpool.Pool) *SecurityRepository {
+	return &SecurityRepository{db: db}
+}
+
+func (r *SecurityRepository) CreateEvent(ctx context.Context, event *domain.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (id, user_id, actor_id, event_type, ip_address, user_agent, detail)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`
+
+	return r.db.QueryRow(ctx, query,
+		event.ID, event.UserID, event.ActorID, event.EventType,
+		event.IPAddress, event.UserAgent, event.Detail,
+	).Scan(&event.CreatedAt)
+}
+
+func (r *SecurityRepository) ListEvents(ctx context.Context, params domain.ListParams) ([]domain.SecurityEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if userID, ok := params.Filters["user_id"]; ok && userID != "" {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, userID)
+		argIndex++
+	}
+
+	if eventType, ok := params.Filters["event_type"]; ok && eventType != "" {
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", argIndex))
+		args = append(args, eventType)
+		argIndex++
+	}
+
+	if dateFrom, ok := params.Filters["date_from"]; ok && dateFrom != "" {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, dateFrom)
+		argIndex++
+	}
+
+	if dateTo, ok := params.Filters["date_to"]; ok && dateTo != "" {
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", argIndex))
+		args = append(args, dateTo)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM security_events %s", whereClause)
+	var total int
+	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	args = append(args, params.Limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, actor_id, event_type, ip_address, user_agent, detail, created_at
+		FROM security_events %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		whereClause, argIndex, argIndex+1,
+	)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []domain.SecurityEvent
+	for rows.Next() {
+		var event domain.SecurityEvent
+		err := rows.Scan(
+			&event.ID, &event.UserID, &event.ActorID, &event.EventType,
+			&event.IPAddress, &event.UserAgent, &event.Detail, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+
+	return events, total, nil
+}
+
+// HasLoginSources reports whether the user has logged in before.
+func (r *SecurityRepository) HasLoginSources(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM login_sources WHERE user_id = $1)`
+	err := r.db.QueryRow(ctx, query, userID).Scan(&exists)
+	return exists, err
+}
+
+// IsKnownLoginSource reports whether both the IP address and the device
+// (user agent) have been seen for this user before.
+func (r *SecurityRepository) IsKnownLoginSource(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) (bool, error) {
+	var knownIP, knownDevice bool
+	query := `
+		SELECT
+			EXISTS(SELECT 1 FROM login_sources WHERE user_id = $1 AND ip_address = $2),
+			EXISTS(SELECT 1 FROM login_sources WHERE user_id = $1 AND user_agent = $3)`
+	err := r.db.QueryRow(ctx, query, userID, ipAddress, userAgent).Scan(&knownIP, &knownDevice)
+	return knownIP && knownDevice, err
+}
+
+func (r *SecurityRepository) TouchLoginSource(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) error {
+	query := `
+		INSERT INTO login_sources (id, user_id, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, ip_address, user_agent) DO UPDATE SET last_seen_at = $5`
+	_, err := r.db.Exec(ctx, query, uuid.New(), userID, ipAddress, userAgent, time.Now())
+	return err
+}
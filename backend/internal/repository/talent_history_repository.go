@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type TalentHistoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTalentHistoryRepository(db *pgxpool.Pool) *TalentHistoryRepository {
+	return &TalentHistoryRepository{db: db}
+}
+
+func (r *TalentHistoryRepository) Create(ctx context.Context, entry *domain.TalentStatusHistory) error {
+	query := `
+		INSERT INTO talent_status_history (id, talent_id, action, from_status, to_status, actor_id, reason, detail_snapshot, batch_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		entry.ID, entry.TalentID, entry.Action, entry.FromStatus, entry.ToStatus,
+		entry.ActorID, entry.Reason, entry.DetailSnapshot, entry.BatchID,
+	).Scan(&entry.CreatedAt)
+}
+
+// ListByTalentID returns the history of a talent, oldest first.
+func (r *TalentHistoryRepository) ListByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentStatusHistory, error) {
+	query := `
+		SELECT id, talent_id, action, from_status, to_status, actor_id, reason, detail_snapshot, batch_id, created_at
+		FROM talent_status_history
+		WHERE talent_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.TalentStatusHistory
+	for rows.Next() {
+		var entry domain.TalentStatusHistory
+		err := rows.Scan(
+			&entry.ID, &entry.TalentID, &entry.Action, &entry.FromStatus, &entry.ToStatus,
+			&entry.ActorID, &entry.Reason, &entry.DetailSnapshot, &entry.BatchID, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -23,23 +25,23 @@ func (r *TalentRepository) Create(ctx context.Context, talent *domain.Talent) er
 	query := `
 		INSERT INTO talents (id, user_id, talent_type, status)
 		VALUES ($1, $2, $3, $4)
-		RETURNING created_at, updated_at`
+		RETURNING queued_at, created_at, updated_at`
 
-	return r.db.QueryRow(ctx, query,
+	return conn(ctx, r.db).QueryRow(ctx, query,
 		talent.ID, talent.UserID, talent.TalentType, talent.Status,
-	).Scan(&talent.CreatedAt, &talent.UpdatedAt)
+	).Scan(&talent.QueuedAt, &talent.CreatedAt, &talent.UpdatedAt)
 }
 
 func (r *TalentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Talent, error) {
 	query := `
-		SELECT id, user_id, talent_type, status, verified_by, verified_at, rejection_reason, created_at, updated_at
+		SELECT id, user_id, talent_type, status, verified_by, verified_at, school_verified_by, school_verified_at, rejection_reason, queued_at, created_at, updated_at
 		FROM talents WHERE id = $1`
 
 	talent := &domain.Talent{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&talent.ID, &talent.UserID, &talent.TalentType, &talent.Status,
-		&talent.VerifiedBy, &talent.VerifiedAt, &talent.RejectionReason,
-		&talent.CreatedAt, &talent.UpdatedAt,
+		&talent.VerifiedBy, &talent.VerifiedAt, &talent.SchoolVerifiedBy, &talent.SchoolVerifiedAt, &talent.RejectionReason,
+		&talent.QueuedAt, &talent.CreatedAt, &talent.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -47,282 +49,379 @@ func (r *TalentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.T
 	return talent, err
 }
 
+// Update saves the verification fields of a talent. A status change moves the
+// talent into a new queue, so queued_at restarts.
 func (r *TalentRepository) Update(ctx context.Context, talent *domain.Talent) error {
 	query := `
-		UPDATE talents SET status = $2, verified_by = $3, verified_at = $4, rejection_reason = $5
+		UPDATE talents SET status = $2, verified_by = $3, verified_at = $4,
+			school_verified_by = $5, school_verified_at = $6, rejection_reason = $7,
+			queued_at = CASE WHEN status IS DISTINCT FROM $2 THEN CURRENT_TIMESTAMP ELSE queued_at END
 		WHERE id = $1
-		RETURNING updated_at`
+		RETURNING queued_at, updated_at`
 
-	return r.db.QueryRow(ctx, query,
-		talent.ID, talent.Status, talent.VerifiedBy, talent.VerifiedAt, talent.RejectionReason,
-	).Scan(&talent.UpdatedAt)
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		talent.ID, talent.Status, talent.VerifiedBy, talent.VerifiedAt,
+		talent.SchoolVerifiedBy, talent.SchoolVerifiedAt, talent.RejectionReason,
+	).Scan(&talent.QueuedAt, &talent.UpdatedAt)
 }
 
 func (r *TalentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM talents WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	return err
 }
 
 func (r *TalentRepository) List(ctx context.Context, params domain.ListParams) ([]domain.Talent, int, error) {
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
-
-	if userID, ok := params.Filters["user_id"]; ok && userID != "" {
-		conditions = append(conditions, fmt.Sprintf("t.user_id = $%d", argIndex))
-		args = append(args, userID)
-		argIndex++
-	}
-
-	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
-		conditions = append(conditions, fmt.Sprintf("u.school_id = $%d", argIndex))
-		args = append(args, schoolID)
-		argIndex++
+	items, total, err := r.ListIncluding(ctx, params, domain.TalentInclude{})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if talentType, ok := params.Filters["talent_type"]; ok && talentType != "" {
-		conditions = append(conditions, fmt.Sprintf("t.talent_type = $%d", argIndex))
-		args = append(args, talentType)
-		argIndex++
+	var talents []domain.Talent
+	for _, item := range items {
+		talents = append(talents, item.Talent)
 	}
+	return talents, total, nil
+}
 
-	if status, ok := params.Filters["status"]; ok && status != "" {
-		conditions = append(conditions, fmt.Sprintf("t.status = $%d", argIndex))
-		args = append(args, status)
-		argIndex++
-	}
-
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
-	}
+// ListIncluding is List with the detail, owner and school of each talent
+// joined into the same query as requested by include.
+func (r *TalentRepository) ListIncluding(ctx context.Context, params domain.ListParams, include domain.TalentInclude) ([]domain.TalentListItem, int, error) {
+	whereClause, args := listWhere(params)
+	argIndex := len(args) + 1
 
 	countQuery := fmt.Sprintf(`
 		SELECT COUNT(*) FROM talents t
 		JOIN users u ON t.user_id = u.id
+		LEFT JOIN verification_queue q ON q.talent_id = t.id
 		%s`, whereClause)
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	// Search results come best match first unless sorted explicitly
 	orderBy := "t.created_at DESC"
-	if params.Sort != "" {
+	switch {
+	case params.Sort != "":
 		if strings.HasPrefix(params.Sort, "-") {
 			orderBy = "t." + strings.TrimPrefix(params.Sort, "-") + " DESC"
 		} else {
 			orderBy = "t." + params.Sort + " ASC"
 		}
+	case params.Search != "":
+		orderBy = fmt.Sprintf("ts_rank_cd(t.search_vector, websearch_to_tsquery('sipodi_search', $%d)) DESC, t.created_at DESC", argIndex)
+		args = append(args, params.Search)
+		argIndex++
 	}
 
 	offset := (params.Page - 1) * params.Limit
 	args = append(args, params.Limit, offset)
 
+	columns := "t.id, t.user_id, t.talent_type, t.status, t.verified_by, t.verified_at, t.school_verified_by, t.school_verified_at, t.rejection_reason, t.queued_at, t.created_at, t.updated_at"
+	joins := ""
+	if include.Detail {
+		columns += ", t.detail"
+	}
+	if include.User {
+		columns += ", u.full_name, u.nip, u.photo_url"
+	}
+	if include.School {
+		columns += ", s.id, s.name, s.npsn"
+		joins = "LEFT JOIN schools s ON s.id = u.school_id"
+	}
+
 	query := fmt.Sprintf(`
-		SELECT t.id, t.user_id, t.talent_type, t.status, t.verified_by, t.verified_at, t.rejection_reason, t.created_at, t.updated_at
+		SELECT %s
 		FROM talents t
 		JOIN users u ON t.user_id = u.id
+		LEFT JOIN verification_queue q ON q.talent_id = t.id
+		%s
 		%s
 		ORDER BY %s
 		LIMIT $%d OFFSET $%d`,
-		whereClause, orderBy, argIndex, argIndex+1,
+		columns, joins, whereClause, orderBy, argIndex, argIndex+1,
 	)
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var talents []domain.Talent
+	var items []domain.TalentListItem
 	for rows.Next() {
-		var talent domain.Talent
-		err := rows.Scan(
-			&talent.ID, &talent.UserID, &talent.TalentType, &talent.Status,
-			&talent.VerifiedBy, &talent.VerifiedAt, &talent.RejectionReason,
-			&talent.CreatedAt, &talent.UpdatedAt,
-		)
-		if err != nil {
+		var item domain.TalentListItem
+		var owner domain.TalentOwner
+		var schoolID *uuid.UUID
+		var schoolName, schoolNPSN *string
+
+		dest := []interface{}{
+			&item.ID, &item.UserID, &item.TalentType, &item.Status,
+			&item.VerifiedBy, &item.VerifiedAt, &item.SchoolVerifiedBy, &item.SchoolVerifiedAt, &item.RejectionReason,
+			&item.QueuedAt, &item.CreatedAt, &item.UpdatedAt,
+		}
+		if include.Detail {
+			dest = append(dest, &item.Detail)
+		}
+		if include.User {
+			dest = append(dest, &owner.FullName, &owner.NIP, &owner.PhotoURL)
+		}
+		if include.School {
+			dest = append(dest, &schoolID, &schoolName, &schoolNPSN)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, 0, err
 		}
-		talents = append(talents, talent)
-	}
 
-	return talents, total, nil
-}
+		if include.User {
+			owner.ID = item.UserID
+			item.Owner = &owner
+		}
+		if schoolID != nil {
+			item.School = &domain.SchoolRef{ID: *schoolID, Name: *schoolName, NPSN: *schoolNPSN}
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-// Training methods
-func (r *TalentRepository) CreateTraining(ctx context.Context, training *domain.TalentTraining) error {
-	query := `
-		INSERT INTO talent_trainings (id, talent_id, activity_name, organizer, start_date, duration_days)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+	if params.Search != "" && len(items) > 0 {
+		if err := r.addHighlights(ctx, items, params.Search); err != nil {
+			return nil, 0, err
+		}
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		training.ID, training.TalentID, training.ActivityName,
-		training.Organizer, training.StartDate, training.DurationDays,
-	)
-	return err
+	return items, total, nil
 }
 
-func (r *TalentRepository) GetTrainingByTalentID(ctx context.Context, talentID uuid.UUID) (*domain.TalentTraining, error) {
+// addHighlights fills in the search snippets of a result page in one query.
+// The text is HTML-escaped and matches are wrapped in <mark> tags.
+func (r *TalentRepository) addHighlights(ctx context.Context, items []domain.TalentListItem, search string) error {
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
 	query := `
-		SELECT id, talent_id, activity_name, organizer, start_date, duration_days
-		FROM talent_trainings WHERE talent_id = $1`
+		SELECT t.id, ts_headline('sipodi_search',
+			replace(replace(replace(u.full_name || ' ' || talent_search_text(t.detail), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+			websearch_to_tsquery('sipodi_search', $2),
+			'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "')
+		FROM talents t
+		JOIN users u ON t.user_id = u.id
+		WHERE t.id = ANY($1)`
 
-	training := &domain.TalentTraining{}
-	err := r.db.QueryRow(ctx, query, talentID).Scan(
-		&training.ID, &training.TalentID, &training.ActivityName,
-		&training.Organizer, &training.StartDate, &training.DurationDays,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, nil
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids, search)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	highlights := make(map[uuid.UUID]string, len(items))
+	for rows.Next() {
+		var id uuid.UUID
+		var highlight string
+		if err := rows.Scan(&id, &highlight); err != nil {
+			return err
+		}
+		highlights[id] = highlight
 	}
-	return training, err
+	for i := range items {
+		if highlight, ok := highlights[items[i].ID]; ok {
+			items[i].Highlight = &highlight
+		}
+	}
+	return rows.Err()
 }
 
-func (r *TalentRepository) UpdateTraining(ctx context.Context, training *domain.TalentTraining) error {
-	query := `
-		UPDATE talent_trainings SET activity_name = $2, organizer = $3, start_date = $4, duration_days = $5
-		WHERE talent_id = $1`
+// CountMatching returns how many talents match the list filters.
+func (r *TalentRepository) CountMatching(ctx context.Context, params domain.ListParams) (int, error) {
+	whereClause, args := listWhere(params)
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) FROM talents t
+		JOIN users u ON t.user_id = u.id
+		LEFT JOIN verification_queue q ON q.talent_id = t.id
+		%s`, whereClause)
 
-	_, err := r.db.Exec(ctx, query,
-		training.TalentID, training.ActivityName, training.Organizer,
-		training.StartDate, training.DurationDays,
-	)
-	return err
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(&total)
+	return total, err
 }
 
-// Mentor methods
-func (r *TalentRepository) CreateMentor(ctx context.Context, mentor *domain.TalentCompetitionMentor) error {
-	query := `
-		INSERT INTO talent_competition_mentors (id, talent_id, competition_name, level, organizer, field, achievement, certificate_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+// ListIDs returns up to limit IDs of talents matching the list filters,
+// longest waiting first.
+func (r *TalentRepository) ListIDs(ctx context.Context, params domain.ListParams, limit int) ([]uuid.UUID, error) {
+	whereClause, args := listWhere(params)
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT t.id FROM talents t
+		JOIN users u ON t.user_id = u.id
+		LEFT JOIN verification_queue q ON q.talent_id = t.id
+		%s
+		ORDER BY t.queued_at ASC, t.id
+		LIMIT $%d`, whereClause, len(args))
 
-	_, err := r.db.Exec(ctx, query,
-		mentor.ID, mentor.TalentID, mentor.CompetitionName, mentor.Level,
-		mentor.Organizer, mentor.Field, mentor.Achievement, mentor.CertificateURL,
-	)
-	return err
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
 
-func (r *TalentRepository) GetMentorByTalentID(ctx context.Context, talentID uuid.UUID) (*domain.TalentCompetitionMentor, error) {
-	query := `
-		SELECT id, talent_id, competition_name, level, organizer, field, achievement, certificate_url
-		FROM talent_competition_mentors WHERE talent_id = $1`
+// listWhere builds the WHERE clause shared by List, Count and ListIDs. It
+// expects talents t joined with users u and verification_queue q.
+func listWhere(params domain.ListParams) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
 
-	mentor := &domain.TalentCompetitionMentor{}
-	err := r.db.QueryRow(ctx, query, talentID).Scan(
-		&mentor.ID, &mentor.TalentID, &mentor.CompetitionName, &mentor.Level,
-		&mentor.Organizer, &mentor.Field, &mentor.Achievement, &mentor.CertificateURL,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, nil
+	if userID, ok := params.Filters["user_id"]; ok && userID != "" {
+		conditions = append(conditions, fmt.Sprintf("t.user_id = $%d", argIndex))
+		args = append(args, userID)
+		argIndex++
 	}
-	return mentor, err
-}
 
-func (r *TalentRepository) UpdateMentor(ctx context.Context, mentor *domain.TalentCompetitionMentor) error {
-	query := `
-		UPDATE talent_competition_mentors SET competition_name = $2, level = $3, organizer = $4, field = $5, achievement = $6, certificate_url = $7
-		WHERE talent_id = $1`
+	if schoolID, ok := params.Filters["school_id"]; ok && schoolID != "" {
+		conditions = append(conditions, fmt.Sprintf("u.school_id = $%d", argIndex))
+		args = append(args, schoolID)
+		argIndex++
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		mentor.TalentID, mentor.CompetitionName, mentor.Level,
-		mentor.Organizer, mentor.Field, mentor.Achievement, mentor.CertificateURL,
-	)
-	return err
-}
+	if talentType, ok := params.Filters["talent_type"]; ok && talentType != "" {
+		conditions = append(conditions, fmt.Sprintf("t.talent_type = $%d", argIndex))
+		args = append(args, talentType)
+		argIndex++
+	}
 
-// Participant methods
-func (r *TalentRepository) CreateParticipant(ctx context.Context, participant *domain.TalentCompetitionParticipant) error {
-	query := `
-		INSERT INTO talent_competition_participants (id, talent_id, competition_name, level, organizer, field, start_date, duration_days, competition_field, achievement, certificate_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	if createdFrom, ok := params.Filters["created_from"]; ok && createdFrom != "" {
+		conditions = append(conditions, fmt.Sprintf("t.created_at >= $%d::date", argIndex))
+		args = append(args, createdFrom)
+		argIndex++
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		participant.ID, participant.TalentID, participant.CompetitionName, participant.Level,
-		participant.Organizer, participant.Field, participant.StartDate, participant.DurationDays,
-		participant.CompetitionField, participant.Achievement, participant.CertificateURL,
-	)
-	return err
-}
+	if createdTo, ok := params.Filters["created_to"]; ok && createdTo != "" {
+		conditions = append(conditions, fmt.Sprintf("t.created_at < $%d::date + 1", argIndex))
+		args = append(args, createdTo)
+		argIndex++
+	}
 
-func (r *TalentRepository) GetParticipantByTalentID(ctx context.Context, talentID uuid.UUID) (*domain.TalentCompetitionParticipant, error) {
-	query := `
-		SELECT id, talent_id, competition_name, level, organizer, field, start_date, duration_days, competition_field, achievement, certificate_url
-		FROM talent_competition_participants WHERE talent_id = $1`
-
-	participant := &domain.TalentCompetitionParticipant{}
-	err := r.db.QueryRow(ctx, query, talentID).Scan(
-		&participant.ID, &participant.TalentID, &participant.CompetitionName, &participant.Level,
-		&participant.Organizer, &participant.Field, &participant.StartDate, &participant.DurationDays,
-		&participant.CompetitionField, &participant.Achievement, &participant.CertificateURL,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, nil
+	if status, ok := params.Filters["status"]; ok && status != "" {
+		conditions = append(conditions, fmt.Sprintf("t.status = $%d", argIndex))
+		args = append(args, status)
+		argIndex++
 	}
-	return participant, err
-}
 
-func (r *TalentRepository) UpdateParticipant(ctx context.Context, participant *domain.TalentCompetitionParticipant) error {
-	query := `
-		UPDATE talent_competition_participants SET competition_name = $2, level = $3, organizer = $4, field = $5, start_date = $6, duration_days = $7, competition_field = $8, achievement = $9, certificate_url = $10
-		WHERE talent_id = $1`
+	if minAge, err := strconv.Atoi(params.Filters["min_age_days"]); err == nil && minAge > 0 {
+		conditions = append(conditions, fmt.Sprintf("t.queued_at <= CURRENT_TIMESTAMP - make_interval(days => $%d)", argIndex))
+		args = append(args, minAge)
+		argIndex++
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		participant.TalentID, participant.CompetitionName, participant.Level,
-		participant.Organizer, participant.Field, participant.StartDate, participant.DurationDays,
-		participant.CompetitionField, participant.Achievement, participant.CertificateURL,
-	)
-	return err
-}
+	// "none" matches talents nobody holds; an expired claim counts as none
+	if assignee, ok := params.Filters["assignee"]; ok && assignee != "" {
+		active := "q.assignee_id IS NOT NULL AND (q.claim_expires_at IS NULL OR q.claim_expires_at > CURRENT_TIMESTAMP)"
+		if assignee == "none" {
+			conditions = append(conditions, "NOT COALESCE("+active+", FALSE)")
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s AND q.assignee_id = $%d", active, argIndex))
+			args = append(args, assignee)
+			argIndex++
+		}
+	}
 
-// Interest methods
-func (r *TalentRepository) CreateInterest(ctx context.Context, interest *domain.TalentInterest) error {
-	query := `
-		INSERT INTO talent_interests (id, talent_id, interest_name, description, certificate_url)
-		VALUES ($1, $2, $3, $4, $5)`
+	if level, ok := params.Filters["level"]; ok && level != "" {
+		conditions = append(conditions, fmt.Sprintf("t.detail->>'level' = $%d", argIndex))
+		args = append(args, level)
+		argIndex++
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		interest.ID, interest.TalentID, interest.InterestName,
-		interest.Description, interest.CertificateURL,
-	)
-	return err
+	for _, kind := range []domain.CatalogKind{domain.CatalogCompetition, domain.CatalogOrganizer} {
+		if entryID, ok := params.Filters[string(kind)+"_id"]; ok && entryID != "" {
+			conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM talent_catalog_links l
+				WHERE l.talent_id = t.id AND l.kind = '%s' AND l.entry_id = $%d)`, kind, argIndex))
+			args = append(args, entryID)
+			argIndex++
+		}
+	}
+
+	// Talents carrying a certificate, see certificate_expiry()
+	switch params.Filters["certificate_status"] {
+	case "expired":
+		conditions = append(conditions, "certificate_expiry(t.detail) < CURRENT_DATE")
+	case "expiring":
+		conditions = append(conditions, fmt.Sprintf(
+			"certificate_expiry(t.detail) BETWEEN CURRENT_DATE AND CURRENT_DATE + $%d::int", argIndex))
+		args = append(args, params.Filters["expiring_within"])
+		argIndex++
+	}
+	if params.Filters["exclude_expired"] == "true" {
+		conditions = append(conditions, "COALESCE(certificate_expiry(t.detail) >= CURRENT_DATE, TRUE)")
+	}
+
+	if params.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("t.search_vector @@ websearch_to_tsquery('sipodi_search', $%d)", argIndex))
+		args = append(args, params.Search)
+		argIndex++
+	}
+
+	// Drafts are private to their owner until submitted
+	if params.Filters["include_drafts"] != "true" {
+		conditions = append(conditions, "t.status <> 'draft'")
+	}
+
+	if duplicate, err := strconv.ParseBool(params.Filters["suspected_duplicate"]); err == nil {
+		exists := "EXISTS (SELECT 1 FROM talent_duplicate_matches d WHERE d.talent_id = t.id)"
+		if !duplicate {
+			exists = "NOT " + exists
+		}
+		conditions = append(conditions, exists)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	return whereClause, args
 }
 
-func (r *TalentRepository) GetInterestByTalentID(ctx context.Context, talentID uuid.UUID) (*domain.TalentInterest, error) {
-	query := `
-		SELECT id, talent_id, interest_name, description, certificate_url
-		FROM talent_interests WHERE talent_id = $1`
+// GetDetail returns the stored detail fields of a talent, or nil when the
+// talent does not exist.
+func (r *TalentRepository) GetDetail(ctx context.Context, talentID uuid.UUID) (json.RawMessage, error) {
+	query := `SELECT detail FROM talents WHERE id = $1`
 
-	interest := &domain.TalentInterest{}
-	err := r.db.QueryRow(ctx, query, talentID).Scan(
-		&interest.ID, &interest.TalentID, &interest.InterestName,
-		&interest.Description, &interest.CertificateURL,
-	)
+	var detail json.RawMessage
+	err := conn(ctx, r.db).QueryRow(ctx, query, talentID).Scan(&detail)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
-	return interest, err
+	return detail, err
 }
 
-func (r *TalentRepository) UpdateInterest(ctx context.Context, interest *domain.TalentInterest) error {
-	query := `
-		UPDATE talent_interests SET interest_name = $2, description = $3, certificate_url = $4
-		WHERE talent_id = $1`
-
-	_, err := r.db.Exec(ctx, query,
-		interest.TalentID, interest.InterestName, interest.Description, interest.CertificateURL,
-	)
+// UpdateDetail overwrites the detail fields of a talent. The detail must
+// already be validated against the talent type schema.
+func (r *TalentRepository) UpdateDetail(ctx context.Context, talentID uuid.UUID, detail json.RawMessage) error {
+	query := `UPDATE talents SET detail = $2 WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID, detail)
 	return err
 }
 
 // Statistics methods
 func (r *TalentRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
-	query := `SELECT status, COUNT(*) FROM talents GROUP BY status`
-	rows, err := r.db.Query(ctx, query)
+	query := `SELECT status, COUNT(*) FROM talents WHERE status <> 'draft' GROUP BY status`
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -341,8 +440,8 @@ func (r *TalentRepository) CountByStatus(ctx context.Context) (map[string]int, e
 }
 
 func (r *TalentRepository) CountByType(ctx context.Context) (map[string]int, error) {
-	query := `SELECT talent_type, COUNT(*) FROM talents GROUP BY talent_type`
-	rows, err := r.db.Query(ctx, query)
+	query := `SELECT talent_type, COUNT(*) FROM talents WHERE status <> 'draft' GROUP BY talent_type`
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -361,14 +460,14 @@ func (r *TalentRepository) CountByType(ctx context.Context) (map[string]int, err
 }
 
 func (r *TalentRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (map[string]int, error) {
-	query := `SELECT status, COUNT(*) FROM talents WHERE user_id = $1 GROUP BY status`
-	rows, err := r.db.Query(ctx, query, userID)
+	query := `SELECT status, COUNT(*) FROM talents WHERE user_id = $1 AND status <> 'draft' GROUP BY status`
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	result := map[string]int{"total": 0, "pending": 0, "approved": 0, "rejected": 0}
+	result := map[string]int{"total": 0, "pending": 0, "school_approved": 0, "needs_revision": 0, "approved": 0, "rejected": 0}
 	for rows.Next() {
 		var status string
 		var count int
@@ -385,15 +484,15 @@ func (r *TalentRepository) CountBySchoolID(ctx context.Context, schoolID uuid.UU
 	query := `
 		SELECT t.status, COUNT(*) FROM talents t
 		JOIN users u ON t.user_id = u.id
-		WHERE u.school_id = $1
+		WHERE u.school_id = $1 AND t.status <> 'draft'
 		GROUP BY t.status`
-	rows, err := r.db.Query(ctx, query, schoolID)
+	rows, err := conn(ctx, r.db).Query(ctx, query, schoolID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	result := map[string]int{"total": 0, "pending": 0, "approved": 0, "rejected": 0}
+	result := map[string]int{"total": 0, "pending": 0, "school_approved": 0, "needs_revision": 0, "approved": 0, "rejected": 0}
 	for rows.Next() {
 		var status string
 		var count int
@@ -412,25 +511,25 @@ func (r *TalentRepository) CountPendingBySchoolID(ctx context.Context, schoolID
 		SELECT COUNT(*) FROM talents t
 		JOIN users u ON t.user_id = u.id
 		WHERE u.school_id = $1 AND t.status = 'pending'`
-	err := r.db.QueryRow(ctx, query, schoolID).Scan(&count)
+	err := conn(ctx, r.db).QueryRow(ctx, query, schoolID).Scan(&count)
 	return count, err
 }
 
 func (r *TalentRepository) Count(ctx context.Context) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM talents`
-	err := r.db.QueryRow(ctx, query).Scan(&count)
+	query := `SELECT COUNT(*) FROM talents WHERE status <> 'draft'`
+	err := conn(ctx, r.db).QueryRow(ctx, query).Scan(&count)
 	return count, err
 }
 
 func (r *TalentRepository) GetRecentByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]domain.Talent, error) {
 	query := `
-		SELECT id, user_id, talent_type, status, verified_by, verified_at, rejection_reason, created_at, updated_at
+		SELECT id, user_id, talent_type, status, verified_by, verified_at, school_verified_by, school_verified_at, rejection_reason, queued_at, created_at, updated_at
 		FROM talents WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2`
 
-	rows, err := r.db.Query(ctx, query, userID, limit)
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -441,8 +540,8 @@ func (r *TalentRepository) GetRecentByUserID(ctx context.Context, userID uuid.UU
 		var talent domain.Talent
 		err := rows.Scan(
 			&talent.ID, &talent.UserID, &talent.TalentType, &talent.Status,
-			&talent.VerifiedBy, &talent.VerifiedAt, &talent.RejectionReason,
-			&talent.CreatedAt, &talent.UpdatedAt,
+			&talent.VerifiedBy, &talent.VerifiedAt, &talent.SchoolVerifiedBy, &talent.SchoolVerifiedAt, &talent.RejectionReason,
+			&talent.QueuedAt, &talent.CreatedAt, &talent.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -454,16 +553,34 @@ func (r *TalentRepository) GetRecentByUserID(ctx context.Context, userID uuid.UU
 
 func (r *TalentRepository) ResetStatus(ctx context.Context, talentID uuid.UUID) error {
 	query := `
-		UPDATE talents SET status = 'pending', verified_by = NULL, verified_at = NULL, rejection_reason = NULL
+		UPDATE talents SET status = 'pending', verified_by = NULL, verified_at = NULL,
+			school_verified_by = NULL, school_verified_at = NULL, rejection_reason = NULL,
+			queued_at = CURRENT_TIMESTAMP
 		WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, talentID)
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID)
 	return err
 }
 
-func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, schoolID *string, dateFrom, dateTo string) (map[string]interface{}, error) {
+// GetCompetitionLevel returns the competition level in the talent detail, or
+// nil for talent types without a level.
+func (r *TalentRepository) GetCompetitionLevel(ctx context.Context, talentID uuid.UUID) (*domain.CompetitionLevel, error) {
+	query := `SELECT detail->>'level' FROM talents WHERE id = $1`
+
+	var level *domain.CompetitionLevel
+	err := conn(ctx, r.db).QueryRow(ctx, query, talentID).Scan(&level)
+	return level, err
+}
+
+// GetStatistics counts talents by groupBy. With excludeExpired, talents whose
+// certificate has expired are left out.
+func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, schoolID *string, dateFrom, dateTo string, excludeExpired bool) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
-	var conditions []string
+	// Drafts have not been submitted and are never counted
+	conditions := []string{"t.status <> 'draft'"}
+	if excludeExpired {
+		conditions = append(conditions, "COALESCE(certificate_expiry(t.detail) >= CURRENT_DATE, TRUE)")
+	}
 	var args []interface{}
 	argIndex := 1
 
@@ -498,7 +615,7 @@ func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, sc
 			JOIN users u ON t.user_id = u.id
 			%s
 			GROUP BY t.talent_type`, whereClause)
-		rows, err := r.db.Query(ctx, query, args...)
+		rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -522,7 +639,7 @@ func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, sc
 			JOIN users u ON t.user_id = u.id
 			%s
 			GROUP BY t.status`, whereClause)
-		rows, err := r.db.Query(ctx, query, args...)
+		rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -540,24 +657,19 @@ func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, sc
 		result["by_status"] = data
 
 	case "level":
-		// Get from competition mentors and participants
+		// Any talent type whose detail carries a level
+		condition := "WHERE t.detail ? 'level'"
+		if whereClause != "" {
+			condition = whereClause + " AND t.detail ? 'level'"
+		}
 		query := fmt.Sprintf(`
-			SELECT level, COUNT(*) FROM (
-				SELECT tcm.level FROM talent_competition_mentors tcm
-				JOIN talents t ON tcm.talent_id = t.id
-				JOIN users u ON t.user_id = u.id
-				%s
-				UNION ALL
-				SELECT tcp.level FROM talent_competition_participants tcp
-				JOIN talents t ON tcp.talent_id = t.id
-				JOIN users u ON t.user_id = u.id
-				%s
-			) combined
-			GROUP BY level`, whereClause, whereClause)
-
-		// Double the args for UNION ALL
-		allArgs := append(args, args...)
-		rows, err := r.db.Query(ctx, query, allArgs...)
+			SELECT t.detail->>'level', COUNT(*)
+			FROM talents t
+			JOIN users u ON t.user_id = u.id
+			%s
+			GROUP BY 1`, condition)
+
+		rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -574,23 +686,28 @@ func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, sc
 		}
 		result["by_level"] = data
 
+	case "competition", "organizer":
+		data, uncataloged, err := r.catalogStatistics(ctx, domain.CatalogKind(groupBy), whereClause, args)
+		if err != nil {
+			return nil, err
+		}
+		result["by_"+groupBy] = data
+		result["uncataloged"] = uncataloged
+
 	case "field":
+		// Any talent type whose detail carries a field
+		condition := "WHERE t.detail ? 'field'"
+		if whereClause != "" {
+			condition = whereClause + " AND t.detail ? 'field'"
+		}
 		query := fmt.Sprintf(`
-			SELECT field, COUNT(*) FROM (
-				SELECT tcm.field FROM talent_competition_mentors tcm
-				JOIN talents t ON tcm.talent_id = t.id
-				JOIN users u ON t.user_id = u.id
-				%s
-				UNION ALL
-				SELECT tcp.field FROM talent_competition_participants tcp
-				JOIN talents t ON tcp.talent_id = t.id
-				JOIN users u ON t.user_id = u.id
-				%s
-			) combined
-			GROUP BY field`, whereClause, whereClause)
-
-		allArgs := append(args, args...)
-		rows, err := r.db.Query(ctx, query, allArgs...)
+			SELECT t.detail->>'field', COUNT(*)
+			FROM talents t
+			JOIN users u ON t.user_id = u.id
+			%s
+			GROUP BY 1`, condition)
+
+		rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -610,3 +727,86 @@ func (r *TalentRepository) GetStatistics(ctx context.Context, groupBy string, sc
 
 	return result, nil
 }
+
+// catalogStatistics counts talents per catalog entry of a kind, keyed by the
+// entry name, together with the talents whose name the catalog does not know
+// yet.
+func (r *TalentRepository) catalogStatistics(ctx context.Context, kind domain.CatalogKind, whereClause string, args []interface{}) (map[string]int, int, error) {
+	field := "d.match_organizer_field"
+	if kind == domain.CatalogCompetition {
+		field = "d.catalog_competition_field"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT e.name, COUNT(*)
+		FROM talents t
+		JOIN users u ON t.user_id = u.id
+		JOIN talent_catalog_links l ON l.talent_id = t.id AND l.kind = '%s'
+		JOIN catalog_entries e ON e.id = l.entry_id
+		%s
+		GROUP BY e.id, e.name`, kind, whereClause)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	data := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, 0, err
+		}
+		data[name] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	condition := fmt.Sprintf("WHERE l.talent_id IS NULL AND catalog_normalize(t.detail->>%s) IS NOT NULL", field)
+	if whereClause != "" {
+		condition = whereClause + strings.Replace(condition, "WHERE", " AND", 1)
+	}
+	uncatalogedQuery := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM talents t
+		JOIN users u ON t.user_id = u.id
+		JOIN talent_type_definitions d ON d.code = t.talent_type
+		LEFT JOIN talent_catalog_links l ON l.talent_id = t.id AND l.kind = '%s'
+		%s`, kind, condition)
+
+	var uncataloged int
+	if err := conn(ctx, r.db).QueryRow(ctx, uncatalogedQuery, args...).Scan(&uncataloged); err != nil {
+		return nil, 0, err
+	}
+	return data, uncataloged, nil
+}
+
+// GetCatalogLinks returns the catalog entries a talent's competition and
+// organizer names resolve to, keyed by kind.
+func (r *TalentRepository) GetCatalogLinks(ctx context.Context, talentID uuid.UUID) (map[domain.CatalogKind]domain.CatalogRef, error) {
+	query := `
+		SELECT l.kind, e.id, e.name
+		FROM talent_catalog_links l
+		JOIN catalog_entries e ON e.id = l.entry_id
+		WHERE l.talent_id = $1`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(map[domain.CatalogKind]domain.CatalogRef)
+	for rows.Next() {
+		var kind domain.CatalogKind
+		var ref domain.CatalogRef
+		if err := rows.Scan(&kind, &ref.ID, &ref.Name); err != nil {
+			return nil, err
+		}
+		links[kind] = ref
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type TalentTypeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTalentTypeRepository(db *pgxpool.Pool) *TalentTypeRepository {
+	return &TalentTypeRepository{db: db}
+}
+
+const talentTypeColumns = `code, name, description, detail_schema, match_name_field, match_organizer_field,
+	match_date_field, catalog_competition_field, is_builtin, is_active, created_at, updated_at`
+
+func scanTalentType(row pgx.Row) (*domain.TalentTypeDefinition, error) {
+	def := &domain.TalentTypeDefinition{}
+	err := row.Scan(
+		&def.Code, &def.Name, &def.Description, &def.DetailSchema, &def.MatchNameField, &def.MatchOrganizerField,
+		&def.MatchDateField, &def.CatalogCompetitionField, &def.IsBuiltin, &def.IsActive, &def.CreatedAt, &def.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// List returns the registered talent types, built-in types first.
+func (r *TalentTypeRepository) List(ctx context.Context, activeOnly bool) ([]domain.TalentTypeDefinition, error) {
+	query := `SELECT ` + talentTypeColumns + ` FROM talent_type_definitions`
+	if activeOnly {
+		query += ` WHERE is_active`
+	}
+	query += ` ORDER BY is_builtin DESC, name ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defs []domain.TalentTypeDefinition
+	for rows.Next() {
+		def, err := scanTalentType(rows)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, *def)
+	}
+	return defs, nil
+}
+
+func (r *TalentTypeRepository) GetByCode(ctx context.Context, code domain.TalentType) (*domain.TalentTypeDefinition, error) {
+	query := `SELECT ` + talentTypeColumns + ` FROM talent_type_definitions WHERE code = $1`
+	return scanTalentType(conn(ctx, r.db).QueryRow(ctx, query, code))
+}
+
+func (r *TalentTypeRepository) Create(ctx context.Context, def *domain.TalentTypeDefinition) error {
+	query := `
+		INSERT INTO talent_type_definitions (code, name, description, detail_schema, match_name_field,
+			match_organizer_field, match_date_field, catalog_competition_field, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING is_builtin, created_at, updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		def.Code, def.Name, def.Description, def.DetailSchema, def.MatchNameField,
+		def.MatchOrganizerField, def.MatchDateField, def.CatalogCompetitionField, def.IsActive,
+	).Scan(&def.IsBuiltin, &def.CreatedAt, &def.UpdatedAt)
+}
+
+func (r *TalentTypeRepository) Update(ctx context.Context, def *domain.TalentTypeDefinition) error {
+	query := `
+		UPDATE talent_type_definitions SET name = $2, description = $3, detail_schema = $4,
+			match_name_field = $5, match_organizer_field = $6, match_date_field = $7,
+			catalog_competition_field = $8, is_active = $9
+		WHERE code = $1
+		RETURNING updated_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		def.Code, def.Name, def.Description, def.DetailSchema,
+		def.MatchNameField, def.MatchOrganizerField, def.MatchDateField, def.CatalogCompetitionField, def.IsActive,
+	).Scan(&def.UpdatedAt)
+}
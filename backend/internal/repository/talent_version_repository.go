@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type TalentVersionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTalentVersionRepository(db *pgxpool.Pool) *TalentVersionRepository {
+	return &TalentVersionRepository{db: db}
+}
+
+// Create stores the next version number of the talent.
+func (r *TalentVersionRepository) Create(ctx context.Context, version *domain.TalentVersion) error {
+	query := `
+		INSERT INTO talent_versions (id, talent_id, version, detail, created_by)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(version), 0) + 1 FROM talent_versions WHERE talent_id = $2), $3, $4)
+		RETURNING version, created_at`
+
+	return conn(ctx, r.db).QueryRow(ctx, query,
+		version.ID, version.TalentID, version.Detail, version.CreatedBy,
+	).Scan(&version.Version, &version.CreatedAt)
+}
+
+func (r *TalentVersionRepository) ListByTalentID(ctx context.Context, talentID uuid.UUID) ([]domain.TalentVersion, error) {
+	query := `
+		SELECT id, talent_id, version, detail, created_by, approved_at, created_at
+		FROM talent_versions
+		WHERE talent_id = $1
+		ORDER BY version ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []domain.TalentVersion
+	for rows.Next() {
+		var version domain.TalentVersion
+		err := rows.Scan(
+			&version.ID, &version.TalentID, &version.Version, &version.Detail,
+			&version.CreatedBy, &version.ApprovedAt, &version.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+func (r *TalentVersionRepository) GetByVersion(ctx context.Context, talentID uuid.UUID, number int) (*domain.TalentVersion, error) {
+	return r.get(ctx, `WHERE talent_id = $1 AND version = $2`, talentID, number)
+}
+
+func (r *TalentVersionRepository) GetLatest(ctx context.Context, talentID uuid.UUID) (*domain.TalentVersion, error) {
+	return r.get(ctx, `WHERE talent_id = $1 ORDER BY version DESC LIMIT 1`, talentID)
+}
+
+// GetLastApproved returns the most recent version that was approved.
+func (r *TalentVersionRepository) GetLastApproved(ctx context.Context, talentID uuid.UUID) (*domain.TalentVersion, error) {
+	return r.get(ctx, `WHERE talent_id = $1 AND approved_at IS NOT NULL ORDER BY version DESC LIMIT 1`, talentID)
+}
+
+// MarkLatestApproved flags the current version as the approved one.
+func (r *TalentVersionRepository) MarkLatestApproved(ctx context.Context, talentID uuid.UUID) error {
+	query := `
+		UPDATE talent_versions SET approved_at = $2
+		WHERE talent_id = $1
+		AND version = (SELECT MAX(version) FROM talent_versions WHERE talent_id = $1)`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID, time.Now())
+	return err
+}
+
+// ListLatest returns the latest version of each given talent, keyed by
+// talent.
+func (r *TalentVersionRepository) ListLatest(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID]*domain.TalentVersion, error) {
+	return r.list(ctx, `WHERE talent_id = ANY($1)`, talentIDs)
+}
+
+// ListLastApproved returns the most recently approved version of each given
+// talent that has one, keyed by talent.
+func (r *TalentVersionRepository) ListLastApproved(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID]*domain.TalentVersion, error) {
+	return r.list(ctx, `WHERE talent_id = ANY($1) AND approved_at IS NOT NULL`, talentIDs)
+}
+
+// list returns the highest version per talent among the rows matching clause.
+func (r *TalentVersionRepository) list(ctx context.Context, clause string, talentIDs []uuid.UUID) (map[uuid.UUID]*domain.TalentVersion, error) {
+	query := `
+		SELECT DISTINCT ON (talent_id) id, talent_id, version, detail, created_by, approved_at, created_at
+		FROM talent_versions ` + clause + `
+		ORDER BY talent_id, version DESC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[uuid.UUID]*domain.TalentVersion)
+	for rows.Next() {
+		version := &domain.TalentVersion{}
+		err := rows.Scan(
+			&version.ID, &version.TalentID, &version.Version, &version.Detail,
+			&version.CreatedBy, &version.ApprovedAt, &version.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		versions[version.TalentID] = version
+	}
+	return versions, rows.Err()
+}
+
+func (r *TalentVersionRepository) get(ctx context.Context, clause string, args ...interface{}) (*domain.TalentVersion, error) {
+	query := `
+		SELECT id, talent_id, version, detail, created_by, approved_at, created_at
+		FROM talent_versions ` + clause
+
+	version := &domain.TalentVersion{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(
+		&version.ID, &version.TalentID, &version.Version, &version.Detail,
+		&version.CreatedBy, &version.ApprovedAt, &version.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return version, err
+}
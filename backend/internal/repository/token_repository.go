@@ -29,15 +29,16 @@ func (r *TokenRepository) Create(ctx context.Context, token *domain.RefreshToken
 	).Scan(&token.CreatedAt)
 }
 
+// GetByHash also returns revoked tokens so callers can detect reuse.
 func (r *TokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token_hash, expires_at, created_at
+		SELECT id, user_id, token_hash, expires_at, revoked_at, created_at
 		FROM refresh_tokens
 		WHERE token_hash = $1 AND expires_at > $2`
 
 	token := &domain.RefreshToken{}
 	err := r.db.QueryRow(ctx, query, tokenHash, time.Now()).Scan(
-		&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt,
+		&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -45,6 +46,12 @@ func (r *TokenRepository) GetByHash(ctx context.Context, tokenHash string) (*dom
 	return token, err
 }
 
+func (r *TokenRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, id, time.Now())
+	return err
+}
+
 func (r *TokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM refresh_tokens WHERE id = $1`
 	_, err := r.db.Exec(ctx, query, id)
@@ -52,7 +59,7 @@ func (r *TokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (r *TokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
-	query := `DELETE FROM refresh_tokens WHERE user_id = $1`
+	query := `DELETE FROM refresh_tokens WHERE user_id = $1 AND revoked_at IS NULL`
 	result, err := r.db.Exec(ctx, query, userID)
 	if err != nil {
 		return 0, err
@@ -68,7 +75,7 @@ func (r *TokenRepository) DeleteExpired(ctx context.Context) error {
 
 func (r *TokenRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1 AND expires_at > $2`
+	query := `SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1 AND expires_at > $2 AND revoked_at IS NULL`
 	err := r.db.QueryRow(ctx, query, userID, time.Now()).Scan(&count)
 	return count, err
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -25,7 +26,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING created_at, updated_at`
 
-	return r.db.QueryRow(ctx, query,
+	return conn(ctx, r.db).QueryRow(ctx, query,
 		user.ID, user.Email, user.PasswordHash, user.Role, user.FullName,
 		user.PhotoURL, user.NUPTK, user.NIP, user.Gender, user.BirthDate,
 		user.GTKType, user.Position, user.SchoolID, user.IsActive,
@@ -34,14 +35,14 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, created_at, updated_at
+		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, email_verified_at, created_at, updated_at
 		FROM users WHERE id = $1`
 
 	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.FullName,
 		&user.PhotoURL, &user.NUPTK, &user.NIP, &user.Gender, &user.BirthDate,
-		&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive,
+		&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive, &user.EmailVerifiedAt,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
@@ -50,16 +51,45 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	return user, err
 }
 
+// ListByIDs returns the given users that exist, keyed by ID.
+func (r *UserRepository) ListByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.User, error) {
+	query := `
+		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, email_verified_at, created_at, updated_at
+		FROM users WHERE id = ANY($1)`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[uuid.UUID]*domain.User)
+	for rows.Next() {
+		user := &domain.User{}
+		err := rows.Scan(
+			&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.FullName,
+			&user.PhotoURL, &user.NUPTK, &user.NIP, &user.Gender, &user.BirthDate,
+			&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive, &user.EmailVerifiedAt,
+			&user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, rows.Err()
+}
+
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, created_at, updated_at
+		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, email_verified_at, created_at, updated_at
 		FROM users WHERE email = $1`
 
 	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, email).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.FullName,
 		&user.PhotoURL, &user.NUPTK, &user.NIP, &user.Gender, &user.BirthDate,
-		&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive,
+		&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive, &user.EmailVerifiedAt,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
@@ -72,26 +102,41 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users SET
 			full_name = $2, photo_url = $3, nuptk = $4, nip = $5, gender = $6,
-			birth_date = $7, gtk_type = $8, position = $9, school_id = $10, is_active = $11
+			birth_date = $7, gtk_type = $8, position = $9, school_id = $10, is_active = $11,
+			role = $12
 		WHERE id = $1
 		RETURNING updated_at`
 
-	return r.db.QueryRow(ctx, query,
+	return conn(ctx, r.db).QueryRow(ctx, query,
 		user.ID, user.FullName, user.PhotoURL, user.NUPTK, user.NIP,
 		user.Gender, user.BirthDate, user.GTKType, user.Position,
-		user.SchoolID, user.IsActive,
+		user.SchoolID, user.IsActive, user.Role,
 	).Scan(&user.UpdatedAt)
 }
 
 func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
 	query := `UPDATE users SET password_hash = $2 WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id, passwordHash)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, passwordHash)
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	query := `UPDATE users SET email_verified_at = $2 WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, verifiedAt)
+	return err
+}
+
+// UpdateEmail replaces the login email. Only called once the new address has
+// been confirmed, so it is stored as verified.
+func (r *UserRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string, verifiedAt time.Time) error {
+	query := `UPDATE users SET email = $2, email_verified_at = $3 WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, email, verifiedAt)
 	return err
 }
 
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	return err
 }
 
@@ -133,6 +178,14 @@ func (r *UserRepository) List(ctx context.Context, params domain.ListParams) ([]
 		argIndex++
 	}
 
+	if emailVerified, ok := params.Filters["email_verified"]; ok && emailVerified != "" {
+		if emailVerified == "true" {
+			conditions = append(conditions, "email_verified_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "email_verified_at IS NULL")
+		}
+	}
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
@@ -141,7 +194,7 @@ func (r *UserRepository) List(ctx context.Context, params domain.ListParams) ([]
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause)
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -161,14 +214,14 @@ func (r *UserRepository) List(ctx context.Context, params domain.ListParams) ([]
 	args = append(args, params.Limit, offset)
 
 	query := fmt.Sprintf(`
-		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, created_at, updated_at
+		SELECT id, email, password_hash, role, full_name, photo_url, nuptk, nip, gender, birth_date, gtk_type, position, school_id, is_active, email_verified_at, created_at, updated_at
 		FROM users %s
 		ORDER BY %s
 		LIMIT $%d OFFSET $%d`,
 		whereClause, orderBy, argIndex, argIndex+1,
 	)
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -180,7 +233,7 @@ func (r *UserRepository) List(ctx context.Context, params domain.ListParams) ([]
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.FullName,
 			&user.PhotoURL, &user.NUPTK, &user.NIP, &user.Gender, &user.BirthDate,
-			&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive,
+			&user.GTKType, &user.Position, &user.SchoolID, &user.IsActive, &user.EmailVerifiedAt,
 			&user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
@@ -195,48 +248,90 @@ func (r *UserRepository) List(ctx context.Context, params domain.ListParams) ([]
 func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
-	err := r.db.QueryRow(ctx, query, email).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, query, email).Scan(&exists)
 	return exists, err
 }
 
 func (r *UserRepository) ExistsByNUPTK(ctx context.Context, nuptk string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE nuptk = $1)`
-	err := r.db.QueryRow(ctx, query, nuptk).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, query, nuptk).Scan(&exists)
 	return exists, err
 }
 
 func (r *UserRepository) ExistsByNIP(ctx context.Context, nip string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE nip = $1)`
-	err := r.db.QueryRow(ctx, query, nip).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, query, nip).Scan(&exists)
 	return exists, err
 }
 
 func (r *UserRepository) CountBySchool(ctx context.Context, schoolID uuid.UUID) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM users WHERE school_id = $1`
-	err := r.db.QueryRow(ctx, query, schoolID).Scan(&count)
+	err := conn(ctx, r.db).QueryRow(ctx, query, schoolID).Scan(&count)
 	return count, err
 }
 
 func (r *UserRepository) CountBySchoolAndType(ctx context.Context, schoolID uuid.UUID, gtkType domain.GTKType) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM users WHERE school_id = $1 AND gtk_type = $2`
-	err := r.db.QueryRow(ctx, query, schoolID, gtkType).Scan(&count)
+	err := conn(ctx, r.db).QueryRow(ctx, query, schoolID, gtkType).Scan(&count)
 	return count, err
 }
 
+// ListActiveIDsByRole returns the IDs of active users with the given role,
+// in id order.
+func (r *UserRepository) ListActiveIDsByRole(ctx context.Context, role domain.UserRole) ([]uuid.UUID, error) {
+	query := `SELECT id FROM users WHERE role = $1 AND is_active = true ORDER BY id`
+	rows, err := conn(ctx, r.db).Query(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ListActiveIDsBySchoolAndRole returns the IDs of active users with the
+// given role at one school, in id order.
+func (r *UserRepository) ListActiveIDsBySchoolAndRole(ctx context.Context, schoolID uuid.UUID, role domain.UserRole) ([]uuid.UUID, error) {
+	query := `SELECT id FROM users WHERE school_id = $1 AND role = $2 AND is_active = true ORDER BY id`
+	rows, err := conn(ctx, r.db).Query(ctx, query, schoolID, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func (r *UserRepository) CountByRole(ctx context.Context, role domain.UserRole) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM users WHERE role = $1`
-	err := r.db.QueryRow(ctx, query, role).Scan(&count)
+	err := conn(ctx, r.db).QueryRow(ctx, query, role).Scan(&count)
 	return count, err
 }
 
 func (r *UserRepository) CountByGTKType(ctx context.Context) (map[string]int, error) {
 	query := `SELECT gtk_type, COUNT(*) FROM users WHERE gtk_type IS NOT NULL GROUP BY gtk_type`
-	rows, err := r.db.Query(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+type VerificationQueueRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewVerificationQueueRepository(db *pgxpool.Pool) *VerificationQueueRepository {
+	return &VerificationQueueRepository{db: db}
+}
+
+func (r *VerificationQueueRepository) GetByTalentID(ctx context.Context, talentID uuid.UUID) (*domain.QueueEntry, error) {
+	query := `
+		SELECT talent_id, assignee_id, assigned_by, assigned_at, claim_expires_at, reminded_at, escalated_at
+		FROM verification_queue WHERE talent_id = $1`
+
+	entry := &domain.QueueEntry{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, talentID).Scan(
+		&entry.TalentID, &entry.AssigneeID, &entry.AssignedBy, &entry.AssignedAt,
+		&entry.ClaimExpiresAt, &entry.RemindedAt, &entry.EscalatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// ListByTalentIDs returns the queue entries of the given talents that have
+// one, keyed by talent.
+func (r *VerificationQueueRepository) ListByTalentIDs(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID]*domain.QueueEntry, error) {
+	query := `
+		SELECT talent_id, assignee_id, assigned_by, assigned_at, claim_expires_at, reminded_at, escalated_at
+		FROM verification_queue WHERE talent_id = ANY($1)`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, talentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make(map[uuid.UUID]*domain.QueueEntry)
+	for rows.Next() {
+		entry := &domain.QueueEntry{}
+		err := rows.Scan(
+			&entry.TalentID, &entry.AssigneeID, &entry.AssignedBy, &entry.AssignedAt,
+			&entry.ClaimExpiresAt, &entry.RemindedAt, &entry.EscalatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries[entry.TalentID] = entry
+	}
+	return entries, rows.Err()
+}
+
+// Claim locks the talent for userID until expiresAt. It returns nil when
+// another user holds an explicit assignment or an unexpired claim. Claiming
+// a talent already assigned to the same user keeps the assignment as is.
+func (r *VerificationQueueRepository) Claim(ctx context.Context, talentID uuid.UUID, userID uuid.UUID, expiresAt time.Time) (*domain.QueueEntry, error) {
+	query := `
+		INSERT INTO verification_queue (talent_id, assignee_id, assigned_by, assigned_at, claim_expires_at)
+		VALUES ($1, $2, $2, CURRENT_TIMESTAMP, $3)
+		ON CONFLICT (talent_id) DO UPDATE SET
+			assignee_id = EXCLUDED.assignee_id,
+			assigned_by = CASE WHEN verification_queue.assignee_id = EXCLUDED.assignee_id
+				THEN verification_queue.assigned_by ELSE EXCLUDED.assigned_by END,
+			assigned_at = CASE WHEN verification_queue.assignee_id = EXCLUDED.assignee_id
+				THEN verification_queue.assigned_at ELSE EXCLUDED.assigned_at END,
+			claim_expires_at = CASE WHEN verification_queue.assignee_id = EXCLUDED.assignee_id
+				AND verification_queue.claim_expires_at IS NULL
+				THEN NULL ELSE EXCLUDED.claim_expires_at END
+		WHERE verification_queue.assignee_id IS NULL
+			OR verification_queue.assignee_id = EXCLUDED.assignee_id
+			OR verification_queue.claim_expires_at <= CURRENT_TIMESTAMP
+		RETURNING talent_id, assignee_id, assigned_by, assigned_at, claim_expires_at, reminded_at, escalated_at`
+
+	entry := &domain.QueueEntry{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, talentID, userID, expiresAt).Scan(
+		&entry.TalentID, &entry.AssigneeID, &entry.AssignedBy, &entry.AssignedAt,
+		&entry.ClaimExpiresAt, &entry.RemindedAt, &entry.EscalatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// Assign gives the talent to assigneeID without an expiry, replacing any
+// claim or earlier assignment.
+func (r *VerificationQueueRepository) Assign(ctx context.Context, talentID uuid.UUID, assigneeID uuid.UUID, assignedBy uuid.UUID) (*domain.QueueEntry, error) {
+	query := `
+		INSERT INTO verification_queue (talent_id, assignee_id, assigned_by, assigned_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (talent_id) DO UPDATE SET
+			assignee_id = EXCLUDED.assignee_id,
+			assigned_by = EXCLUDED.assigned_by,
+			assigned_at = EXCLUDED.assigned_at,
+			claim_expires_at = NULL
+		RETURNING talent_id, assignee_id, assigned_by, assigned_at, claim_expires_at, reminded_at, escalated_at`
+
+	entry := &domain.QueueEntry{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, talentID, assigneeID, assignedBy).Scan(
+		&entry.TalentID, &entry.AssigneeID, &entry.AssignedBy, &entry.AssignedAt,
+		&entry.ClaimExpiresAt, &entry.RemindedAt, &entry.EscalatedAt,
+	)
+	return entry, err
+}
+
+// Release drops the assignee but keeps the SLA notices already sent.
+func (r *VerificationQueueRepository) Release(ctx context.Context, talentID uuid.UUID) error {
+	query := `
+		UPDATE verification_queue SET assignee_id = NULL, assigned_by = NULL,
+			assigned_at = NULL, claim_expires_at = NULL
+		WHERE talent_id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID)
+	return err
+}
+
+// Clear removes all queue state of a talent, used when it leaves its queue.
+func (r *VerificationQueueRepository) Clear(ctx context.Context, talentID uuid.UUID) error {
+	query := `DELETE FROM verification_queue WHERE talent_id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID)
+	return err
+}
+
+func (r *VerificationQueueRepository) MarkReminded(ctx context.Context, talentID uuid.UUID) error {
+	query := `
+		INSERT INTO verification_queue (talent_id, reminded_at) VALUES ($1, CURRENT_TIMESTAMP)
+		ON CONFLICT (talent_id) DO UPDATE SET reminded_at = EXCLUDED.reminded_at`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID)
+	return err
+}
+
+func (r *VerificationQueueRepository) MarkEscalated(ctx context.Context, talentID uuid.UUID) error {
+	query := `
+		INSERT INTO verification_queue (talent_id, escalated_at) VALUES ($1, CURRENT_TIMESTAMP)
+		ON CONFLICT (talent_id) DO UPDATE SET escalated_at = EXCLUDED.escalated_at`
+	_, err := conn(ctx, r.db).Exec(ctx, query, talentID)
+	return err
+}
+
+// ListUnreminded returns talents waiting since before cutoff that have not
+// had a reminder yet.
+func (r *VerificationQueueRepository) ListUnreminded(ctx context.Context, cutoff time.Time) ([]domain.QueuedTalent, error) {
+	return r.listWaiting(ctx, `q.reminded_at IS NULL`, cutoff)
+}
+
+// ListUnescalated returns talents waiting since before cutoff that have not
+// been escalated yet.
+func (r *VerificationQueueRepository) ListUnescalated(ctx context.Context, cutoff time.Time) ([]domain.QueuedTalent, error) {
+	return r.listWaiting(ctx, `q.escalated_at IS NULL`, cutoff)
+}
+
+// ListWaiting returns every talent waiting for verification, oldest first.
+func (r *VerificationQueueRepository) ListWaiting(ctx context.Context) ([]domain.QueuedTalent, error) {
+	return r.listWaiting(ctx, `TRUE`, time.Now())
+}
+
+func (r *VerificationQueueRepository) listWaiting(ctx context.Context, condition string, cutoff time.Time) ([]domain.QueuedTalent, error) {
+	query := `
+		SELECT t.id, t.status, u.school_id,
+			CASE WHEN q.claim_expires_at IS NULL OR q.claim_expires_at > CURRENT_TIMESTAMP
+				THEN q.assignee_id END,
+			t.queued_at
+		FROM talents t
+		JOIN users u ON t.user_id = u.id
+		LEFT JOIN verification_queue q ON q.talent_id = t.id
+		WHERE t.status IN ('pending', 'school_approved') AND t.queued_at <= $1
+			AND (q.talent_id IS NULL OR ` + condition + `)
+		ORDER BY t.queued_at ASC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var talents []domain.QueuedTalent
+	for rows.Next() {
+		var talent domain.QueuedTalent
+		if err := rows.Scan(&talent.TalentID, &talent.Status, &talent.SchoolID, &talent.AssigneeID, &talent.QueuedAt); err != nil {
+			return nil, err
+		}
+		talents = append(talents, talent)
+	}
+	return talents, nil
+}
@@ -18,6 +18,14 @@ type Router struct {
 	uploadHandler       *handler.UploadHandler
 	dashboardHandler    *handler.DashboardHandler
 	exportHandler       *handler.ExportHandler
+	registrationHandler *handler.RegistrationHandler
+	securityHandler     *handler.SecurityHandler
+	talentTypeHandler   *handler.TalentTypeHandler
+	leaderboardHandler  *handler.LeaderboardHandler
+	catalogHandler      *handler.CatalogHandler
+	reportHandler       *handler.ReportHandler
+	auditHandler        *handler.AuditHandler
+	announcementHandler *handler.AnnouncementHandler
 	authService         *service.AuthService
 }
 
@@ -31,6 +39,14 @@ func NewRouter(
 	uploadHandler *handler.UploadHandler,
 	dashboardHandler *handler.DashboardHandler,
 	exportHandler *handler.ExportHandler,
+	registrationHandler *handler.RegistrationHandler,
+	securityHandler *handler.SecurityHandler,
+	talentTypeHandler *handler.TalentTypeHandler,
+	leaderboardHandler *handler.LeaderboardHandler,
+	catalogHandler *handler.CatalogHandler,
+	reportHandler *handler.ReportHandler,
+	auditHandler *handler.AuditHandler,
+	announcementHandler *handler.AnnouncementHandler,
 	authService *service.AuthService,
 ) *Router {
 	return &Router{
@@ -43,6 +59,14 @@ func NewRouter(
 		uploadHandler:       uploadHandler,
 		dashboardHandler:    dashboardHandler,
 		exportHandler:       exportHandler,
+		registrationHandler: registrationHandler,
+		securityHandler:     securityHandler,
+		talentTypeHandler:   talentTypeHandler,
+		leaderboardHandler:  leaderboardHandler,
+		catalogHandler:      catalogHandler,
+		reportHandler:       reportHandler,
+		auditHandler:        auditHandler,
+		announcementHandler: announcementHandler,
 		authService:         authService,
 	}
 }
@@ -59,9 +83,16 @@ func (r *Router) Setup(app *fiber.App) {
 	auth := api.Group("/auth")
 	auth.Post("/login", r.authHandler.Login)
 	auth.Post("/refresh", r.authHandler.Refresh)
+	auth.Post("/register", r.registrationHandler.Register)
+	auth.Get("/register/schools/:npsn", r.registrationHandler.LookupSchool)
+	auth.Post("/email/confirm", r.userHandler.ConfirmEmail)
 	auth.Post("/logout", middleware.AuthMiddleware(r.authService), r.authHandler.Logout)
 	auth.Post("/logout-all", middleware.AuthMiddleware(r.authService), r.authHandler.LogoutAll)
 
+	// Notification stream; registered ahead of the protected group because
+	// EventSource clients authenticate with a stream ticket instead
+	api.Get("/me/notifications/stream", middleware.StreamAuthMiddleware(r.authService), r.notificationHandler.Stream)
+
 	// Protected routes
 	protected := api.Group("", middleware.AuthMiddleware(r.authService))
 
@@ -69,18 +100,27 @@ func (r *Router) Setup(app *fiber.App) {
 	protected.Get("/me", r.userHandler.GetMe)
 	protected.Patch("/me", r.userHandler.UpdateMe)
 	protected.Patch("/me/password", r.userHandler.ChangePassword)
+	protected.Post("/me/email/verification", r.userHandler.ResendVerification)
+	protected.Delete("/me/email/pending", r.userHandler.CancelEmailChange)
 
 	// My talents (GTK)
 	protected.Get("/me/talents", r.talentHandler.ListMyTalents)
 	protected.Post("/me/talents", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.Create)
 	protected.Put("/me/talents/:id", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.Update)
 	protected.Delete("/me/talents/:id", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.Delete)
+	protected.Post("/me/talents/:id/submit", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.Submit)
+	protected.Post("/me/talents/:id/withdraw", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.Withdraw)
+	protected.Post("/me/talents/:id/attachments", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.AddAttachment)
+	protected.Delete("/me/talents/:id/attachments/:attachment_id", middleware.RoleMiddleware(domain.RoleGTK), r.talentHandler.DeleteAttachment)
 
 	// My notifications
 	protected.Get("/me/notifications", r.notificationHandler.List)
 	protected.Get("/me/notifications/unread-count", r.notificationHandler.GetUnreadCount)
 	protected.Patch("/me/notifications/:id/read", r.notificationHandler.MarkAsRead)
 	protected.Patch("/me/notifications/read-all", r.notificationHandler.MarkAllAsRead)
+	protected.Post("/me/notifications/stream-ticket", r.authHandler.StreamTicket)
+	protected.Get("/me/notification-preferences", r.notificationHandler.GetPreferences)
+	protected.Put("/me/notification-preferences", r.notificationHandler.UpdatePreferences)
 
 	// Schools routes
 	schools := protected.Group("/schools")
@@ -101,19 +141,82 @@ func (r *Router) Setup(app *fiber.App) {
 	users.Patch("/:id/activate", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.userHandler.Activate)
 	users.Patch("/:id/deactivate", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.userHandler.Deactivate)
 
+	// Registration review routes
+	registrations := protected.Group("/registrations")
+	registrations.Get("/", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.registrationHandler.List)
+	registrations.Get("/:id", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.registrationHandler.GetByID)
+	registrations.Post("/:id/approve", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.registrationHandler.Approve)
+	registrations.Post("/:id/reject", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.registrationHandler.Reject)
+
+	// Security event log
+	protected.Get("/security-events", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.securityHandler.ListEvents)
+
+	// Domain event audit log
+	protected.Get("/audit-log", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.auditHandler.List)
+
+	// Talent type registry
+	talentTypes := protected.Group("/talent-types")
+	talentTypes.Get("/", r.talentTypeHandler.List)
+	talentTypes.Get("/:code", r.talentTypeHandler.GetByCode)
+	talentTypes.Post("/", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.talentTypeHandler.Create)
+	talentTypes.Put("/:code", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.talentTypeHandler.Update)
+
+	// Scoring scheme and leaderboards
+	protected.Get("/scoring-scheme", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.leaderboardHandler.GetScheme)
+	protected.Put("/scoring-scheme", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.leaderboardHandler.UpdateScheme)
+	leaderboards := protected.Group("/leaderboards")
+	leaderboards.Get("/gtk", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.leaderboardHandler.GTK)
+	leaderboards.Get("/schools", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.leaderboardHandler.Schools)
+
+	// Competition and organizer catalog. Suggest and queue routes MUST come
+	// BEFORE parameterized routes to avoid :id matching them
+	catalog := protected.Group("/catalog")
+	catalog.Get("/suggest", r.catalogHandler.Suggest)
+	catalog.Get("/queue", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.ListQueue)
+	catalog.Post("/queue/:id/resolve", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.ResolveQueueItem)
+	catalog.Post("/queue/:id/dismiss", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.DismissQueueItem)
+	catalog.Get("/", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.catalogHandler.List)
+	catalog.Get("/:id", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.catalogHandler.GetByID)
+	catalog.Post("/", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.Create)
+	catalog.Put("/:id", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.Update)
+	catalog.Post("/:id/aliases", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.AddAlias)
+	catalog.Delete("/:id/aliases/:alias_id", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.DeleteAlias)
+	catalog.Post("/:id/merge", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.catalogHandler.Merge)
+
 	// Talents routes
 	talents := protected.Group("/talents")
 	talents.Get("/", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.talentHandler.List)
 	talents.Get("/:id", r.talentHandler.GetByID)
+	talents.Get("/:id/history", r.talentHandler.History)
+	talents.Get("/:id/versions", r.talentHandler.ListVersions)
+	talents.Get("/:id/versions/:a/diff/:b", r.talentHandler.DiffVersions)
 
 	// Verification routes
 	verifications := protected.Group("/verifications")
 	verifications.Get("/talents", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.ListPending)
 	// Batch routes MUST come BEFORE parameterized routes to avoid :id matching "batch"
+	verifications.Post("/talents/batch/preview", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.PreviewBatch)
 	verifications.Post("/talents/batch/approve", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.BatchApprove)
 	verifications.Post("/talents/batch/reject", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.BatchReject)
 	verifications.Post("/talents/:id/approve", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.Approve)
 	verifications.Post("/talents/:id/reject", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.Reject)
+	verifications.Post("/talents/:id/request-revision", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.RequestRevision)
+	verifications.Post("/talents/:id/claim", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.Claim)
+	verifications.Delete("/talents/:id/claim", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.verificationHandler.ReleaseClaim)
+	verifications.Post("/talents/:id/assign", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.verificationHandler.Assign)
+
+	// Announcement routes. The manage route MUST come BEFORE parameterized
+	// routes to avoid :id matching "manage"
+	announcements := protected.Group("/announcements")
+	announcements.Get("/", r.announcementHandler.Feed)
+	announcements.Get("/manage", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.ListManaged)
+	announcements.Post("/", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.Create)
+	announcements.Get("/:id", r.announcementHandler.GetByID)
+	announcements.Put("/:id", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.Update)
+	announcements.Delete("/:id", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.Delete)
+	announcements.Patch("/:id/read", r.announcementHandler.MarkAsRead)
+	announcements.Post("/:id/attachments", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.AddAttachment)
+	announcements.Delete("/:id/attachments/:attachment_id", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.announcementHandler.DeleteAttachment)
 
 	// Upload routes
 	uploads := protected.Group("/uploads")
@@ -131,4 +234,8 @@ func (r *Router) Setup(app *fiber.App) {
 	exports.Get("/gtk", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.exportHandler.ExportGTK)
 	exports.Get("/talents", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.exportHandler.ExportTalents)
 	exports.Get("/schools", middleware.RoleMiddleware(domain.RoleSuperAdmin), r.exportHandler.ExportSchools)
+	exports.Get("/pkb", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.reportHandler.ExportPKB)
+
+	// Report routes
+	protected.Get("/reports/pkb", middleware.RoleMiddleware(domain.RoleSuperAdmin, domain.RoleAdminSekolah), r.reportHandler.PKB)
 }
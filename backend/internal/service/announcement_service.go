@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var (
+	ErrAnnouncementNotFound           = errors.New("announcement not found")
+	ErrAnnouncementAttachmentNotFound = errors.New("announcement attachment not found")
+)
+
+// AnnouncementService manages announcements and their feed. Managing
+// methods take the school of a school admin, which limits them to the
+// announcements of that school; nil means a super admin.
+type AnnouncementService struct {
+	announcementRepo *repository.AnnouncementRepository
+	txManager        *repository.TxManager
+	notifications    *NotificationService
+}
+
+func NewAnnouncementService(
+	announcementRepo *repository.AnnouncementRepository,
+	txManager *repository.TxManager,
+	notifications *NotificationService,
+) *AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		txManager:        txManager,
+		notifications:    notifications,
+	}
+}
+
+// Create stores an announcement with its attachments. The audience is
+// notified once the announcement is published, by PublishDue.
+func (s *AnnouncementService) Create(ctx context.Context, req domain.CreateAnnouncementRequest, attachments []domain.AnnouncementAttachment, authorID uuid.UUID, schoolID *uuid.UUID) (*domain.Announcement, error) {
+	announcement := &domain.Announcement{
+		ID:        uuid.New(),
+		Title:     strings.TrimSpace(req.Title),
+		Body:      strings.TrimSpace(req.Body),
+		AuthorID:  &authorID,
+		SchoolID:  schoolID,
+		Audience:  req.Audience,
+		IsPinned:  req.IsPinned,
+		PublishAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.PublishAt != nil {
+		announcement.PublishAt = *req.PublishAt
+	}
+
+	if errs := validateAnnouncement(announcement); len(errs) > 0 {
+		return nil, errs
+	}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.announcementRepo.Create(ctx, announcement); err != nil {
+			return err
+		}
+		for i := range attachments {
+			if err := s.saveAttachment(ctx, announcement.ID, authorID, &attachments[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetManaged(ctx, announcement.ID, schoolID)
+}
+
+// Update replaces an announcement. Moving the publish time of an
+// announcement already sent out does not notify its audience again.
+func (s *AnnouncementService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateAnnouncementRequest, schoolID *uuid.UUID) (*domain.Announcement, error) {
+	announcement, err := s.GetManaged(ctx, id, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement.Title = strings.TrimSpace(req.Title)
+	announcement.Body = strings.TrimSpace(req.Body)
+	announcement.Audience = req.Audience
+	announcement.IsPinned = req.IsPinned
+	announcement.ExpiresAt = req.ExpiresAt
+	if req.PublishAt != nil {
+		announcement.PublishAt = *req.PublishAt
+	}
+
+	if errs := validateAnnouncement(announcement); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := s.announcementRepo.Update(ctx, announcement); err != nil {
+		return nil, err
+	}
+	return s.GetManaged(ctx, id, schoolID)
+}
+
+func (s *AnnouncementService) Delete(ctx context.Context, id uuid.UUID, schoolID *uuid.UUID) error {
+	if _, err := s.GetManaged(ctx, id, schoolID); err != nil {
+		return err
+	}
+	return s.announcementRepo.Delete(ctx, id)
+}
+
+// ListManaged lists the announcements an admin may manage, in any status.
+func (s *AnnouncementService) ListManaged(ctx context.Context, params domain.ListParams, schoolID *uuid.UUID) ([]domain.Announcement, int, error) {
+	if schoolID != nil {
+		params.Filters["school_id"] = schoolID.String()
+	}
+	announcements, total, err := s.announcementRepo.ListManaged(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.loadAttachments(ctx, announcements); err != nil {
+		return nil, 0, err
+	}
+	return announcements, total, nil
+}
+
+// GetManaged returns an announcement the admin may manage, with its read
+// count.
+func (s *AnnouncementService) GetManaged(ctx context.Context, id uuid.UUID, schoolID *uuid.UUID) (*domain.Announcement, error) {
+	announcement, err := s.announcementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if announcement == nil {
+		return nil, ErrAnnouncementNotFound
+	}
+	if schoolID != nil && (announcement.SchoolID == nil || *announcement.SchoolID != *schoolID) {
+		return nil, ErrForbidden
+	}
+	attachments, err := s.announcementRepo.ListAttachments(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	announcement.Attachments = orEmpty(attachments[id])
+	return announcement, nil
+}
+
+// ListFeed lists the active announcements addressed to the user.
+func (s *AnnouncementService) ListFeed(ctx context.Context, userID uuid.UUID, params domain.ListParams) ([]domain.Announcement, int, error) {
+	announcements, total, err := s.announcementRepo.ListFeed(ctx, userID, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.loadAttachments(ctx, announcements); err != nil {
+		return nil, 0, err
+	}
+	return announcements, total, nil
+}
+
+// GetForUser returns an active announcement addressed to the user.
+func (s *AnnouncementService) GetForUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Announcement, error) {
+	announcement, err := s.announcementRepo.GetForUser(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if announcement == nil {
+		return nil, ErrAnnouncementNotFound
+	}
+	attachments, err := s.announcementRepo.ListAttachments(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	announcement.Attachments = orEmpty(attachments[id])
+	return announcement, nil
+}
+
+func (s *AnnouncementService) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.announcementRepo.CountUnread(ctx, userID)
+}
+
+func (s *AnnouncementService) MarkAsRead(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.GetForUser(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.announcementRepo.MarkRead(ctx, id, userID)
+}
+
+func (s *AnnouncementService) AddAttachment(ctx context.Context, id uuid.UUID, attachment *domain.AnnouncementAttachment, userID uuid.UUID, schoolID *uuid.UUID) (*domain.Announcement, error) {
+	if _, err := s.GetManaged(ctx, id, schoolID); err != nil {
+		return nil, err
+	}
+	if err := s.saveAttachment(ctx, id, userID, attachment); err != nil {
+		return nil, err
+	}
+	return s.GetManaged(ctx, id, schoolID)
+}
+
+func (s *AnnouncementService) DeleteAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID, schoolID *uuid.UUID) (*domain.Announcement, error) {
+	if _, err := s.GetManaged(ctx, id, schoolID); err != nil {
+		return nil, err
+	}
+	deleted, err := s.announcementRepo.DeleteAttachment(ctx, id, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrAnnouncementAttachmentNotFound
+	}
+	return s.GetManaged(ctx, id, schoolID)
+}
+
+// PublishDue notifies the audience of every announcement that reached its
+// publish time. Each announcement is sent out in its own transaction, so a
+// failure leaves it for the next run without notifying anyone twice.
+func (s *AnnouncementService) PublishDue(ctx context.Context) error {
+	for {
+		found := false
+		err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+			announcement, err := s.announcementRepo.ClaimUnnotified(ctx)
+			if err != nil || announcement == nil {
+				return err
+			}
+			found = true
+			return s.publish(ctx, announcement)
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+	}
+}
+
+func (s *AnnouncementService) publish(ctx context.Context, announcement *domain.Announcement) error {
+	recipients, err := s.announcementRepo.ListRecipientIDs(ctx, announcement.ID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Pengumuman baru: %s", announcement.Title)
+	for _, id := range recipients {
+		notification := &domain.Notification{
+			UserID:  id,
+			Type:    domain.NotificationAnnouncement,
+			Message: message,
+		}
+		if err := s.notifications.Notify(ctx, notification); err != nil {
+			return fmt.Errorf("notify %s of announcement %s: %w", id, announcement.ID, err)
+		}
+	}
+	log.Printf("announcement %s published to %d users", announcement.ID, len(recipients))
+
+	return s.announcementRepo.MarkNotified(ctx, announcement.ID)
+}
+
+func (s *AnnouncementService) saveAttachment(ctx context.Context, announcementID uuid.UUID, userID uuid.UUID, attachment *domain.AnnouncementAttachment) error {
+	attachment.ID = uuid.New()
+	attachment.AnnouncementID = announcementID
+	attachment.UploadedBy = &userID
+	return s.announcementRepo.AddAttachment(ctx, attachment)
+}
+
+func (s *AnnouncementService) loadAttachments(ctx context.Context, announcements []domain.Announcement) error {
+	if len(announcements) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(announcements))
+	for _, a := range announcements {
+		ids = append(ids, a.ID)
+	}
+	attachments, err := s.announcementRepo.ListAttachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range announcements {
+		announcements[i].Attachments = orEmpty(attachments[announcements[i].ID])
+	}
+	return nil
+}
+
+func orEmpty(attachments []domain.AnnouncementAttachment) []domain.AnnouncementAttachment {
+	if attachments == nil {
+		return []domain.AnnouncementAttachment{}
+	}
+	return attachments
+}
+
+// validateAnnouncement checks an announcement before it is stored. The
+// audience of a school announcement can only be narrowed to that school.
+func validateAnnouncement(a *domain.Announcement) ValidationErrors {
+	var errs ValidationErrors
+
+	if a.Title == "" {
+		errs.add("title", "Judul wajib diisi")
+	} else if len([]rune(a.Title)) > 255 {
+		errs.add("title", "Judul maksimal 255 karakter")
+	}
+	if a.Body == "" {
+		errs.add("body", "Isi pengumuman wajib diisi")
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(a.PublishAt) {
+		errs.add("expires_at", "Waktu berakhir harus setelah waktu terbit")
+	}
+
+	for i, role := range a.Audience.Roles {
+		if !role.IsValid() {
+			errs.add(fmt.Sprintf("audience.roles[%d]", i), "Role harus super_admin, admin_sekolah atau gtk")
+		}
+	}
+	for i, status := range a.Audience.SchoolStatuses {
+		if !status.IsValid() {
+			errs.add(fmt.Sprintf("audience.school_statuses[%d]", i), "Status sekolah harus negeri atau swasta")
+		}
+	}
+	for i, gtkType := range a.Audience.GTKTypes {
+		if !gtkType.IsValid() {
+			errs.add(fmt.Sprintf("audience.gtk_types[%d]", i), "Jenis GTK harus guru, tendik atau kepala_sekolah")
+		}
+	}
+	if a.SchoolID != nil {
+		for i, id := range a.Audience.SchoolIDs {
+			if id != *a.SchoolID {
+				errs.add(fmt.Sprintf("audience.school_ids[%d]", i), "Pengumuman sekolah hanya dapat ditujukan ke sekolah sendiri")
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// AuditService reads the audit log the audit consumer fills from the outbox.
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// List filters the audit log by event_type, aggregate_id, actor_id,
+// date_from and date_to, newest first.
+func (s *AuditService) List(ctx context.Context, params domain.ListParams) ([]domain.AuditLogEntry, int, error) {
+	var errs ValidationErrors
+	for _, field := range []string{"aggregate_id", "actor_id"} {
+		if id := params.Filters[field]; id != "" {
+			if _, err := uuid.Parse(id); err != nil {
+				errs.add(field, "ID tidak valid")
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, 0, errs
+	}
+	return s.auditRepo.List(ctx, params)
+}
@@ -20,19 +20,31 @@ var (
 	ErrAccountDisabled    = errors.New("account disabled")
 	ErrTokenExpired       = errors.New("token expired")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrAccountPending     = errors.New("account pending approval")
+	ErrAccountRejected    = errors.New("account registration rejected")
 )
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	tokenRepo *repository.TokenRepository
-	jwtConfig config.JWTConfig
+	userRepo         *repository.UserRepository
+	tokenRepo        *repository.TokenRepository
+	registrationRepo *repository.RegistrationRepository
+	securityService  *SecurityService
+	jwtConfig        config.JWTConfig
 }
 
-func NewAuthService(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, jwtConfig config.JWTConfig) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	tokenRepo *repository.TokenRepository,
+	registrationRepo *repository.RegistrationRepository,
+	securityService *SecurityService,
+	jwtConfig config.JWTConfig,
+) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		tokenRepo: tokenRepo,
-		jwtConfig: jwtConfig,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		registrationRepo: registrationRepo,
+		securityService:  securityService,
+		jwtConfig:        jwtConfig,
 	}
 }
 
@@ -44,7 +56,7 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) Login(ctx context.Context, req domain.LoginRequest) (*domain.LoginResponse, string, error) {
+func (s *AuthService) Login(ctx context.Context, req domain.LoginRequest, meta domain.RequestMeta) (*domain.LoginResponse, string, error) {
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, "", err
@@ -58,7 +70,7 @@ func (s *AuthService) Login(ctx context.Context, req domain.LoginRequest) (*doma
 	}
 
 	if !user.IsActive {
-		return nil, "", ErrAccountDisabled
+		return nil, "", s.inactiveReason(ctx, user.ID)
 	}
 
 	accessToken, err := s.generateAccessToken(user)
@@ -71,6 +83,8 @@ func (s *AuthService) Login(ctx context.Context, req domain.LoginRequest) (*doma
 		return nil, "", err
 	}
 
+	s.securityService.CheckLoginSource(ctx, user, meta)
+
 	var birthDateStr *string
 	if user.BirthDate != nil {
 		str := user.BirthDate.Format("2006-01-02")
@@ -100,7 +114,7 @@ func (s *AuthService) Login(ctx context.Context, req domain.LoginRequest) (*doma
 	}, refreshToken, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*domain.RefreshResponse, string, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, meta domain.RequestMeta) (*domain.RefreshResponse, string, error) {
 	tokenHash := hashToken(refreshToken)
 	token, err := s.tokenRepo.GetByHash(ctx, tokenHash)
 	if err != nil {
@@ -110,6 +124,16 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*d
 		return nil, "", ErrInvalidToken
 	}
 
+	// A rotated token is only ever presented again if it was copied, so end
+	// every session of the user and alert them.
+	if token.RevokedAt != nil {
+		s.tokenRepo.DeleteByUserID(ctx, token.UserID)
+		if user, _ := s.userRepo.GetByID(ctx, token.UserID); user != nil {
+			s.securityService.Record(ctx, user, domain.SecurityTokenReuseDetected, nil, meta, "")
+		}
+		return nil, "", ErrInvalidToken
+	}
+
 	if time.Now().After(token.ExpiresAt) {
 		s.tokenRepo.Delete(ctx, token.ID)
 		return nil, "", ErrTokenExpired
@@ -123,8 +147,8 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*d
 		return nil, "", ErrAccountDisabled
 	}
 
-	// Delete old token
-	s.tokenRepo.Delete(ctx, token.ID)
+	// Revoke old token; it is kept until expiry to detect reuse
+	s.tokenRepo.Revoke(ctx, token.ID)
 
 	accessToken, err := s.generateAccessToken(user)
 	if err != nil {
@@ -167,6 +191,54 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	// Stream tickets are only good for opening the notification stream
+	if len(claims.Audience) > 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// streamTicketAudience marks tokens issued by IssueStreamTicket.
+const streamTicketAudience = "notification-stream"
+
+// IssueStreamTicket returns a short-lived token that opens the notification
+// stream for the caller. It exists for clients such as EventSource that
+// cannot send an Authorization header and must put the token in the URL.
+func (s *AuthService) IssueStreamTicket(claims *JWTClaims) (string, error) {
+	ticket := JWTClaims{
+		UserID:   claims.UserID,
+		Email:    claims.Email,
+		Role:     claims.Role,
+		SchoolID: claims.SchoolID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{streamTicketAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtConfig.StreamTicketExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, ticket)
+	return token.SignedString([]byte(s.jwtConfig.Secret))
+}
+
+// StreamTicketExpiry is how long tickets from IssueStreamTicket last.
+func (s *AuthService) StreamTicketExpiry() time.Duration {
+	return s.jwtConfig.StreamTicketExpiry
+}
+
+func (s *AuthService) ValidateStreamTicket(ticket string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(ticket, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtConfig.Secret), nil
+	}, jwt.WithAudience(streamTicketAudience))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
 	claims, ok := token.Claims.(*JWTClaims)
 	if !ok || !token.Valid {
 		return nil, ErrInvalidToken
@@ -175,6 +247,24 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return claims, nil
 }
 
+// inactiveReason distinguishes self-registered accounts still waiting for
+// review from accounts an admin has deactivated.
+func (s *AuthService) inactiveReason(ctx context.Context, userID uuid.UUID) error {
+	registration, err := s.registrationRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if registration != nil {
+		switch registration.Status {
+		case domain.RegistrationStatusPending:
+			return ErrAccountPending
+		case domain.RegistrationStatusRejected:
+			return ErrAccountRejected
+		}
+	}
+	return ErrAccountDisabled
+}
+
 func (s *AuthService) generateAccessToken(user *domain.User) (string, error) {
 	claims := JWTClaims{
 		UserID:   user.ID,
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var (
+	ErrCatalogEntryNotFound   = errors.New("catalog entry not found")
+	ErrCatalogAliasNotFound   = errors.New("catalog alias not found")
+	ErrCatalogAliasTaken      = errors.New("alias belongs to another catalog entry")
+	ErrCatalogAliasIsName     = errors.New("alias is the entry name")
+	ErrCatalogKindMismatch    = errors.New("catalog entries are of different kinds")
+	ErrCatalogQueueNotFound   = errors.New("catalog queue item not found")
+	ErrCatalogQueueNotPending = errors.New("catalog queue item is not pending")
+)
+
+// CatalogService curates the competition and organizer catalog. Talents are
+// linked to entries by the database as soon as an alias matches their name;
+// names nothing matches land in the normalization queue.
+type CatalogService struct {
+	catalogRepo *repository.CatalogRepository
+	txManager   *repository.TxManager
+}
+
+func NewCatalogService(catalogRepo *repository.CatalogRepository, txManager *repository.TxManager) *CatalogService {
+	return &CatalogService{catalogRepo: catalogRepo, txManager: txManager}
+}
+
+func (s *CatalogService) List(ctx context.Context, params domain.ListParams) ([]domain.CatalogEntry, int, error) {
+	return s.catalogRepo.List(ctx, params)
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error) {
+	entry, err := s.catalogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrCatalogEntryNotFound
+	}
+	return entry, nil
+}
+
+// Suggest autocompletes a competition or organizer name for talent forms.
+func (s *CatalogService) Suggest(ctx context.Context, kind domain.CatalogKind, query string, limit int) ([]domain.CatalogSuggestion, error) {
+	return s.catalogRepo.Suggest(ctx, kind, strings.TrimSpace(query), limit)
+}
+
+// Create adds an entry under its name and the given aliases.
+func (s *CatalogService) Create(ctx context.Context, req domain.CreateCatalogEntryRequest, createdBy uuid.UUID) (*domain.CatalogEntry, error) {
+	entry := &domain.CatalogEntry{
+		ID:           uuid.New(),
+		Kind:         req.Kind,
+		Name:         strings.TrimSpace(req.Name),
+		DefaultLevel: emptyLevelToNil(req.DefaultLevel),
+		DefaultField: emptyFieldToNil(req.DefaultField),
+		IsActive:     true,
+		CreatedBy:    &createdBy,
+	}
+
+	var errs ValidationErrors
+	if !entry.Kind.IsValid() {
+		errs.add("kind", "Jenis katalog harus competition atau organizer")
+	}
+	errs = append(errs, validateCatalogEntry(entry)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.catalogRepo.Create(ctx, entry); err != nil {
+			return err
+		}
+		for i, alias := range append([]string{entry.Name}, req.Aliases...) {
+			field := fmt.Sprintf("aliases[%d]", i-1)
+			if i == 0 {
+				field = "name"
+			}
+			if err := s.addAlias(ctx, entry, alias, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.GetByID(ctx, entry.ID)
+}
+
+// Update changes an entry. A new name is added as an alias and the old one
+// kept, so talents using either stay linked.
+func (s *CatalogService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateCatalogEntryRequest) (*domain.CatalogEntry, error) {
+	entry, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		entry.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.DefaultLevel != nil {
+		entry.DefaultLevel = emptyLevelToNil(req.DefaultLevel)
+	}
+	if req.DefaultField != nil {
+		entry.DefaultField = emptyFieldToNil(req.DefaultField)
+	}
+	if req.IsActive != nil {
+		entry.IsActive = *req.IsActive
+	}
+
+	if errs := validateCatalogEntry(entry); len(errs) > 0 {
+		return nil, errs
+	}
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.catalogRepo.Update(ctx, entry); err != nil {
+			return err
+		}
+		return s.addAlias(ctx, entry, entry.Name, "name")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.GetByID(ctx, id)
+}
+
+func (s *CatalogService) AddAlias(ctx context.Context, id uuid.UUID, alias string) (*domain.CatalogEntry, error) {
+	entry, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addAlias(ctx, entry, alias, "alias"); err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.GetByID(ctx, id)
+}
+
+// addAlias adds a spelling to an entry unless the entry already has it.
+// field names the request field in validation errors.
+func (s *CatalogService) addAlias(ctx context.Context, entry *domain.CatalogEntry, alias, field string) error {
+	alias = strings.TrimSpace(alias)
+	if len(alias) > 255 {
+		return ValidationErrors{{Field: field, Message: "Maksimal 255 karakter"}}
+	}
+	normalized, err := s.catalogRepo.Normalize(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if normalized == nil {
+		return ValidationErrors{{Field: field, Message: "Harus memuat huruf atau angka"}}
+	}
+
+	existing, err := s.catalogRepo.FindAlias(ctx, entry.Kind, *normalized)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.EntryID == entry.ID {
+			return nil
+		}
+		return ErrCatalogAliasTaken
+	}
+
+	return s.catalogRepo.AddAlias(ctx, entry.Kind, &domain.CatalogAlias{
+		ID:      uuid.New(),
+		EntryID: entry.ID,
+		Alias:   alias,
+	})
+}
+
+// DeleteAlias removes a spelling; talents using it are unlinked. The
+// spelling of the entry name cannot be removed.
+func (s *CatalogService) DeleteAlias(ctx context.Context, id, aliasID uuid.UUID) error {
+	entry, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	alias, err := s.catalogRepo.GetAlias(ctx, aliasID)
+	if err != nil {
+		return err
+	}
+	if alias == nil || alias.EntryID != id {
+		return ErrCatalogAliasNotFound
+	}
+
+	nameKey, err := s.catalogRepo.Normalize(ctx, entry.Name)
+	if err != nil {
+		return err
+	}
+	aliasKey, err := s.catalogRepo.Normalize(ctx, alias.Alias)
+	if err != nil {
+		return err
+	}
+	if nameKey != nil && aliasKey != nil && *nameKey == *aliasKey {
+		return ErrCatalogAliasIsName
+	}
+	return s.catalogRepo.DeleteAlias(ctx, aliasID)
+}
+
+// Merge folds a duplicate entry into target, moving its aliases and talents.
+func (s *CatalogService) Merge(ctx context.Context, id, targetID uuid.UUID) (*domain.CatalogEntry, error) {
+	if id == targetID {
+		return nil, ValidationErrors{{Field: "target_id", Message: "Entri tidak dapat digabung dengan dirinya sendiri"}}
+	}
+	source, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.catalogRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ValidationErrors{{Field: "target_id", Message: "Entri tujuan tidak ditemukan"}}
+	}
+	if source.Kind != target.Kind {
+		return nil, ErrCatalogKindMismatch
+	}
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		return s.catalogRepo.Merge(ctx, source.ID, target.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.GetByID(ctx, target.ID)
+}
+
+func (s *CatalogService) ListQueue(ctx context.Context, params domain.ListParams) ([]domain.CatalogQueueItem, int, error) {
+	return s.catalogRepo.ListQueue(ctx, params)
+}
+
+// ResolveQueueItem maps a queued name to an entry by adding it as an alias,
+// creating the entry first when none is given. Every talent carrying the
+// name is linked.
+func (s *CatalogService) ResolveQueueItem(ctx context.Context, id uuid.UUID, req domain.ResolveCatalogQueueRequest, resolvedBy uuid.UUID) (*domain.CatalogQueueItem, error) {
+	item, err := s.pendingQueueItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var entry *domain.CatalogEntry
+		var err error
+		if req.EntryID != nil {
+			entry, err = s.catalogRepo.GetByID(ctx, *req.EntryID)
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				return ValidationErrors{{Field: "entry_id", Message: "Entri katalog tidak ditemukan"}}
+			}
+			if entry.Kind != item.Kind {
+				return ErrCatalogKindMismatch
+			}
+		} else {
+			name := req.Name
+			if strings.TrimSpace(name) == "" {
+				name = item.RawName
+			}
+			entry, err = s.Create(ctx, domain.CreateCatalogEntryRequest{
+				Kind:         item.Kind,
+				Name:         name,
+				DefaultLevel: req.DefaultLevel,
+				DefaultField: req.DefaultField,
+			}, resolvedBy)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := s.addAlias(ctx, entry, item.RawName, "raw_name"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		item.Status = domain.CatalogQueueResolved
+		item.EntryID = &entry.ID
+		item.ResolvedBy = &resolvedBy
+		item.ResolvedAt = &now
+		return s.catalogRepo.UpdateQueueItem(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.GetQueueItem(ctx, id)
+}
+
+// DismissQueueItem keeps a queued name as free text. It is not queued again.
+func (s *CatalogService) DismissQueueItem(ctx context.Context, id uuid.UUID, dismissedBy uuid.UUID) (*domain.CatalogQueueItem, error) {
+	item, err := s.pendingQueueItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item.Status = domain.CatalogQueueDismissed
+	item.ResolvedBy = &dismissedBy
+	item.ResolvedAt = &now
+	if err := s.catalogRepo.UpdateQueueItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *CatalogService) pendingQueueItem(ctx context.Context, id uuid.UUID) (*domain.CatalogQueueItem, error) {
+	item, err := s.catalogRepo.GetQueueItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrCatalogQueueNotFound
+	}
+	if item.Status != domain.CatalogQueuePending {
+		return nil, ErrCatalogQueueNotPending
+	}
+	return item, nil
+}
+
+// validateCatalogEntry checks the name and that only competitions carry a
+// default level and field.
+func validateCatalogEntry(entry *domain.CatalogEntry) ValidationErrors {
+	var errs ValidationErrors
+	if entry.Name == "" {
+		errs.add("name", "Nama wajib diisi")
+	} else if len(entry.Name) > 255 {
+		errs.add("name", "Maksimal 255 karakter")
+	}
+
+	if entry.DefaultLevel != nil && !entry.DefaultLevel.IsValid() {
+		errs.add("default_level", "Jenjang harus kota, provinsi, nasional, atau internasional")
+	}
+	if entry.DefaultField != nil && !entry.DefaultField.IsValid() {
+		errs.add("default_field", "Bidang tidak valid")
+	}
+	if entry.Kind == domain.CatalogOrganizer && (entry.DefaultLevel != nil || entry.DefaultField != nil) {
+		errs.add("kind", "Jenjang dan bidang bawaan hanya untuk lomba")
+	}
+	return errs
+}
+
+func emptyLevelToNil(level *domain.CompetitionLevel) *domain.CompetitionLevel {
+	if level == nil || *level == "" {
+		return nil
+	}
+	return level
+}
+
+func emptyFieldToNil(field *domain.TalentField) *domain.TalentField {
+	if field == nil || *field == "" {
+		return nil
+	}
+	return field
+}
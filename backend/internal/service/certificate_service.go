@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// CertificateService reminds GTK and their school admins of certificates
+// about to expire. Certificates live in the talent detail as the optional
+// certificate_number, certificate_issued_date and certificate_expiry_date
+// fields.
+type CertificateService struct {
+	certificateRepo *repository.CertificateRepository
+	userRepo        *repository.UserRepository
+	notifications   *NotificationService
+	reminderDays    []int
+}
+
+func NewCertificateService(
+	certificateRepo *repository.CertificateRepository,
+	userRepo *repository.UserRepository,
+	notifications *NotificationService,
+	certificateConfig config.CertificateConfig,
+) *CertificateService {
+	return &CertificateService{
+		certificateRepo: certificateRepo,
+		userRepo:        userRepo,
+		notifications:   notifications,
+		reminderDays:    certificateConfig.ReminderDays,
+	}
+}
+
+// CheckExpiry notifies the owner and the school admins of every certificate
+// that reached a reminder threshold. Each threshold is notified once per
+// expiry date, so renewing a certificate starts the reminders over.
+func (s *CertificateService) CheckExpiry(ctx context.Context) error {
+	if len(s.reminderDays) == 0 {
+		return nil
+	}
+
+	certs, err := s.certificateRepo.ListDue(ctx, s.reminderDays)
+	if err != nil {
+		return err
+	}
+
+	for _, cert := range certs {
+		message := fmt.Sprintf("Sertifikat %s berakhir dalam %d hari (%s)", cert.Name, cert.DaysLeft, cert.ExpiryDate.Format("02-01-2006"))
+		if cert.DaysLeft == 0 {
+			message = fmt.Sprintf("Sertifikat %s berakhir hari ini", cert.Name)
+		}
+
+		recipients := []uuid.UUID{cert.UserID}
+		if cert.SchoolID != nil {
+			admins, err := s.userRepo.ListActiveIDsBySchoolAndRole(ctx, *cert.SchoolID, domain.RoleAdminSekolah)
+			if err != nil {
+				log.Printf("failed to load school admins for certificate of talent %s: %v", cert.TalentID, err)
+			}
+			recipients = append(recipients, admins...)
+		}
+
+		for _, id := range recipients {
+			notification := &domain.Notification{
+				UserID:   id,
+				TalentID: &cert.TalentID,
+				Type:     domain.NotificationCertificateExpiring,
+				Message:  message,
+			}
+			if err := s.notifications.Notify(ctx, notification); err != nil {
+				log.Printf("failed to notify %s of expiring certificate of talent %s: %v", id, cert.TalentID, err)
+			}
+		}
+
+		if err := s.certificateRepo.MarkNotified(ctx, cert.TalentID, cert.ExpiryDate, cert.DaysBefore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -97,6 +97,6 @@ func (s *DashboardService) GetSchoolsStatistics(ctx context.Context, params doma
 	return s.schoolRepo.GetStatistics(ctx, params)
 }
 
-func (s *DashboardService) GetTalentsStatistics(ctx context.Context, groupBy string, schoolID *string, dateFrom, dateTo string) (map[string]interface{}, error) {
-	return s.talentRepo.GetStatistics(ctx, groupBy, schoolID, dateFrom, dateTo)
+func (s *DashboardService) GetTalentsStatistics(ctx context.Context, groupBy string, schoolID *string, dateFrom, dateTo string, excludeExpired bool) (map[string]interface{}, error) {
+	return s.talentRepo.GetStatistics(ctx, groupBy, schoolID, dateFrom, dateTo, excludeExpired)
 }
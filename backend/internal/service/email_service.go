@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/mailer"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// EmailService sends the emails queued by NotificationService and collects
+// digest items into one email per user and day.
+type EmailService struct {
+	emailQueueRepo *repository.EmailQueueRepository
+	userRepo       *repository.UserRepository
+	txManager      *repository.TxManager
+	mailer         mailer.Mailer
+	renderer       *mailer.Renderer
+	appConfig      config.AppConfig
+	maxAttempts    int
+	batchSize      int
+	digestHour     int
+	location       *time.Location
+}
+
+func NewEmailService(
+	emailQueueRepo *repository.EmailQueueRepository,
+	userRepo *repository.UserRepository,
+	txManager *repository.TxManager,
+	m mailer.Mailer,
+	renderer *mailer.Renderer,
+	appConfig config.AppConfig,
+	cfg config.NotificationConfig,
+) *EmailService {
+	return &EmailService{
+		emailQueueRepo: emailQueueRepo,
+		userRepo:       userRepo,
+		txManager:      txManager,
+		mailer:         m,
+		renderer:       renderer,
+		appConfig:      appConfig,
+		maxAttempts:    cfg.EmailMaxAttempts,
+		batchSize:      cfg.EmailBatchSize,
+		digestHour:     cfg.DigestHour,
+		location:       cfg.Location(),
+	}
+}
+
+// ProcessQueue sends up to the batch size of due emails.
+func (s *EmailService) ProcessQueue(ctx context.Context) error {
+	for i := 0; i < s.batchSize; i++ {
+		found, err := s.sendOne(ctx)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+	}
+	return nil
+}
+
+// sendOne sends one due email while holding its row, so no other sender
+// picks it up meanwhile. A failed send is recorded for a retry.
+func (s *EmailService) sendOne(ctx context.Context) (bool, error) {
+	found := false
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		job, err := s.emailQueueRepo.ClaimDue(ctx, s.maxAttempts)
+		if err != nil || job == nil {
+			return err
+		}
+		found = true
+
+		sendErr := s.mailer.Send(ctx, mailer.Message{
+			To:      job.To,
+			Subject: job.Subject,
+			Text:    job.Text,
+			HTML:    job.HTML,
+		})
+		if sendErr != nil {
+			log.Printf("failed to send email %s to %s (attempt %d): %v", job.ID, job.To, job.Attempts+1, sendErr)
+			return s.emailQueueRepo.MarkFailed(ctx, job.ID, sendErr.Error())
+		}
+		return s.emailQueueRepo.MarkSent(ctx, job.ID)
+	})
+	return found, err
+}
+
+// SendDigests queues the daily digest of every user with items collected
+// before the most recent digest hour. Running it more often than daily is
+// safe: items are only taken once.
+func (s *EmailService) SendDigests(ctx context.Context) error {
+	cutoff := latestAt(time.Now(), s.location, -1, s.digestHour)
+	userIDs, err := s.emailQueueRepo.ListDigestUsers(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+			return s.queueDigest(ctx, userID, cutoff)
+		})
+		if err != nil {
+			log.Printf("failed to queue notification digest for user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *EmailService) queueDigest(ctx context.Context, userID uuid.UUID, cutoff time.Time) error {
+	items, err := s.emailQueueRepo.ClaimDigestItems(ctx, userID, cutoff)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Items of deactivated users are dropped rather than kept forever
+	if user == nil || !user.IsActive {
+		return s.emailQueueRepo.MarkDigestSent(ctx, ids)
+	}
+
+	entries := make([]mailer.DigestEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, mailer.DigestEntry{
+			Message: item.Message,
+			Time:    item.CreatedAt.In(s.location).Format("02-01-2006 15:04"),
+		})
+	}
+	msg, err := s.renderer.Render("digest", mailer.TemplateData{
+		AppName:     s.appConfig.Name,
+		FrontendURL: s.appConfig.FrontendURL,
+		FullName:    user.FullName,
+		Items:       entries,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.emailQueueRepo.Enqueue(ctx, &domain.EmailJob{
+		ID:      uuid.New(),
+		UserID:  &user.ID,
+		To:      user.Email,
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+	})
+	if err != nil {
+		return err
+	}
+	return s.emailQueueRepo.MarkDigestSent(ctx, ids)
+}
+
+// latestAt returns the latest time at or before now that falls on hour in
+// location and, unless weekday is negative, on that weekday.
+func latestAt(now time.Time, location *time.Location, weekday time.Weekday, hour int) time.Time {
+	local := now.In(location)
+	at := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, location)
+	if at.After(local) {
+		at = at.AddDate(0, 0, -1)
+	}
+	if weekday >= 0 {
+		at = at.AddDate(0, 0, -int((at.Weekday()-weekday+7)%7))
+	}
+	return at
+}
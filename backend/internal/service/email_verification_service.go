@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/mailer"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var (
+	ErrVerificationTokenInvalid = errors.New("verification token invalid or expired")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailUnchanged           = errors.New("email unchanged")
+)
+
+const emailTokenExpiry = 24 * time.Hour
+
+type EmailVerificationService struct {
+	verificationRepo *repository.EmailVerificationRepository
+	userRepo         *repository.UserRepository
+	mailer           mailer.Mailer
+	appConfig        config.AppConfig
+}
+
+func NewEmailVerificationService(
+	verificationRepo *repository.EmailVerificationRepository,
+	userRepo *repository.UserRepository,
+	mailer mailer.Mailer,
+	appConfig config.AppConfig,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		verificationRepo: verificationRepo,
+		userRepo:         userRepo,
+		mailer:           mailer,
+		appConfig:        appConfig,
+	}
+}
+
+// SendVerification emails a confirmation link for the user's current address.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *domain.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := s.verificationRepo.DeletePending(ctx, user.ID, domain.EmailTokenVerify); err != nil {
+		return err
+	}
+
+	token, err := s.createToken(ctx, user.ID, user.Email, domain.EmailTokenVerify)
+	if err != nil {
+		return err
+	}
+
+	link := s.confirmLink(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email akun SIPODI",
+		Text: fmt.Sprintf(
+			"Halo %s,\n\nSilakan verifikasi alamat email Anda dengan membuka tautan berikut:\n%s\n\nTautan berlaku selama 24 jam.",
+			user.FullName, link,
+		),
+	})
+}
+
+// ResendVerification is the self-service variant of SendVerification.
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// RequestEmailChange keeps users.email untouched and sends a confirmation link
+// to the new address plus a notice to the current one.
+func (s *EmailVerificationService) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) error {
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailTaken
+	}
+
+	if err := s.verificationRepo.DeletePending(ctx, user.ID, domain.EmailTokenChange); err != nil {
+		return err
+	}
+
+	token, err := s.createToken(ctx, user.ID, newEmail, domain.EmailTokenChange)
+	if err != nil {
+		return err
+	}
+
+	link := s.confirmLink(token)
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Konfirmasi perubahan email akun SIPODI",
+		Text: fmt.Sprintf(
+			"Halo %s,\n\nAda permintaan untuk mengganti email akun SIPODI Anda menjadi alamat ini. Konfirmasi melalui tautan berikut:\n%s\n\nTautan berlaku selama 24 jam. Abaikan email ini jika Anda tidak merasa memintanya.",
+			user.FullName, link,
+		),
+	}); err != nil {
+		return err
+	}
+
+	// The notice to the old address is informational; a failure here should
+	// not block the change request.
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Permintaan perubahan email akun SIPODI",
+		Text: fmt.Sprintf(
+			"Halo %s,\n\nAda permintaan untuk mengganti email akun SIPODI Anda menjadi %s. Email akun belum berubah sampai alamat baru dikonfirmasi.\n\nJika Anda tidak merasa memintanya, segera ganti password dan hubungi admin.",
+			user.FullName, newEmail,
+		),
+	}); err != nil {
+		log.Printf("failed to send email change notice to %s: %v", user.Email, err)
+	}
+
+	return nil
+}
+
+func (s *EmailVerificationService) CancelEmailChange(ctx context.Context, userID uuid.UUID) error {
+	return s.verificationRepo.DeletePending(ctx, userID, domain.EmailTokenChange)
+}
+
+// Confirm consumes a token. Verification tokens mark the current address as
+// verified; change tokens swap users.email to the confirmed address.
+func (s *EmailVerificationService) Confirm(ctx context.Context, token string) (*domain.EmailVerification, error) {
+	verification, err := s.verificationRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if verification == nil {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, verification.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	now := time.Now()
+	switch verification.Purpose {
+	case domain.EmailTokenVerify:
+		// The address may have changed since the link was sent
+		if user.Email != verification.Email {
+			return nil, ErrVerificationTokenInvalid
+		}
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+	case domain.EmailTokenChange:
+		exists, err := s.userRepo.ExistsByEmail(ctx, verification.Email)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrEmailTaken
+		}
+		if err := s.userRepo.UpdateEmail(ctx, user.ID, verification.Email, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.verificationRepo.MarkConfirmed(ctx, verification.ID, now); err != nil {
+		return nil, err
+	}
+	verification.ConfirmedAt = &now
+
+	return verification, nil
+}
+
+// GetPendingEmail returns the address awaiting confirmation, if any.
+func (s *EmailVerificationService) GetPendingEmail(ctx context.Context, userID uuid.UUID) (*string, error) {
+	verification, err := s.verificationRepo.GetPending(ctx, userID, domain.EmailTokenChange)
+	if err != nil || verification == nil {
+		return nil, err
+	}
+	return &verification.Email, nil
+}
+
+func (s *EmailVerificationService) createToken(ctx context.Context, userID uuid.UUID, email string, purpose domain.EmailTokenPurpose) (string, error) {
+	rawToken := uuid.New().String() + uuid.New().String()
+
+	verification := &domain.EmailVerification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Email:     email,
+		Purpose:   purpose,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(emailTokenExpiry),
+	}
+
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+func (s *EmailVerificationService) confirmLink(token string) string {
+	return s.appConfig.FrontendURL + "/verify-email?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// NotificationConsumer turns talent submissions, verification outcomes and
+// registration reviews into notifications, sent over the channels each
+// recipient chose. Rejected applicants no longer have an account and are
+// only emailed.
+type NotificationConsumer struct {
+	userRepo      *repository.UserRepository
+	notifications *NotificationService
+}
+
+func NewNotificationConsumer(userRepo *repository.UserRepository, notifications *NotificationService) *NotificationConsumer {
+	return &NotificationConsumer{userRepo: userRepo, notifications: notifications}
+}
+
+func (c *NotificationConsumer) Name() string { return "notification" }
+
+func (c *NotificationConsumer) Subscribes(eventType domain.EventType) bool {
+	switch eventType {
+	case domain.EventTalentSubmitted, domain.EventTalentUpdated,
+		domain.EventTalentSchoolApproved, domain.EventTalentApproved, domain.EventTalentRejected,
+		domain.EventTalentRevisionRequested, domain.EventUserRegistrationApproved,
+		domain.EventUserRegistrationRejected:
+		return true
+	}
+	return false
+}
+
+func (c *NotificationConsumer) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	switch event.EventType {
+	case domain.EventUserRegistrationApproved, domain.EventUserRegistrationRejected:
+		var payload domain.UserEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		if event.EventType == domain.EventUserRegistrationRejected {
+			return c.notifications.Email(ctx, "registration_rejected", payload.Email, payload.FullName,
+				"Alasan: "+reasonText(payload.Reason))
+		}
+		return c.create(ctx, payload.UserID, nil, domain.NotificationRegistrationApproved,
+			"Pendaftaran akun Anda telah disetujui. Selamat datang di SIPODI")
+	}
+
+	var payload domain.TalentEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	talentID := &payload.TalentID
+
+	switch event.EventType {
+	case domain.EventTalentSubmitted:
+		return c.notifyVerifiers(ctx, payload, "Talenta baru diajukan dan menunggu verifikasi")
+	case domain.EventTalentUpdated:
+		// Editing a talent that left the queue sends it back for verification
+		if payload.ToStatus != domain.TalentStatusPending || payload.FromStatus == nil ||
+			*payload.FromStatus == domain.TalentStatusPending || *payload.FromStatus == domain.TalentStatusDraft {
+			return nil
+		}
+		return c.notifyVerifiers(ctx, payload, "Talenta yang diperbarui diajukan kembali dan menunggu verifikasi")
+	case domain.EventTalentSchoolApproved:
+		if err := c.create(ctx, payload.UserID, talentID, domain.NotificationTalentSchoolApproved,
+			"Talenta Anda telah disetujui admin sekolah dan menunggu pengesahan dinas"); err != nil {
+			return err
+		}
+		ids, err := c.userRepo.ListActiveIDsByRole(ctx, domain.RoleSuperAdmin)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := c.create(ctx, id, talentID, domain.NotificationEndorsementRequested,
+				"Talenta tingkat tinggi menunggu pengesahan dinas"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case domain.EventTalentApproved:
+		return c.create(ctx, payload.UserID, talentID, domain.NotificationTalentApproved, "Talenta Anda telah disetujui")
+	case domain.EventTalentRejected:
+		return c.create(ctx, payload.UserID, talentID, domain.NotificationTalentRejected,
+			"Talenta Anda ditolak. Alasan: "+reasonText(payload.Reason))
+	case domain.EventTalentRevisionRequested:
+		return c.create(ctx, payload.UserID, talentID, domain.NotificationTalentNeedsRevision,
+			"Talenta Anda perlu diperbaiki. Catatan: "+reasonText(payload.Reason))
+	}
+	return nil
+}
+
+// notifyVerifiers alerts the admins of the owner's school that a talent
+// entered their queue. Schools without an admin are verified by super_admin.
+func (c *NotificationConsumer) notifyVerifiers(ctx context.Context, payload domain.TalentEventPayload, message string) error {
+	if payload.ToStatus != domain.TalentStatusPending {
+		return nil
+	}
+	owner, err := c.userRepo.GetByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	var ids []uuid.UUID
+	if owner != nil && owner.SchoolID != nil {
+		ids, err = c.userRepo.ListActiveIDsBySchoolAndRole(ctx, *owner.SchoolID, domain.RoleAdminSekolah)
+		if err != nil {
+			return err
+		}
+	}
+	if len(ids) == 0 {
+		ids, err = c.userRepo.ListActiveIDsByRole(ctx, domain.RoleSuperAdmin)
+		if err != nil {
+			return err
+		}
+	}
+
+	if owner != nil {
+		message += ": " + owner.FullName
+	}
+	for _, id := range ids {
+		if id == payload.UserID {
+			continue
+		}
+		if err := c.create(ctx, id, &payload.TalentID, domain.NotificationTalentSubmitted, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *NotificationConsumer) create(ctx context.Context, userID uuid.UUID, talentID *uuid.UUID, notifType domain.NotificationType, message string) error {
+	return c.notifications.Notify(ctx, &domain.Notification{
+		UserID:   userID,
+		TalentID: talentID,
+		Type:     notifType,
+		Message:  message,
+	})
+}
+
+// AuditConsumer keeps every domain event in the audit log.
+type AuditConsumer struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditConsumer(auditRepo *repository.AuditRepository) *AuditConsumer {
+	return &AuditConsumer{auditRepo: auditRepo}
+}
+
+func (c *AuditConsumer) Name() string { return "audit" }
+
+func (c *AuditConsumer) Subscribes(eventType domain.EventType) bool { return true }
+
+func (c *AuditConsumer) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	return c.auditRepo.Record(ctx, event)
+}
+
+// WebhookConsumer posts every domain event to an external endpoint. The
+// body is signed with HMAC-SHA256 in X-SIPODI-Signature, and
+// X-SIPODI-Delivery carries the event ID so receivers can drop duplicates.
+type WebhookConsumer struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookConsumer(cfg config.EventsConfig) *WebhookConsumer {
+	return &WebhookConsumer{
+		url:    cfg.WebhookURL,
+		secret: cfg.WebhookSecret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *WebhookConsumer) Name() string { return "webhook" }
+
+func (c *WebhookConsumer) Subscribes(eventType domain.EventType) bool { return true }
+
+func (c *WebhookConsumer) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(c.secret))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SIPODI-Event", string(event.EventType))
+	req.Header.Set("X-SIPODI-Delivery", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-SIPODI-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func reasonText(reason *string) string {
+	if reason == nil || *reason == "" {
+		return "-"
+	}
+	return *reason
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// NotificationHub relays the notification changes Postgres announces with
+// NOTIFY to the notification streams open on this API instance. Every
+// instance runs its own hub, so a notification written anywhere reaches the
+// user's streams everywhere.
+type NotificationHub struct {
+	notificationRepo *repository.NotificationRepository
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*NotificationSubscription]struct{}
+	closed      bool
+}
+
+// NotificationSubscription receives a signal on C whenever the user's
+// notifications may have changed. Signals coalesce, so a slow stream never
+// blocks the hub. C is closed when the hub stops.
+type NotificationSubscription struct {
+	UserID uuid.UUID
+	C      chan struct{}
+}
+
+func NewNotificationHub(notificationRepo *repository.NotificationRepository) *NotificationHub {
+	return &NotificationHub{
+		notificationRepo: notificationRepo,
+		subscribers:      make(map[uuid.UUID]map[*NotificationSubscription]struct{}),
+	}
+}
+
+// Run listens for changes until ctx ends, reconnecting after failures, and
+// then closes every subscription. After each (re)connect all streams are
+// signalled so they catch up on anything missed meanwhile.
+func (h *NotificationHub) Run(ctx context.Context) {
+	defer h.close()
+	for {
+		err := h.notificationRepo.Listen(ctx, h.signalAll, h.signal)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("notification listener stopped: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (h *NotificationHub) Subscribe(userID uuid.UUID) *NotificationSubscription {
+	sub := &NotificationSubscription{UserID: userID, C: make(chan struct{}, 1)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.C)
+		return sub
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*NotificationSubscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+	return sub
+}
+
+func (h *NotificationHub) Unsubscribe(sub *NotificationSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs, ok := h.subscribers[sub.UserID]
+	if !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.UserID)
+	}
+}
+
+func (h *NotificationHub) signal(userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers[userID] {
+		notify(sub)
+	}
+}
+
+func (h *NotificationHub) signalAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			notify(sub)
+		}
+	}
+}
+
+func (h *NotificationHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			close(sub.C)
+		}
+	}
+	h.subscribers = make(map[uuid.UUID]map[*NotificationSubscription]struct{})
+	h.closed = true
+}
+
+// notify signals sub unless a signal is already pending.
+func notify(sub *NotificationSubscription) {
+	select {
+	case sub.C <- struct{}{}:
+	default:
+	}
+}
@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
 	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/mailer"
 	"github.com/sipodi/backend/internal/repository"
 )
 
@@ -15,14 +18,201 @@ var (
 
 type NotificationService struct {
 	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	emailQueueRepo   *repository.EmailQueueRepository
+	renderer         *mailer.Renderer
+	appConfig        config.AppConfig
 }
 
-func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+func NewNotificationService(
+	notificationRepo *repository.NotificationRepository,
+	userRepo *repository.UserRepository,
+	emailQueueRepo *repository.EmailQueueRepository,
+	renderer *mailer.Renderer,
+	appConfig config.AppConfig,
+) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		emailQueueRepo:   emailQueueRepo,
+		renderer:         renderer,
+		appConfig:        appConfig,
 	}
 }
 
+// Notify delivers a notification over the channels the recipient chose for
+// its type: an in-app notification, an email in the send queue and/or an
+// item for the daily digest. details are extra lines shown only in the
+// email. Everything is written with the connection in ctx, so a notification
+// raised inside a transaction is only sent once that transaction commits.
+func (s *NotificationService) Notify(ctx context.Context, n *domain.Notification, details ...string) error {
+	pref, err := s.preference(ctx, n.UserID, n.Type)
+	if err != nil {
+		return err
+	}
+
+	if pref.InApp {
+		n.ID = uuid.New()
+		if err := s.notificationRepo.Create(ctx, n); err != nil {
+			return err
+		}
+	}
+	if !pref.Email && !pref.Digest {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	// Security alerts still reach a deactivated account, so its owner
+	// learns about the deactivation
+	if user == nil || (!user.IsActive && n.Type != domain.NotificationSecurityAlert) {
+		return nil
+	}
+
+	if pref.Email {
+		msg, err := s.renderer.Render(string(n.Type), mailer.TemplateData{
+			AppName:     s.appConfig.Name,
+			FrontendURL: s.appConfig.FrontendURL,
+			FullName:    user.FullName,
+			Message:     n.Message,
+			Details:     details,
+		})
+		if err != nil {
+			return fmt.Errorf("render %s email: %w", n.Type, err)
+		}
+		err = s.emailQueueRepo.Enqueue(ctx, &domain.EmailJob{
+			ID:      uuid.New(),
+			UserID:  &user.ID,
+			To:      user.Email,
+			Subject: msg.Subject,
+			Text:    msg.Text,
+			HTML:    msg.HTML,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if pref.Digest {
+		return s.emailQueueRepo.AddDigestItem(ctx, &domain.DigestItem{
+			ID:       uuid.New(),
+			UserID:   user.ID,
+			Type:     n.Type,
+			Message:  n.Message,
+			TalentID: n.TalentID,
+		})
+	}
+	return nil
+}
+
+// Email queues an email rendered from template for someone without an
+// account to notify, such as an applicant whose registration was rejected.
+func (s *NotificationService) Email(ctx context.Context, template, to, fullName, message string, details ...string) error {
+	msg, err := s.renderer.Render(template, mailer.TemplateData{
+		AppName:     s.appConfig.Name,
+		FrontendURL: s.appConfig.FrontendURL,
+		FullName:    fullName,
+		Message:     message,
+		Details:     details,
+	})
+	if err != nil {
+		return fmt.Errorf("render %s email: %w", template, err)
+	}
+	return s.emailQueueRepo.Enqueue(ctx, &domain.EmailJob{
+		ID:      uuid.New(),
+		To:      to,
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+	})
+}
+
+// NotifyOnce is Notify for notifications that must reach a user only once,
+// such as a periodic digest. key identifies the notification; repeated calls
+// with a key the user already got do nothing. Run it in a transaction so a
+// failed send does not use up the key.
+func (s *NotificationService) NotifyOnce(ctx context.Context, key string, n *domain.Notification, details ...string) error {
+	claimed, err := s.notificationRepo.ClaimKey(ctx, n.UserID, key)
+	if err != nil || !claimed {
+		return err
+	}
+	return s.Notify(ctx, n, details...)
+}
+
+// ClaimedKey returns the users who already got the notification identified
+// by key, so a job can skip them before doing any work.
+func (s *NotificationService) ClaimedKey(ctx context.Context, key string) (map[uuid.UUID]bool, error) {
+	return s.notificationRepo.ListKeyClaimants(ctx, key)
+}
+
+func (s *NotificationService) preference(ctx context.Context, userID uuid.UUID, notifType domain.NotificationType) (domain.NotificationPreference, error) {
+	pref, err := s.notificationRepo.GetPreference(ctx, userID, notifType)
+	if err != nil {
+		return domain.NotificationPreference{}, err
+	}
+	if pref == nil {
+		return notifType.DefaultPreference(), nil
+	}
+	return *pref, nil
+}
+
+// GetPreferences returns the user's channels for every notification type,
+// filling in the defaults for types the user never changed.
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	saved, err := s.notificationRepo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[domain.NotificationType]domain.NotificationPreference, len(saved))
+	for _, pref := range saved {
+		byType[pref.Type] = pref
+	}
+
+	prefs := make([]domain.NotificationPreference, 0, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		pref, ok := byType[t]
+		if !ok {
+			pref = t.DefaultPreference()
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences saves the given types and leaves the others alone.
+// Security alerts always go out by email.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *domain.UpdateNotificationPreferencesRequest) ([]domain.NotificationPreference, error) {
+	var errs ValidationErrors
+	if len(req.Preferences) == 0 {
+		errs.add("preferences", "Minimal satu preferensi harus diisi")
+	}
+	seen := make(map[domain.NotificationType]bool)
+	for i, pref := range req.Preferences {
+		field := fmt.Sprintf("preferences[%d].type", i)
+		switch {
+		case !pref.Type.IsValid():
+			errs.add(field, "Tipe notifikasi tidak valid")
+		case seen[pref.Type]:
+			errs.add(field, "Tipe notifikasi duplikat")
+		case pref.Type == domain.NotificationSecurityAlert && !pref.Email:
+			errs.add(fmt.Sprintf("preferences[%d].email", i), "Email peringatan keamanan tidak dapat dinonaktifkan")
+		}
+		seen[pref.Type] = true
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	for _, pref := range req.Preferences {
+		if err := s.notificationRepo.UpsertPreference(ctx, userID, pref); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
 func (s *NotificationService) List(ctx context.Context, userID uuid.UUID, params domain.ListParams) ([]domain.Notification, int, error) {
 	return s.notificationRepo.List(ctx, userID, params)
 }
@@ -49,3 +239,13 @@ func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID uuid.UUI
 func (s *NotificationService) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
 	return s.notificationRepo.CountUnread(ctx, userID)
 }
+
+// ListSince returns up to limit notifications of the user created after seq,
+// oldest first. The notification stream uses it to push and replay.
+func (s *NotificationService) ListSince(ctx context.Context, userID uuid.UUID, seq int64, limit int) ([]domain.Notification, error) {
+	return s.notificationRepo.ListSince(ctx, userID, seq, limit)
+}
+
+func (s *NotificationService) LatestSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.LatestSeq(ctx, userID)
+}
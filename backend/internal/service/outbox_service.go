@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// EventConsumer handles domain events delivered from the outbox. Delivery is
+// at least once: an event may reach Handle again after a failure, so Handle
+// must tolerate duplicates. Database writes made through ctx share the
+// transaction that marks the delivery done and are never duplicated.
+type EventConsumer interface {
+	Name() string
+	Subscribes(eventType domain.EventType) bool
+	Handle(ctx context.Context, event domain.OutboxEvent) error
+}
+
+// OutboxService writes domain events to the outbox in the transaction of
+// the state change and dispatches them to the registered consumers later.
+type OutboxService struct {
+	outboxRepo  *repository.OutboxRepository
+	txManager   *repository.TxManager
+	consumers   []EventConsumer
+	maxAttempts int
+	batchSize   int
+	retention   time.Duration
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository, txManager *repository.TxManager, cfg config.EventsConfig) *OutboxService {
+	return &OutboxService{
+		outboxRepo:  outboxRepo,
+		txManager:   txManager,
+		maxAttempts: cfg.MaxAttempts,
+		batchSize:   cfg.BatchSize,
+		retention:   cfg.Retention,
+	}
+}
+
+// Register adds consumers. Only events published afterwards reach them, so
+// register every consumer before serving requests.
+func (s *OutboxService) Register(consumers ...EventConsumer) {
+	s.consumers = append(s.consumers, consumers...)
+}
+
+// Publish stores an event for every consumer subscribed to its type. Call it
+// inside the transaction of the change so the event exists exactly when the
+// change does.
+func (s *OutboxService) Publish(ctx context.Context, eventType domain.EventType, aggregateID uuid.UUID, actorID *uuid.UUID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var consumers []string
+	for _, c := range s.consumers {
+		if c.Subscribes(eventType) {
+			consumers = append(consumers, c.Name())
+		}
+	}
+
+	event := &domain.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		ActorID:     actorID,
+		Payload:     data,
+	}
+	return s.outboxRepo.Insert(ctx, event, consumers)
+}
+
+// Dispatch delivers up to the batch size of due events, then prunes events
+// past retention.
+func (s *OutboxService) Dispatch(ctx context.Context) error {
+	for i := 0; i < s.batchSize; i++ {
+		found, err := s.dispatchOne(ctx)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+	}
+
+	if _, err := s.outboxRepo.Prune(ctx, time.Now().Add(-s.retention), s.maxAttempts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// dispatchOne hands one due delivery to its consumer while holding its row,
+// so no other dispatcher picks it up meanwhile. The consumer runs in a
+// savepoint: a failure rolls back whatever it wrote, and the attempt is
+// recorded for a retry before the row is released.
+func (s *OutboxService) dispatchOne(ctx context.Context) (bool, error) {
+	found := false
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		delivery, err := s.outboxRepo.ClaimDue(ctx, s.maxAttempts)
+		if err != nil || delivery == nil {
+			return err
+		}
+		found = true
+
+		handleErr := s.txManager.WithSavepoint(ctx, func(ctx context.Context) error {
+			consumer := s.consumer(delivery.Consumer)
+			if consumer == nil {
+				return fmt.Errorf("no consumer named %q", delivery.Consumer)
+			}
+			return consumer.Handle(ctx, delivery.Event)
+		})
+		if handleErr != nil {
+			log.Printf("failed to deliver %s event %d to %s (attempt %d): %v",
+				delivery.Event.EventType, delivery.Event.ID, delivery.Consumer, delivery.Attempts+1, handleErr)
+			return s.outboxRepo.MarkFailed(ctx, delivery.Event.ID, delivery.Consumer, handleErr.Error())
+		}
+		return s.outboxRepo.MarkDelivered(ctx, delivery.Event.ID, delivery.Consumer)
+	})
+	return found, err
+}
+
+func (s *OutboxService) consumer(name string) EventConsumer {
+	for _, c := range s.consumers {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var (
+	ErrRegistrationNotFound = errors.New("registration not found")
+	ErrRegistrationReviewed = errors.New("registration already reviewed")
+)
+
+type RegistrationService struct {
+	registrationRepo    *repository.RegistrationRepository
+	userRepo            *repository.UserRepository
+	schoolRepo          *repository.SchoolRepository
+	verificationService *EmailVerificationService
+	txManager           *repository.TxManager
+	outbox              *OutboxService
+}
+
+func NewRegistrationService(
+	registrationRepo *repository.RegistrationRepository,
+	userRepo *repository.UserRepository,
+	schoolRepo *repository.SchoolRepository,
+	verificationService *EmailVerificationService,
+	txManager *repository.TxManager,
+	outbox *OutboxService,
+) *RegistrationService {
+	return &RegistrationService{
+		registrationRepo:    registrationRepo,
+		userRepo:            userRepo,
+		schoolRepo:          schoolRepo,
+		verificationService: verificationService,
+		txManager:           txManager,
+		outbox:              outbox,
+	}
+}
+
+// Register creates an inactive GTK account together with a pending
+// registration that the school's admin_sekolah has to review.
+func (s *RegistrationService) Register(ctx context.Context, req domain.RegisterRequest) (*domain.UserRegistration, error) {
+	school, err := s.schoolRepo.GetByNPSN(ctx, req.NPSN)
+	if err != nil {
+		return nil, err
+	}
+	if school == nil {
+		return nil, ErrSchoolNotFound
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailTaken
+	}
+
+	if req.NUPTK != nil && *req.NUPTK != "" {
+		exists, err := s.userRepo.ExistsByNUPTK(ctx, *req.NUPTK)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrNUPTKTaken
+		}
+	}
+
+	if req.NIP != nil && *req.NIP != "" {
+		exists, err := s.userRepo.ExistsByNIP(ctx, *req.NIP)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrNIPTaken
+		}
+	}
+
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var birthDate *time.Time
+	if req.BirthDate != nil && *req.BirthDate != "" {
+		t, err := time.Parse("2006-01-02", *req.BirthDate)
+		if err == nil {
+			birthDate = &t
+		}
+	}
+
+	user := &domain.User{
+		ID:           uuid.New(),
+		Email:        req.Email,
+		PasswordHash: passwordHash,
+		Role:         domain.RoleGTK,
+		FullName:     req.FullName,
+		NUPTK:        emptyToNil(req.NUPTK),
+		NIP:          emptyToNil(req.NIP),
+		Gender:       req.Gender,
+		BirthDate:    birthDate,
+		GTKType:      req.GTKType,
+		Position:     req.Position,
+		SchoolID:     &school.ID,
+		IsActive:     false,
+	}
+
+	registration := &domain.UserRegistration{
+		ID:       uuid.New(),
+		UserID:   &user.ID,
+		SchoolID: school.ID,
+		Email:    user.Email,
+		FullName: user.FullName,
+		Status:   domain.RegistrationStatusPending,
+	}
+
+	// The account and its registration are created together so there is
+	// never an orphan account that can not be reviewed
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		if err := s.registrationRepo.Create(ctx, registration); err != nil {
+			return err
+		}
+		return s.outbox.Publish(ctx, domain.EventUserRegistered, user.ID, nil, userEventPayload(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verificationService.SendVerification(ctx, user); err != nil {
+		log.Printf("failed to send verification email to %s: %v", user.Email, err)
+	}
+
+	return registration, nil
+}
+
+func (s *RegistrationService) GetByID(ctx context.Context, id uuid.UUID) (*domain.UserRegistration, error) {
+	registration, err := s.registrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil {
+		return nil, ErrRegistrationNotFound
+	}
+	return registration, nil
+}
+
+func (s *RegistrationService) List(ctx context.Context, params domain.ListParams) ([]domain.UserRegistration, int, error) {
+	return s.registrationRepo.List(ctx, params)
+}
+
+func (s *RegistrationService) Approve(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID) (*domain.UserRegistration, error) {
+	registration, err := s.registrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil {
+		return nil, ErrRegistrationNotFound
+	}
+	if registration.Status != domain.RegistrationStatusPending {
+		return nil, ErrRegistrationReviewed
+	}
+
+	if registration.UserID == nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.userRepo.GetByID(ctx, *registration.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.IsActive = true
+	now := time.Now()
+	registration.Status = domain.RegistrationStatusApproved
+	registration.ReviewedBy = &reviewerID
+	registration.ReviewedAt = &now
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.review(ctx, registration); err != nil {
+			return err
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.outbox.Publish(ctx, domain.EventUserRegistrationApproved, user.ID, &reviewerID, userEventPayload(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+// Reject records the rejection and deletes the pending account, so the GTK
+// can register again with the same email, NUPTK and NIP once the mistake
+// is fixed. The applicant learns the reason by email.
+func (s *RegistrationService) Reject(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, reason string) (*domain.UserRegistration, error) {
+	registration, err := s.registrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil {
+		return nil, ErrRegistrationNotFound
+	}
+	if registration.Status != domain.RegistrationStatusPending {
+		return nil, ErrRegistrationReviewed
+	}
+
+	now := time.Now()
+	registration.Status = domain.RegistrationStatusRejected
+	registration.ReviewedBy = &reviewerID
+	registration.ReviewedAt = &now
+	registration.RejectionReason = &reason
+
+	payload := domain.UserEventPayload{
+		Email:    registration.Email,
+		FullName: registration.FullName,
+		Role:     domain.RoleGTK,
+		SchoolID: &registration.SchoolID,
+		Reason:   &reason,
+	}
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.review(ctx, registration); err != nil {
+			return err
+		}
+		if registration.UserID != nil {
+			payload.UserID = *registration.UserID
+			if err := s.userRepo.Delete(ctx, *registration.UserID); err != nil {
+				return err
+			}
+		}
+		return s.outbox.Publish(ctx, domain.EventUserRegistrationRejected, payload.UserID, &reviewerID, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	registration.UserID = nil
+
+	return registration, nil
+}
+
+// review stores the decision on a registration that must still be pending.
+// The status checks of Approve and Reject run before their transaction, so a
+// reviewer who lost the race gets ErrRegistrationReviewed here instead.
+func (s *RegistrationService) review(ctx context.Context, registration *domain.UserRegistration) error {
+	updated, err := s.registrationRepo.Update(ctx, registration)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrRegistrationReviewed
+	}
+	return nil
+}
+
+func (s *RegistrationService) GetSchoolByNPSN(ctx context.Context, npsn string) (*domain.School, error) {
+	school, err := s.schoolRepo.GetByNPSN(ctx, npsn)
+	if err != nil {
+		return nil, err
+	}
+	if school == nil {
+		return nil, ErrSchoolNotFound
+	}
+	return school, nil
+}
+
+func (s *RegistrationService) GetSchool(ctx context.Context, schoolID uuid.UUID) (*domain.School, error) {
+	return s.schoolRepo.GetByID(ctx, schoolID)
+}
+
+func (s *RegistrationService) GetUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
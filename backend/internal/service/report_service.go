@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// ReportService builds the periodic reports the dinas has to submit.
+type ReportService struct {
+	reportRepo *repository.ReportRepository
+	pkb        config.PKBConfig
+}
+
+func NewReportService(reportRepo *repository.ReportRepository, pkb config.PKBConfig) *ReportService {
+	return &ReportService{reportRepo: reportRepo, pkb: pkb}
+}
+
+// PKB reports each active GTK's professional development load for a year,
+// the current one unless the year filter says otherwise. Days of approved
+// trainings are converted to lesson hours (JP) and compared to the annual
+// target.
+func (s *ReportService) PKB(ctx context.Context, params domain.ListParams) ([]domain.PKBReportEntry, int, error) {
+	var errs ValidationErrors
+	if params.Filters["year"] == "" {
+		params.Filters["year"] = strconv.Itoa(time.Now().Year())
+	}
+	year, err := strconv.Atoi(params.Filters["year"])
+	if err != nil || year < 1900 || year > 9999 {
+		errs.add("year", "Tahun tidak valid")
+	}
+	if schoolID := params.Filters["school_id"]; schoolID != "" {
+		if _, err := uuid.Parse(schoolID); err != nil {
+			errs.add("school_id", "ID sekolah tidak valid")
+		}
+	}
+	if belowTarget := params.Filters["below_target"]; belowTarget != "" {
+		b, err := strconv.ParseBool(belowTarget)
+		if err != nil {
+			errs.add("below_target", "below_target harus true atau false")
+		}
+		params.Filters["below_target"] = strconv.FormatBool(b)
+	}
+	if len(errs) > 0 {
+		return nil, 0, errs
+	}
+
+	entries, total, err := s.reportRepo.PKB(ctx, params, s.pkb.JPPerDay, s.pkb.AnnualTargetJP)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range entries {
+		entries[i].Year = year
+	}
+	return entries, total, nil
+}
+
+// pkbExportPageSize is how many rows ExportPKB reads per query.
+const pkbExportPageSize = 1000
+
+// ExportPKB returns every row of the PKB report for the filters, reading it
+// page by page so an export of the whole province is never cut short.
+func (s *ReportService) ExportPKB(ctx context.Context, params domain.ListParams) ([]domain.PKBReportEntry, error) {
+	params.Limit = pkbExportPageSize
+	var entries []domain.PKBReportEntry
+	for params.Page = 1; ; params.Page++ {
+		page, total, err := s.PKB(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+		if len(page) < params.Limit || len(entries) >= total {
+			return entries, nil
+		}
+	}
+}
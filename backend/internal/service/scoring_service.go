@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var ErrScoringSchemeNotFound = errors.New("scoring scheme not found")
+
+// ScoringService keeps the scoring scheme and ranks GTK and schools by the
+// points of their approved talents. Scores are computed when the leaderboard
+// is read, so a scheme change applies to every talent at once.
+type ScoringService struct {
+	scoringRepo    *repository.ScoringRepository
+	talentTypeRepo *repository.TalentTypeRepository
+}
+
+func NewScoringService(scoringRepo *repository.ScoringRepository, talentTypeRepo *repository.TalentTypeRepository) *ScoringService {
+	return &ScoringService{scoringRepo: scoringRepo, talentTypeRepo: talentTypeRepo}
+}
+
+func (s *ScoringService) GetScheme(ctx context.Context) (*domain.ScoringSchemeSettings, error) {
+	settings, err := s.scoringRepo.GetScheme(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrScoringSchemeNotFound
+	}
+	return settings, nil
+}
+
+// UpdateScheme replaces the scoring scheme as a whole.
+func (s *ScoringService) UpdateScheme(ctx context.Context, scheme domain.ScoringScheme, updatedBy uuid.UUID) (*domain.ScoringSchemeSettings, error) {
+	errs, err := s.validateScheme(ctx, &scheme)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	settings := &domain.ScoringSchemeSettings{Scheme: scheme, UpdatedBy: &updatedBy}
+	if err := s.scoringRepo.SaveScheme(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *ScoringService) validateScheme(ctx context.Context, scheme *domain.ScoringScheme) (ValidationErrors, error) {
+	var errs ValidationErrors
+
+	for level, points := range scheme.LevelPoints {
+		if !level.IsValid() {
+			errs.add("level_points."+string(level), "Jenjang harus kota, provinsi, nasional, atau internasional")
+		} else if points < 0 {
+			errs.add("level_points."+string(level), "Poin tidak boleh negatif")
+		}
+	}
+
+	for i, m := range scheme.AchievementMultipliers {
+		field := fmt.Sprintf("achievement_multipliers[%d]", i)
+		if m.Pattern == "" {
+			errs.add(field+".pattern", "Pola prestasi wajib diisi")
+		} else {
+			valid, err := s.scoringRepo.IsValidPattern(ctx, m.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !valid {
+				errs.add(field+".pattern", "Pola prestasi bukan regular expression yang valid")
+			}
+		}
+		if m.Multiplier < 0 {
+			errs.add(field+".multiplier", "Pengali tidak boleh negatif")
+		}
+	}
+	if scheme.DefaultAchievementMultiplier < 0 {
+		errs.add("default_achievement_multiplier", "Pengali tidak boleh negatif")
+	}
+
+	for code, rule := range scheme.Types {
+		field := "types." + string(code)
+		def, err := s.talentTypeRepo.GetByCode(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if def == nil {
+			errs.add(field, "Jenis talenta tidak valid")
+			continue
+		}
+		if rule.Points < 0 || rule.PointsPerDay < 0 || rule.LevelFactor < 0 {
+			errs.add(field, "Poin dan faktor tidak boleh negatif")
+		}
+		if rule.MaxDays < 0 {
+			errs.add(field+".max_days", "Batas hari tidak boleh negatif")
+		}
+	}
+
+	if scheme.LevelPoints == nil {
+		scheme.LevelPoints = map[domain.CompetitionLevel]float64{}
+	}
+	if scheme.AchievementMultipliers == nil {
+		scheme.AchievementMultipliers = []domain.AchievementMultiplier{}
+	}
+	if scheme.Types == nil {
+		scheme.Types = map[domain.TalentType]domain.TalentTypeScore{}
+	}
+	return errs, nil
+}
+
+// GTKLeaderboard ranks GTK over the filters year, field, talent_type,
+// school_status and school_id.
+func (s *ScoringService) GTKLeaderboard(ctx context.Context, params domain.ListParams) ([]domain.GTKLeaderboardEntry, int, error) {
+	if errs := validateLeaderboardFilters(params); len(errs) > 0 {
+		return nil, 0, errs
+	}
+	return s.scoringRepo.GTKLeaderboard(ctx, params)
+}
+
+// SchoolLeaderboard ranks schools by total points, or by points per GTK
+// when byAverage is set.
+func (s *ScoringService) SchoolLeaderboard(ctx context.Context, params domain.ListParams, byAverage bool) ([]domain.SchoolLeaderboardEntry, int, error) {
+	if errs := validateLeaderboardFilters(params); len(errs) > 0 {
+		return nil, 0, errs
+	}
+	return s.scoringRepo.SchoolLeaderboard(ctx, params, byAverage)
+}
+
+func validateLeaderboardFilters(params domain.ListParams) ValidationErrors {
+	var errs ValidationErrors
+	if year := params.Filters["year"]; year != "" {
+		if y, err := strconv.Atoi(year); err != nil || y < 1900 || y > 9999 {
+			errs.add("year", "Tahun tidak valid")
+		}
+	}
+	if field := params.Filters["field"]; field != "" && !domain.TalentField(field).IsValid() {
+		errs.add("field", "Bidang tidak valid")
+	}
+	if status := params.Filters["school_status"]; status != "" && !domain.SchoolStatus(status).IsValid() {
+		errs.add("school_status", "Status sekolah harus negeri atau swasta")
+	}
+	if schoolID := params.Filters["school_id"]; schoolID != "" {
+		if _, err := uuid.Parse(schoolID); err != nil {
+			errs.add("school_id", "ID sekolah tidak valid")
+		}
+	}
+	return errs
+}
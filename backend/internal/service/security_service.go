@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+type SecurityService struct {
+	securityRepo  *repository.SecurityRepository
+	userRepo      *repository.UserRepository
+	notifications *NotificationService
+}
+
+func NewSecurityService(
+	securityRepo *repository.SecurityRepository,
+	userRepo *repository.UserRepository,
+	notifications *NotificationService,
+) *SecurityService {
+	return &SecurityService{
+		securityRepo:  securityRepo,
+		userRepo:      userRepo,
+		notifications: notifications,
+	}
+}
+
+var securityMessages = map[domain.SecurityEventType]string{
+	domain.SecurityPasswordChanged:    "Password akun Anda telah diubah",
+	domain.SecurityNewLogin:           "Login baru ke akun Anda dari perangkat atau alamat IP yang belum pernah digunakan",
+	domain.SecurityAccountDeactivated: "Akun Anda telah dinonaktifkan oleh admin",
+	domain.SecurityAccountReactivated: "Akun Anda telah diaktifkan kembali oleh admin",
+	domain.SecurityRoleChanged:        "Role akun Anda telah diubah",
+	domain.SecurityTokenReuseDetected: "Terdeteksi penggunaan ulang sesi login lama. Semua sesi telah diakhiri, silakan login ulang dan segera ganti password Anda",
+}
+
+// Record logs a security event and alerts the affected user; the alert
+// always goes out by email as well. It is best effort: failures are logged
+// so the action that triggered the event is never rolled back because of
+// them.
+func (s *SecurityService) Record(ctx context.Context, user *domain.User, eventType domain.SecurityEventType, actorID *uuid.UUID, meta domain.RequestMeta, detail string) {
+	event := &domain.SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		ActorID:   actorID,
+		EventType: eventType,
+		IPAddress: emptyToNil(&meta.IPAddress),
+		UserAgent: emptyToNil(&meta.UserAgent),
+		Detail:    emptyToNil(&detail),
+	}
+	if err := s.securityRepo.CreateEvent(ctx, event); err != nil {
+		log.Printf("failed to record security event %s for user %s: %v", eventType, user.ID, err)
+		return
+	}
+
+	message := securityMessages[eventType]
+	if detail != "" {
+		message += " (" + detail + ")"
+	}
+
+	notification := &domain.Notification{
+		UserID:  user.ID,
+		Type:    domain.NotificationSecurityAlert,
+		Message: message,
+	}
+	if err := s.notifications.Notify(ctx, notification, securityDetails(event)...); err != nil {
+		log.Printf("failed to send security alert to user %s: %v", user.ID, err)
+	}
+}
+
+// CheckLoginSource records a login source and raises a new_login event when
+// the IP address or device has not been seen for this user. The very first
+// login of an account is not reported.
+func (s *SecurityService) CheckLoginSource(ctx context.Context, user *domain.User, meta domain.RequestMeta) {
+	hasSources, err := s.securityRepo.HasLoginSources(ctx, user.ID)
+	if err != nil {
+		log.Printf("failed to check login sources for user %s: %v", user.ID, err)
+		return
+	}
+
+	known, err := s.securityRepo.IsKnownLoginSource(ctx, user.ID, meta.IPAddress, meta.UserAgent)
+	if err != nil {
+		log.Printf("failed to check login sources for user %s: %v", user.ID, err)
+		return
+	}
+
+	if err := s.securityRepo.TouchLoginSource(ctx, user.ID, meta.IPAddress, meta.UserAgent); err != nil {
+		log.Printf("failed to save login source for user %s: %v", user.ID, err)
+	}
+
+	if hasSources && !known {
+		s.Record(ctx, user, domain.SecurityNewLogin, nil, meta, "IP "+meta.IPAddress)
+	}
+}
+
+func (s *SecurityService) ListEvents(ctx context.Context, params domain.ListParams) ([]domain.SecurityEvent, int, error) {
+	return s.securityRepo.ListEvents(ctx, params)
+}
+
+func (s *SecurityService) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.userRepo.GetByID(ctx, id)
+}
+
+// securityDetails lists when and from where the event happened, for the
+// alert email.
+func securityDetails(event *domain.SecurityEvent) []string {
+	details := []string{"Waktu: " + event.CreatedAt.Format(time.RFC1123)}
+	if event.IPAddress != nil {
+		details = append(details, "Alamat IP: "+*event.IPAddress)
+	}
+	if event.UserAgent != nil {
+		details = append(details, "Perangkat: "+*event.UserAgent)
+	}
+	return details
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+// Batch verification: approve or reject many talents selected by ID or by
+// list filters, in one transaction or item by item.
+
+// errBatchItemFailed rolls back the transaction of a failed batch item.
+var errBatchItemFailed = errors.New("batch item failed")
+
+type batchIDKey struct{}
+
+// withBatchID tags the history entries recorded under ctx with a batch.
+func withBatchID(ctx context.Context, batchID uuid.UUID) context.Context {
+	return context.WithValue(ctx, batchIDKey{}, batchID)
+}
+
+func batchIDFrom(ctx context.Context) *uuid.UUID {
+	if batchID, ok := ctx.Value(batchIDKey{}).(uuid.UUID); ok {
+		return &batchID
+	}
+	return nil
+}
+
+// BatchLimit is the most talents one batch may touch.
+func (s *TalentService) BatchLimit() int {
+	return s.batchLimit
+}
+
+// PreviewBatch reports how many talents a selection matches without
+// changing anything. schoolID limits an admin_sekolah to their school.
+func (s *TalentService) PreviewBatch(ctx context.Context, sel domain.BatchSelection, schoolID *uuid.UUID) (*domain.BatchPreviewResponse, error) {
+	params, errs := batchParams(sel, schoolID)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	preview := &domain.BatchPreviewResponse{Limit: s.batchLimit}
+	if params == nil {
+		preview.IDs = uniqueIDs(sel.IDs)
+		preview.MatchedCount = len(preview.IDs)
+		return preview, nil
+	}
+
+	count, err := s.talentRepo.CountMatching(ctx, *params)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.talentRepo.ListIDs(ctx, *params, s.batchLimit)
+	if err != nil {
+		return nil, err
+	}
+	preview.MatchedCount = count
+	preview.IDs = ids
+	if preview.IDs == nil {
+		preview.IDs = []uuid.UUID{}
+	}
+	return preview, nil
+}
+
+func (s *TalentService) BatchApprove(ctx context.Context, req domain.BatchApproveRequest, verifierID uuid.UUID, verifierRole domain.UserRole, schoolID *uuid.UUID) (*domain.BatchResult, error) {
+	return s.runBatch(ctx, req.BatchSelection, req.Mode, schoolID, func(ctx context.Context, id uuid.UUID) (*domain.Talent, error) {
+		return s.approve(ctx, id, verifierID, verifierRole)
+	})
+}
+
+func (s *TalentService) BatchReject(ctx context.Context, req domain.BatchRejectRequest, verifierID uuid.UUID, verifierRole domain.UserRole, schoolID *uuid.UUID) (*domain.BatchResult, error) {
+	return s.runBatch(ctx, req.BatchSelection, req.Mode, schoolID, func(ctx context.Context, id uuid.UUID) (*domain.Talent, error) {
+		return s.reject(ctx, id, verifierID, verifierRole, req.RejectionReason)
+	})
+}
+
+// runBatch applies fn to every selected talent. In best_effort mode each item
+// commits on its own; in all_or_nothing mode the batch stops at the first
+// failure and everything done so far is rolled back. Every history entry of
+// the batch carries the same batch id.
+func (s *TalentService) runBatch(ctx context.Context, sel domain.BatchSelection, mode domain.BatchMode, schoolID *uuid.UUID, fn func(ctx context.Context, id uuid.UUID) (*domain.Talent, error)) (*domain.BatchResult, error) {
+	if mode == "" {
+		mode = domain.BatchModeBestEffort
+	}
+	if !mode.IsValid() {
+		return nil, ValidationErrors{{Field: "mode", Message: "Mode harus best_effort atau all_or_nothing"}}
+	}
+
+	ids, err := s.selectBatch(ctx, sel, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.BatchResult{
+		BatchID:   uuid.New(),
+		Mode:      mode,
+		FailedIDs: []domain.FailedItem{},
+		Results:   make([]domain.BatchItemResult, 0, len(ids)),
+	}
+	ctx = withBatchID(ctx, result.BatchID)
+
+	item := func(ctx context.Context, id uuid.UUID) domain.BatchItemResult {
+		talent, err := s.batchItem(ctx, id, schoolID, fn)
+		if err != nil {
+			return domain.BatchItemResult{ID: id, Outcome: domain.BatchItemFailed, Reason: batchFailureReason(id, err)}
+		}
+		return domain.BatchItemResult{ID: id, Outcome: domain.BatchItemSucceeded, Status: &talent.Status}
+	}
+
+	if mode == domain.BatchModeBestEffort {
+		for _, id := range ids {
+			var outcome domain.BatchItemResult
+			err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+				outcome = item(ctx, id)
+				if outcome.Outcome == domain.BatchItemFailed {
+					return errBatchItemFailed
+				}
+				return nil
+			})
+			if err != nil && err != errBatchItemFailed {
+				// The commit itself failed
+				outcome = domain.BatchItemResult{ID: id, Outcome: domain.BatchItemFailed, Reason: batchFailureReason(id, err)}
+			}
+			addBatchOutcome(result, outcome)
+		}
+		return result, nil
+	}
+
+	var attempted []domain.BatchItemResult
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		for _, id := range ids {
+			outcome := item(ctx, id)
+			attempted = append(attempted, outcome)
+			if outcome.Outcome == domain.BatchItemFailed {
+				return errBatchItemFailed
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for _, outcome := range attempted {
+			addBatchOutcome(result, outcome)
+		}
+		return result, nil
+	}
+
+	// Nothing was kept: the failed item explains why, the items before it
+	// were undone and the rest never ran. A failed commit fails them all.
+	for i, id := range ids {
+		var outcome domain.BatchItemResult
+		switch {
+		case i < len(attempted) && attempted[i].Outcome == domain.BatchItemFailed:
+			outcome = attempted[i]
+		case i < len(attempted) && err == errBatchItemFailed:
+			outcome = domain.BatchItemResult{ID: id, Outcome: domain.BatchItemRolledBack}
+		case i < len(attempted):
+			outcome = domain.BatchItemResult{ID: id, Outcome: domain.BatchItemFailed, Reason: batchFailureReason(id, err)}
+		default:
+			outcome = domain.BatchItemResult{ID: id, Outcome: domain.BatchItemSkipped}
+		}
+		addBatchOutcome(result, outcome)
+	}
+	return result, nil
+}
+
+// batchFailureReason explains a failed item the way the single-talent
+// endpoints would. Unexpected errors are logged, not shown.
+func batchFailureReason(id uuid.UUID, err error) string {
+	switch err {
+	case ErrTalentNotFound:
+		return "Talenta tidak ditemukan"
+	case ErrForbidden:
+		return "Anda hanya dapat memverifikasi talenta GTK di sekolah Anda"
+	case ErrAlreadyVerified:
+		return "Talenta sudah diverifikasi sebelumnya"
+	case ErrEndorsementRequired:
+		return "Talenta sudah disetujui sekolah dan menunggu pengesahan dinas"
+	case ErrRevisionPending:
+		return "Talenta sedang menunggu perbaikan dari GTK"
+	case ErrClaimedByOther:
+		return "Talenta sedang ditangani verifikator lain"
+	}
+	log.Printf("batch verification of talent %s failed: %v", id, err)
+	return "Terjadi kesalahan pada server"
+}
+
+func addBatchOutcome(result *domain.BatchResult, outcome domain.BatchItemResult) {
+	result.Results = append(result.Results, outcome)
+	switch outcome.Outcome {
+	case domain.BatchItemSucceeded:
+		result.SuccessCount++
+	case domain.BatchItemFailed:
+		result.FailedCount++
+		result.FailedIDs = append(result.FailedIDs, domain.FailedItem{ID: outcome.ID, Reason: outcome.Reason})
+	}
+}
+
+// batchItem runs fn for one talent after checking an admin_sekolah only
+// touches talents of their own school.
+func (s *TalentService) batchItem(ctx context.Context, id uuid.UUID, schoolID *uuid.UUID, fn func(ctx context.Context, id uuid.UUID) (*domain.Talent, error)) (*domain.Talent, error) {
+	if schoolID != nil {
+		talent, err := s.talentRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if talent == nil {
+			return nil, ErrTalentNotFound
+		}
+		owner, err := s.userRepo.GetByID(ctx, talent.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if owner == nil || owner.SchoolID == nil || *owner.SchoolID != *schoolID {
+			return nil, ErrForbidden
+		}
+	}
+	return fn(ctx, id)
+}
+
+// selectBatch resolves a selection to talent IDs, refusing selections larger
+// than the batch limit instead of truncating them.
+func (s *TalentService) selectBatch(ctx context.Context, sel domain.BatchSelection, schoolID *uuid.UUID) ([]uuid.UUID, error) {
+	params, errs := batchParams(sel, schoolID)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var ids []uuid.UUID
+	if params == nil {
+		ids = uniqueIDs(sel.IDs)
+	} else {
+		var err error
+		ids, err = s.talentRepo.ListIDs(ctx, *params, s.batchLimit+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) > s.batchLimit {
+		return nil, ErrBatchTooLarge
+	}
+	return ids, nil
+}
+
+// batchParams validates a selection and turns its filters into list params,
+// returning nil params for an ID selection. The filters are those of
+// GET /talents, already parsed and checked by the handler, with the search
+// query in q. Filter selections only match talents waiting for
+// verification, pending unless status says otherwise.
+func batchParams(sel domain.BatchSelection, schoolID *uuid.UUID) (*domain.ListParams, ValidationErrors) {
+	var errs ValidationErrors
+	switch {
+	case len(sel.IDs) > 0 && len(sel.Filters) > 0:
+		errs.add("ids", "Pilih talenta dengan ids atau filters, tidak keduanya")
+		return nil, errs
+	case len(sel.IDs) > 0:
+		return nil, nil
+	case len(sel.Filters) == 0:
+		errs.add("ids", "ids atau filters wajib diisi")
+		return nil, errs
+	}
+
+	filters := make(map[string]string, len(sel.Filters))
+	for key, value := range sel.Filters {
+		filters[key] = value
+	}
+	search := filters["q"]
+	delete(filters, "q")
+
+	switch filters["status"] {
+	case "":
+		filters["status"] = string(domain.TalentStatusPending)
+	case string(domain.TalentStatusPending), string(domain.TalentStatusSchoolApproved):
+	default:
+		errs.add("filters.status", "Status harus pending atau school_approved")
+		return nil, errs
+	}
+	if schoolID != nil {
+		filters["school_id"] = schoolID.String()
+	}
+	return &domain.ListParams{Filters: filters, Search: search}, nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+)
+
+// Duplicate detection thresholds on pg_trgm similarity (0..1)
+const (
+	duplicateNameThreshold      = 0.6
+	duplicateOrganizerThreshold = 0.5
+	duplicateCandidateLimit     = 10
+)
+
+func (s *TalentService) GetDuplicateMatches(ctx context.Context, talentID uuid.UUID) ([]domain.TalentDuplicateMatch, error) {
+	return s.duplicateRepo.ListByTalentID(ctx, talentID)
+}
+
+// detectDuplicates rebuilds the suspected duplicates of a submitted talent.
+// Failures are logged and never block the submission.
+func (s *TalentService) detectDuplicates(ctx context.Context, talent *domain.Talent) {
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		matches, err := s.findDuplicates(ctx, talent)
+		if err != nil {
+			return err
+		}
+		return s.duplicateRepo.Replace(ctx, talent.ID, matches)
+	})
+	if err != nil {
+		log.Printf("failed to detect duplicates of talent %s: %v", talent.ID, err)
+	}
+}
+
+// findDuplicates flags two kinds of matches: another talent of the same GTK
+// and type with a similar name plus a similar organizer or the same date,
+// and a talent of any GTK carrying the same file. Types whose registry entry
+// names no organizer or date field have only a name to compare, so a
+// similar name is enough for them.
+func (s *TalentService) findDuplicates(ctx context.Context, talent *domain.Talent) ([]domain.TalentDuplicateMatch, error) {
+	byID := make(map[uuid.UUID]*domain.TalentDuplicateMatch)
+
+	def, err := s.GetTalentType(ctx, talent.TalentType)
+	if err != nil {
+		return nil, err
+	}
+	nameOnly := def.MatchOrganizerField == nil && def.MatchDateField == nil
+
+	candidates, err := s.duplicateRepo.FindSimilar(ctx, talent.ID, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if candidate.NameScore < duplicateNameThreshold {
+			continue
+		}
+		reasons := []domain.DuplicateReason{domain.DuplicateSimilarName}
+		if candidate.OrganizerScore >= duplicateOrganizerThreshold {
+			reasons = append(reasons, domain.DuplicateSimilarOrganizer)
+		}
+		if candidate.SameDate {
+			reasons = append(reasons, domain.DuplicateSameDate)
+		}
+		if len(reasons) == 1 && !nameOnly {
+			continue
+		}
+		byID[candidate.TalentID] = &domain.TalentDuplicateMatch{
+			TalentID: talent.ID,
+			MatchID:  candidate.TalentID,
+			Reasons:  reasons,
+			Score:    candidate.NameScore,
+		}
+	}
+
+	sameFile, err := s.duplicateRepo.FindSameFile(ctx, talent.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range sameFile {
+		match, ok := byID[id]
+		if !ok {
+			match = &domain.TalentDuplicateMatch{TalentID: talent.ID, MatchID: id}
+			byID[id] = match
+		}
+		match.Reasons = append(match.Reasons, domain.DuplicateSameFile)
+		match.Score = 1
+	}
+
+	matches := make([]domain.TalentDuplicateMatch, 0, len(byID))
+	for _, match := range byID {
+		matches = append(matches, *match)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
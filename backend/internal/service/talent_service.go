@@ -4,142 +4,251 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/config"
 	"github.com/sipodi/backend/internal/domain"
 	"github.com/sipodi/backend/internal/repository"
 )
 
 var (
-	ErrTalentNotFound  = errors.New("talent not found")
-	ErrAlreadyVerified = errors.New("talent already verified")
-	ErrForbidden       = errors.New("forbidden")
+	ErrTalentNotFound      = errors.New("talent not found")
+	ErrAlreadyVerified     = errors.New("talent already verified")
+	ErrForbidden           = errors.New("forbidden")
+	ErrEndorsementRequired = errors.New("talent awaiting endorsement")
+	ErrRevisionPending     = errors.New("talent awaiting revision")
+	ErrVersionNotFound     = errors.New("talent version not found")
+	ErrAttachmentNotFound  = errors.New("attachment not found")
+	ErrClaimedByOther      = errors.New("talent claimed by another verifier")
+	ErrInvalidAssignee     = errors.New("invalid assignee")
+	ErrBatchTooLarge       = errors.New("batch too large")
+	ErrNotDraft            = errors.New("talent is not a draft")
+	ErrNotWithdrawable     = errors.New("talent cannot be withdrawn")
 )
 
 type TalentService struct {
 	talentRepo       *repository.TalentRepository
 	userRepo         *repository.UserRepository
-	notificationRepo *repository.NotificationRepository
+	notifications    *NotificationService
+	historyRepo      *repository.TalentHistoryRepository
+	commentRepo      *repository.ReviewCommentRepository
+	versionRepo      *repository.TalentVersionRepository
+	attachmentRepo   *repository.AttachmentRepository
+	queueRepo        *repository.VerificationQueueRepository
+	duplicateRepo    *repository.DuplicateRepository
+	talentTypeRepo   *repository.TalentTypeRepository
+	txManager        *repository.TxManager
+	outbox           *OutboxService
+	endorsementLevel domain.CompetitionLevel
+	claimTTL         time.Duration
+	slaWarningDays   int
+	slaEscalateDays  int
+	batchLimit       int
+	pendingDigestDay time.Weekday
+	digestHour       int
+	location         *time.Location
 }
 
 func NewTalentService(
 	talentRepo *repository.TalentRepository,
 	userRepo *repository.UserRepository,
-	notificationRepo *repository.NotificationRepository,
+	notifications *NotificationService,
+	historyRepo *repository.TalentHistoryRepository,
+	commentRepo *repository.ReviewCommentRepository,
+	versionRepo *repository.TalentVersionRepository,
+	attachmentRepo *repository.AttachmentRepository,
+	queueRepo *repository.VerificationQueueRepository,
+	duplicateRepo *repository.DuplicateRepository,
+	talentTypeRepo *repository.TalentTypeRepository,
+	txManager *repository.TxManager,
+	outbox *OutboxService,
+	verificationConfig config.VerificationConfig,
+	notificationConfig config.NotificationConfig,
 ) *TalentService {
 	return &TalentService{
 		talentRepo:       talentRepo,
 		userRepo:         userRepo,
-		notificationRepo: notificationRepo,
+		notifications:    notifications,
+		historyRepo:      historyRepo,
+		commentRepo:      commentRepo,
+		versionRepo:      versionRepo,
+		attachmentRepo:   attachmentRepo,
+		queueRepo:        queueRepo,
+		duplicateRepo:    duplicateRepo,
+		talentTypeRepo:   talentTypeRepo,
+		txManager:        txManager,
+		outbox:           outbox,
+		endorsementLevel: domain.CompetitionLevel(verificationConfig.EndorsementLevel),
+		claimTTL:         verificationConfig.ClaimTTL,
+		slaWarningDays:   verificationConfig.SLAWarningDays,
+		slaEscalateDays:  verificationConfig.SLAEscalationDays,
+		batchLimit:       verificationConfig.BatchLimit,
+		pendingDigestDay: parseWeekday(verificationConfig.PendingDigestDay),
+		digestHour:       notificationConfig.DigestHour,
+		location:         notificationConfig.Location(),
 	}
 }
 
-func (s *TalentService) Create(ctx context.Context, userID uuid.UUID, req domain.CreateTalentRequest, certificateURL *string) (*domain.Talent, error) {
+// Create stores a new talent and sends it to verification, or keeps it as a
+// draft of its owner when req.Draft is set.
+func (s *TalentService) Create(ctx context.Context, userID uuid.UUID, req domain.CreateTalentRequest, attachments []domain.TalentAttachment) (*domain.Talent, error) {
+	def, err := s.activeTalentType(ctx, req.TalentType)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, errs := parseTalentDetail(def, req.Detail, req.Draft, time.Now().In(s.location))
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
 	talent := &domain.Talent{
 		ID:         uuid.New(),
 		UserID:     userID,
 		TalentType: req.TalentType,
 		Status:     domain.TalentStatusPending,
 	}
-
-	if err := s.talentRepo.Create(ctx, talent); err != nil {
-		return nil, err
+	if req.Draft {
+		talent.Status = domain.TalentStatusDraft
 	}
 
-	// Create detail based on type
-	switch req.TalentType {
-	case domain.TalentTypePesertaPelatihan:
-		if err := s.createTrainingDetail(ctx, talent.ID, req.Detail); err != nil {
-			return nil, err
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.talentRepo.Create(ctx, talent); err != nil {
+			return err
 		}
-	case domain.TalentTypePembimbingLomba:
-		if err := s.createMentorDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
+		if err := s.talentRepo.UpdateDetail(ctx, talent.ID, detail); err != nil {
+			return err
 		}
-	case domain.TalentTypePesertaLomba:
-		if err := s.createParticipantDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
+		for i := range attachments {
+			if err := s.saveAttachment(ctx, talent.ID, userID, &attachments[i]); err != nil {
+				return err
+			}
 		}
-	case domain.TalentTypeMinatBakat:
-		if err := s.createInterestDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
+		if err := s.createVersion(ctx, talent, &userID); err != nil {
+			return err
+		}
+		if talent.Status == domain.TalentStatusDraft {
+			return s.recordHistory(ctx, talent, domain.TalentHistoryDrafted, nil, &userID, nil)
 		}
+		if err := s.recordHistory(ctx, talent, domain.TalentHistorySubmitted, nil, &userID, nil); err != nil {
+			return err
+		}
+		return s.publish(ctx, domain.EventTalentSubmitted, talent, nil, userID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if talent.Status == domain.TalentStatusDraft {
+		return talent, nil
 	}
 
+	s.detectDuplicates(ctx, talent)
+
 	return talent, nil
 }
 
-func (s *TalentService) createTrainingDetail(ctx context.Context, talentID uuid.UUID, detail interface{}) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.TrainingDetail
-	json.Unmarshal(detailBytes, &d)
-
-	startDate, _ := time.Parse("2006-01-02", d.StartDate)
-	training := &domain.TalentTraining{
-		ID:           uuid.New(),
-		TalentID:     talentID,
-		ActivityName: d.ActivityName,
-		Organizer:    d.Organizer,
-		StartDate:    startDate,
-		DurationDays: d.DurationDays,
+// activeTalentType returns a talent type new submissions may use.
+func (s *TalentService) activeTalentType(ctx context.Context, code domain.TalentType) (*domain.TalentTypeDefinition, error) {
+	def, err := s.talentTypeRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil || !def.IsActive {
+		return nil, ValidationErrors{{Field: "talent_type", Message: "Jenis talenta tidak valid"}}
 	}
-	return s.talentRepo.CreateTraining(ctx, training)
+	return def, nil
 }
 
-func (s *TalentService) createMentorDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.MentorDetail
-	json.Unmarshal(detailBytes, &d)
+// Submit sends a draft to verification. The stored detail must now pass the
+// full schema, including the required fields a draft may leave out.
+func (s *TalentService) Submit(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Talent, error) {
+	talent, err := s.getOwnTalent(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if talent.Status != domain.TalentStatusDraft {
+		return nil, ErrNotDraft
+	}
 
-	mentor := &domain.TalentCompetitionMentor{
-		ID:              uuid.New(),
-		TalentID:        talentID,
-		CompetitionName: d.CompetitionName,
-		Level:           d.Level,
-		Organizer:       d.Organizer,
-		Field:           d.Field,
-		Achievement:     d.Achievement,
-		CertificateURL:  certURL,
+	def, err := s.activeTalentType(ctx, talent.TalentType)
+	if err != nil {
+		return nil, err
+	}
+	var detail interface{}
+	if err := json.Unmarshal(s.detailSnapshot(ctx, talent), &detail); err != nil {
+		return nil, err
+	}
+	if _, errs := parseTalentDetail(def, detail, false, time.Now().In(s.location)); len(errs) > 0 {
+		return nil, errs
+	}
+
+	previousStatus := talent.Status
+	var submitted *domain.Talent
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.talentRepo.ResetStatus(ctx, talent.ID); err != nil {
+			return err
+		}
+		var err error
+		submitted, err = s.talentRepo.GetByID(ctx, talent.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, submitted, domain.TalentHistorySubmitted, &previousStatus, &userID, nil); err != nil {
+			return err
+		}
+		return s.publish(ctx, domain.EventTalentSubmitted, submitted, &previousStatus, userID, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.talentRepo.CreateMentor(ctx, mentor)
+
+	s.detectDuplicates(ctx, submitted)
+
+	return submitted, nil
 }
 
-func (s *TalentService) createParticipantDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.ParticipantDetail
-	json.Unmarshal(detailBytes, &d)
-
-	startDate, _ := time.Parse("2006-01-02", d.StartDate)
-	participant := &domain.TalentCompetitionParticipant{
-		ID:               uuid.New(),
-		TalentID:         talentID,
-		CompetitionName:  d.CompetitionName,
-		Level:            d.Level,
-		Organizer:        d.Organizer,
-		Field:            d.Field,
-		StartDate:        startDate,
-		DurationDays:     d.DurationDays,
-		CompetitionField: d.CompetitionField,
-		Achievement:      d.Achievement,
-		CertificateURL:   certURL,
-	}
-	return s.talentRepo.CreateParticipant(ctx, participant)
-}
-
-func (s *TalentService) createInterestDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.InterestDetail
-	json.Unmarshal(detailBytes, &d)
+// Withdraw pulls a pending talent back to draft before anyone verified it,
+// taking it out of the verification queue along with any claim on it.
+func (s *TalentService) Withdraw(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Talent, error) {
+	previousStatus := domain.TalentStatusPending
+	var talent *domain.Talent
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		talent, err = s.getOwnTalent(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		if talent.Status != domain.TalentStatusPending {
+			return ErrNotWithdrawable
+		}
 
-	interest := &domain.TalentInterest{
-		ID:             uuid.New(),
-		TalentID:       talentID,
-		InterestName:   d.InterestName,
-		Description:    d.Description,
-		CertificateURL: certURL,
+		talent.Status = domain.TalentStatusDraft
+		if err := s.talentRepo.Update(ctx, talent); err != nil {
+			return err
+		}
+		if err := s.queueRepo.Clear(ctx, talent.ID); err != nil {
+			return err
+		}
+		if err := s.duplicateRepo.Replace(ctx, talent.ID, nil); err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, talent, domain.TalentHistoryWithdrawn, &previousStatus, &userID, nil); err != nil {
+			return err
+		}
+		return s.publish(ctx, domain.EventTalentWithdrawn, talent, &previousStatus, userID, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.talentRepo.CreateInterest(ctx, interest)
+
+	return talent, nil
 }
 
 func (s *TalentService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Talent, error) {
@@ -153,196 +262,411 @@ func (s *TalentService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tale
 	return talent, nil
 }
 
-func (s *TalentService) GetDetail(ctx context.Context, talent *domain.Talent) (interface{}, *string, error) {
-	switch talent.TalentType {
-	case domain.TalentTypePesertaPelatihan:
-		detail, err := s.talentRepo.GetTrainingByTalentID(ctx, talent.ID)
-		if err != nil {
-			return nil, nil, err
-		}
-		return detail, nil, nil
-	case domain.TalentTypePembimbingLomba:
-		detail, err := s.talentRepo.GetMentorByTalentID(ctx, talent.ID)
-		if err != nil {
-			return nil, nil, err
-		}
-		return detail, detail.CertificateURL, nil
-	case domain.TalentTypePesertaLomba:
-		detail, err := s.talentRepo.GetParticipantByTalentID(ctx, talent.ID)
-		if err != nil {
-			return nil, nil, err
-		}
-		return detail, detail.CertificateURL, nil
-	case domain.TalentTypeMinatBakat:
-		detail, err := s.talentRepo.GetInterestByTalentID(ctx, talent.ID)
-		if err != nil {
-			return nil, nil, err
-		}
-		return detail, detail.CertificateURL, nil
+// GetVisible returns a talent as the viewer may see it. A draft only exists
+// for its owner; everyone else gets ErrTalentNotFound.
+func (s *TalentService) GetVisible(ctx context.Context, id uuid.UUID, viewerID uuid.UUID) (*domain.Talent, error) {
+	talent, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil, nil
+	if talent.Status == domain.TalentStatusDraft && talent.UserID != viewerID {
+		return nil, ErrTalentNotFound
+	}
+	return talent, nil
 }
 
-func (s *TalentService) Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, req domain.UpdateTalentRequest, certificateURL *string) (*domain.Talent, error) {
-	talent, err := s.talentRepo.GetByID(ctx, id)
+// GetDetail returns the detail fields of a talent as stored.
+func (s *TalentService) GetDetail(ctx context.Context, talent *domain.Talent) (json.RawMessage, error) {
+	return s.talentRepo.GetDetail(ctx, talent.ID)
+}
+
+// GetTalentType returns the registry definition of a talent type.
+func (s *TalentService) GetTalentType(ctx context.Context, code domain.TalentType) (*domain.TalentTypeDefinition, error) {
+	def, err := s.talentTypeRepo.GetByCode(ctx, code)
 	if err != nil {
 		return nil, err
 	}
-	if talent == nil {
-		return nil, ErrTalentNotFound
-	}
-	if talent.UserID != userID {
-		return nil, ErrForbidden
+	if def == nil {
+		return nil, ErrTalentTypeNotFound
 	}
+	return def, nil
+}
 
-	// Update detail based on type
-	switch talent.TalentType {
-	case domain.TalentTypePesertaPelatihan:
-		if err := s.updateTrainingDetail(ctx, talent.ID, req.Detail); err != nil {
-			return nil, err
-		}
-	case domain.TalentTypePembimbingLomba:
-		if err := s.updateMentorDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
-		}
-	case domain.TalentTypePesertaLomba:
-		if err := s.updateParticipantDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
-		}
-	case domain.TalentTypeMinatBakat:
-		if err := s.updateInterestDetail(ctx, talent.ID, req.Detail, certificateURL); err != nil {
-			return nil, err
+// IsReviewField reports whether verifiers can comment on the given field of
+// a talent type.
+func (s *TalentService) IsReviewField(ctx context.Context, code domain.TalentType, field string) (bool, error) {
+	def, err := s.GetTalentType(ctx, code)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range reviewFields(def) {
+		if f == field {
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	// Reset status to pending
-	if err := s.talentRepo.ResetStatus(ctx, talent.ID); err != nil {
+// Update overwrites the talent detail. A new certificate replaces the
+// existing certificate attachments, matching the single certificate the
+// upload_id field used to carry.
+func (s *TalentService) Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, req domain.UpdateTalentRequest, certificate *domain.TalentAttachment) (*domain.Talent, error) {
+	talent, err := s.getOwnTalent(ctx, id, userID)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.talentRepo.GetByID(ctx, id)
-}
+	// Existing talents of a deactivated type can still be edited
+	def, err := s.GetTalentType(ctx, talent.TalentType)
+	if err != nil {
+		return nil, err
+	}
+	detail, errs := parseTalentDetail(def, req.Detail, talent.Status == domain.TalentStatusDraft, time.Now().In(s.location))
+	if len(errs) > 0 {
+		return nil, errs
+	}
 
-func (s *TalentService) updateTrainingDetail(ctx context.Context, talentID uuid.UUID, detail interface{}) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.TrainingDetail
-	json.Unmarshal(detailBytes, &d)
+	return s.applyChange(ctx, talent, userID, func(ctx context.Context) error {
+		if err := s.talentRepo.UpdateDetail(ctx, talent.ID, detail); err != nil {
+			return err
+		}
+		if certificate != nil {
+			if err := s.attachmentRepo.DeleteByKind(ctx, talent.ID, domain.AttachmentCertificate); err != nil {
+				return err
+			}
+			return s.saveAttachment(ctx, talent.ID, userID, certificate)
+		}
+		return nil
+	})
+}
 
-	startDate, _ := time.Parse("2006-01-02", d.StartDate)
-	training := &domain.TalentTraining{
-		TalentID:     talentID,
-		ActivityName: d.ActivityName,
-		Organizer:    d.Organizer,
-		StartDate:    startDate,
-		DurationDays: d.DurationDays,
+func (s *TalentService) AddAttachment(ctx context.Context, talentID uuid.UUID, userID uuid.UUID, attachment *domain.TalentAttachment) (*domain.Talent, error) {
+	talent, err := s.getOwnTalent(ctx, talentID, userID)
+	if err != nil {
+		return nil, err
 	}
-	return s.talentRepo.UpdateTraining(ctx, training)
+
+	return s.applyChange(ctx, talent, userID, func(ctx context.Context) error {
+		return s.saveAttachment(ctx, talent.ID, userID, attachment)
+	})
 }
 
-func (s *TalentService) updateMentorDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.MentorDetail
-	json.Unmarshal(detailBytes, &d)
+func (s *TalentService) DeleteAttachment(ctx context.Context, talentID uuid.UUID, attachmentID uuid.UUID, userID uuid.UUID) (*domain.Talent, error) {
+	talent, err := s.getOwnTalent(ctx, talentID, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	mentor := &domain.TalentCompetitionMentor{
-		TalentID:        talentID,
-		CompetitionName: d.CompetitionName,
-		Level:           d.Level,
-		Organizer:       d.Organizer,
-		Field:           d.Field,
-		Achievement:     d.Achievement,
-		CertificateURL:  certURL,
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
 	}
-	return s.talentRepo.UpdateMentor(ctx, mentor)
+	if attachment == nil || attachment.TalentID != talent.ID {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return s.applyChange(ctx, talent, userID, func(ctx context.Context) error {
+		return s.attachmentRepo.Delete(ctx, attachment.ID)
+	})
 }
 
-func (s *TalentService) updateParticipantDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.ParticipantDetail
-	json.Unmarshal(detailBytes, &d)
-
-	startDate, _ := time.Parse("2006-01-02", d.StartDate)
-	participant := &domain.TalentCompetitionParticipant{
-		TalentID:         talentID,
-		CompetitionName:  d.CompetitionName,
-		Level:            d.Level,
-		Organizer:        d.Organizer,
-		Field:            d.Field,
-		StartDate:        startDate,
-		DurationDays:     d.DurationDays,
-		CompetitionField: d.CompetitionField,
-		Achievement:      d.Achievement,
-		CertificateURL:   certURL,
-	}
-	return s.talentRepo.UpdateParticipant(ctx, participant)
-}
-
-func (s *TalentService) updateInterestDetail(ctx context.Context, talentID uuid.UUID, detail interface{}, certURL *string) error {
-	detailBytes, _ := json.Marshal(detail)
-	var d domain.InterestDetail
-	json.Unmarshal(detailBytes, &d)
+func (s *TalentService) GetAttachments(ctx context.Context, talentID uuid.UUID) ([]domain.TalentAttachment, error) {
+	return s.attachmentRepo.ListByTalentID(ctx, talentID)
+}
 
-	interest := &domain.TalentInterest{
-		TalentID:       talentID,
-		InterestName:   d.InterestName,
-		Description:    d.Description,
-		CertificateURL: certURL,
-	}
-	return s.talentRepo.UpdateInterest(ctx, interest)
+// GetCatalogLinks returns the catalog entries the talent's competition and
+// organizer resolve to.
+func (s *TalentService) GetCatalogLinks(ctx context.Context, talentID uuid.UUID) (map[domain.CatalogKind]domain.CatalogRef, error) {
+	return s.talentRepo.GetCatalogLinks(ctx, talentID)
 }
 
-func (s *TalentService) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+func (s *TalentService) getOwnTalent(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Talent, error) {
 	talent, err := s.talentRepo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if talent == nil {
-		return ErrTalentNotFound
+		return nil, ErrTalentNotFound
 	}
 	if talent.UserID != userID {
-		return ErrForbidden
+		return nil, ErrForbidden
+	}
+	return talent, nil
+}
+
+// applyChange runs an owner edit of the talent detail or attachments in one
+// transaction, sends the talent back to pending and stores a new version.
+// A draft stays a draft until it is submitted.
+func (s *TalentService) applyChange(ctx context.Context, talent *domain.Talent, userID uuid.UUID, change func(ctx context.Context) error) (*domain.Talent, error) {
+	previousStatus := talent.Status
+	previousDetail := s.detailSnapshot(ctx, talent)
+
+	action := domain.TalentHistoryEdited
+	if previousStatus != domain.TalentStatusPending && previousStatus != domain.TalentStatusDraft {
+		action = domain.TalentHistoryReset
+	}
+
+	var updated *domain.Talent
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := change(ctx); err != nil {
+			return err
+		}
+
+		// Reset status to pending; the edit is a new submission, so it
+		// re-enters the queue unclaimed with a fresh SLA clock
+		if previousStatus != domain.TalentStatusDraft {
+			if err := s.talentRepo.ResetStatus(ctx, talent.ID); err != nil {
+				return err
+			}
+			if err := s.queueRepo.Clear(ctx, talent.ID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updated, err = s.talentRepo.GetByID(ctx, talent.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.createVersion(ctx, updated, &userID); err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, updated, action, &previousStatus, &userID, nil); err != nil {
+			return err
+		}
+		if previousStatus == domain.TalentStatusDraft {
+			return nil
+		}
+		return s.publish(ctx, domain.EventTalentUpdated, updated, &previousStatus, userID, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.talentRepo.Delete(ctx, id)
+	if previousStatus == domain.TalentStatusNeedsRevision {
+		s.resolveComments(ctx, updated, previousDetail)
+	}
+
+	if updated.Status != domain.TalentStatusDraft {
+		s.detectDuplicates(ctx, updated)
+	}
+
+	return updated, nil
+}
+
+func (s *TalentService) saveAttachment(ctx context.Context, talentID uuid.UUID, userID uuid.UUID, attachment *domain.TalentAttachment) error {
+	attachment.ID = uuid.New()
+	attachment.TalentID = talentID
+	attachment.UploadedBy = &userID
+	return s.attachmentRepo.Create(ctx, attachment)
+}
+
+func (s *TalentService) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		talent, err := s.talentRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if talent == nil {
+			return ErrTalentNotFound
+		}
+		if talent.UserID != userID {
+			return ErrForbidden
+		}
+
+		if err := s.talentRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if talent.Status == domain.TalentStatusDraft {
+			return nil
+		}
+		return s.publish(ctx, domain.EventTalentDeleted, talent, nil, userID, nil)
+	})
 }
 
 func (s *TalentService) List(ctx context.Context, params domain.ListParams) ([]domain.Talent, int, error) {
 	return s.talentRepo.List(ctx, params)
 }
 
+// ListIncluding lists talents with the related data selected by include.
+func (s *TalentService) ListIncluding(ctx context.Context, params domain.ListParams, include domain.TalentInclude) ([]domain.TalentListItem, int, error) {
+	return s.talentRepo.ListIncluding(ctx, params, include)
+}
+
 func (s *TalentService) GetUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
 	return s.userRepo.GetByID(ctx, userID)
 }
 
 // Verification methods
-func (s *TalentService) Approve(ctx context.Context, id uuid.UUID, verifierID uuid.UUID) (*domain.Talent, error) {
-	talent, err := s.talentRepo.GetByID(ctx, id)
+
+// Approve advances a talent through the verification workflow. Competition
+// talents at or above the endorsement level stop at school_approved when
+// approved by admin_sekolah and need a super_admin endorsement to become
+// approved. A super_admin approval always finalizes the talent.
+func (s *TalentService) Approve(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole) (*domain.Talent, error) {
+	var talent *domain.Talent
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		talent, err = s.approve(ctx, id, verifierID, verifierRole)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	if talent == nil {
-		return nil, ErrTalentNotFound
+	return talent, nil
+}
+
+// approve is Approve within the transaction of ctx, which lets a batch run
+// many approvals in one transaction. Any failed step fails the approval.
+func (s *TalentService) approve(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole) (*domain.Talent, error) {
+	talent, err := s.verifiableTalent(ctx, id, verifierID, verifierRole)
+	if err != nil {
+		return nil, err
+	}
+
+	previousStatus := talent.Status
+	now := time.Now()
+	action := domain.TalentHistoryApproved
+	event := domain.EventTalentApproved
+	if talent.Status == domain.TalentStatusPending && verifierRole != domain.RoleSuperAdmin {
+		talent.SchoolVerifiedBy = &verifierID
+		talent.SchoolVerifiedAt = &now
+
+		needsEndorsement, err := s.NeedsEndorsement(ctx, talent)
+		if err != nil {
+			return nil, err
+		}
+		if needsEndorsement {
+			action = domain.TalentHistorySchoolApproved
+			event = domain.EventTalentSchoolApproved
+		}
+	}
+
+	if action == domain.TalentHistorySchoolApproved {
+		talent.Status = domain.TalentStatusSchoolApproved
+	} else {
+		talent.Status = domain.TalentStatusApproved
+		talent.VerifiedBy = &verifierID
+		talent.VerifiedAt = &now
+	}
+	if err := s.talentRepo.Update(ctx, talent); err != nil {
+		return nil, err
+	}
+	if talent.Status == domain.TalentStatusApproved {
+		if err := s.versionRepo.MarkLatestApproved(ctx, talent.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.queueRepo.Clear(ctx, talent.ID); err != nil {
+		return nil, err
+	}
+	if err := s.recordHistory(ctx, talent, action, &previousStatus, &verifierID, nil); err != nil {
+		return nil, err
+	}
+	if err := s.publish(ctx, event, talent, &previousStatus, verifierID, nil); err != nil {
+		return nil, err
+	}
+	return talent, nil
+}
+
+func (s *TalentService) Reject(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole, reason string) (*domain.Talent, error) {
+	var talent *domain.Talent
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		talent, err = s.reject(ctx, id, verifierID, verifierRole, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	if talent.Status != domain.TalentStatusPending {
-		return nil, ErrAlreadyVerified
+	return talent, nil
+}
+
+// reject is Reject within the transaction of ctx, see approve.
+func (s *TalentService) reject(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole, reason string) (*domain.Talent, error) {
+	talent, err := s.verifiableTalent(ctx, id, verifierID, verifierRole)
+	if err != nil {
+		return nil, err
 	}
 
+	previousStatus := talent.Status
 	now := time.Now()
-	talent.Status = domain.TalentStatusApproved
+	talent.Status = domain.TalentStatusRejected
 	talent.VerifiedBy = &verifierID
 	talent.VerifiedAt = &now
+	talent.RejectionReason = &reason
 
 	if err := s.talentRepo.Update(ctx, talent); err != nil {
 		return nil, err
 	}
+	if err := s.queueRepo.Clear(ctx, talent.ID); err != nil {
+		return nil, err
+	}
+	if err := s.recordHistory(ctx, talent, domain.TalentHistoryRejected, &previousStatus, &verifierID, &reason); err != nil {
+		return nil, err
+	}
+	if err := s.publish(ctx, domain.EventTalentRejected, talent, &previousStatus, verifierID, &reason); err != nil {
+		return nil, err
+	}
+	return talent, nil
+}
+
+// RequestRevision sends a talent back to its owner with comments on the
+// detail fields that need fixing, instead of rejecting it outright.
+func (s *TalentService) RequestRevision(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole, comments []domain.ReviewCommentRequest) (*domain.Talent, error) {
+	var talent *domain.Talent
+	var previousStatus domain.TalentStatus
+	var reason string
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		talent, err = s.verifiableTalent(ctx, id, verifierID, verifierRole)
+		if err != nil {
+			return err
+		}
+
+		previousStatus = talent.Status
+		now := time.Now()
+		talent.Status = domain.TalentStatusNeedsRevision
+		talent.VerifiedBy = &verifierID
+		talent.VerifiedAt = &now
 
-	// Create notification
-	s.createNotification(ctx, talent.UserID, talent.ID, domain.NotificationTalentApproved, "Talenta Anda telah disetujui")
+		if err := s.talentRepo.Update(ctx, talent); err != nil {
+			return err
+		}
+
+		var summary []string
+		for _, c := range comments {
+			comment := &domain.TalentReviewComment{
+				ID:       uuid.New(),
+				TalentID: talent.ID,
+				Field:    c.Field,
+				Comment:  c.Comment,
+				AuthorID: &verifierID,
+			}
+			if err := s.commentRepo.Create(ctx, comment); err != nil {
+				return err
+			}
+			summary = append(summary, c.Field+": "+c.Comment)
+		}
+
+		reason = strings.Join(summary, "; ")
+		if err := s.queueRepo.Clear(ctx, talent.ID); err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, talent, domain.TalentHistoryRevisionRequested, &previousStatus, &verifierID, &reason); err != nil {
+			return err
+		}
+		return s.publish(ctx, domain.EventTalentRevisionRequested, talent, &previousStatus, verifierID, &reason)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return talent, nil
 }
 
-func (s *TalentService) Reject(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, reason string) (*domain.Talent, error) {
+// verifiableTalent loads a talent the verifier may decide on right now.
+func (s *TalentService) verifiableTalent(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole) (*domain.Talent, error) {
 	talent, err := s.talentRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -350,79 +674,278 @@ func (s *TalentService) Reject(ctx context.Context, id uuid.UUID, verifierID uui
 	if talent == nil {
 		return nil, ErrTalentNotFound
 	}
-	if talent.Status != domain.TalentStatusPending {
-		return nil, ErrAlreadyVerified
+	if err := s.checkCanVerify(talent, verifierRole); err != nil {
+		return nil, err
 	}
+	if err := s.checkClaim(ctx, talent.ID, verifierID); err != nil {
+		return nil, err
+	}
+	return talent, nil
+}
 
-	now := time.Now()
-	talent.Status = domain.TalentStatusRejected
-	talent.VerifiedBy = &verifierID
-	talent.VerifiedAt = &now
-	talent.RejectionReason = &reason
+func (s *TalentService) GetReviewComments(ctx context.Context, talentID uuid.UUID) ([]domain.TalentReviewComment, error) {
+	return s.commentRepo.ListByTalentID(ctx, talentID)
+}
 
-	if err := s.talentRepo.Update(ctx, talent); err != nil {
+// resolveComments marks open review comments as resolved when the field
+// they point at was changed by the edit. Comments on untouched fields stay
+// unresolved so the verifier can see what was ignored.
+func (s *TalentService) resolveComments(ctx context.Context, talent *domain.Talent, previousDetail json.RawMessage) {
+	comments, err := s.commentRepo.ListUnresolvedByTalentID(ctx, talent.ID)
+	if err != nil {
+		log.Printf("failed to load review comments for talent %s: %v", talent.ID, err)
+		return
+	}
+
+	var before, after map[string]interface{}
+	json.Unmarshal(previousDetail, &before)
+	json.Unmarshal(s.detailSnapshot(ctx, talent), &after)
+
+	for _, comment := range comments {
+		if reflect.DeepEqual(before[comment.Field], after[comment.Field]) {
+			continue
+		}
+		if err := s.commentRepo.Resolve(ctx, comment.ID); err != nil {
+			log.Printf("failed to resolve review comment %s: %v", comment.ID, err)
+		}
+	}
+}
+
+// NeedsEndorsement reports whether the talent requires the second
+// (super_admin) verification stage, which depends on the competition level
+// of any talent type whose detail has one.
+func (s *TalentService) NeedsEndorsement(ctx context.Context, talent *domain.Talent) (bool, error) {
+	level, err := s.talentRepo.GetCompetitionLevel(ctx, talent.ID)
+	if err != nil || level == nil || !level.IsValid() {
+		return false, err
+	}
+	return level.AtLeast(s.endorsementLevel), nil
+}
+
+// checkCanVerify reports whether a talent is in a queue the verifier works on.
+// Drafts are not visible to verifiers at all.
+func (s *TalentService) checkCanVerify(talent *domain.Talent, verifierRole domain.UserRole) error {
+	switch talent.Status {
+	case domain.TalentStatusDraft:
+		return ErrTalentNotFound
+	case domain.TalentStatusPending:
+		return nil
+	case domain.TalentStatusSchoolApproved:
+		if verifierRole != domain.RoleSuperAdmin {
+			return ErrEndorsementRequired
+		}
+		return nil
+	case domain.TalentStatusNeedsRevision:
+		return ErrRevisionPending
+	default:
+		return ErrAlreadyVerified
+	}
+}
+
+// publish writes a talent event to the outbox in the transaction of ctx.
+func (s *TalentService) publish(ctx context.Context, eventType domain.EventType, talent *domain.Talent, fromStatus *domain.TalentStatus, actorID uuid.UUID, reason *string) error {
+	return s.outbox.Publish(ctx, eventType, talent.ID, &actorID, domain.TalentEventPayload{
+		TalentID:   talent.ID,
+		UserID:     talent.UserID,
+		TalentType: talent.TalentType,
+		FromStatus: fromStatus,
+		ToStatus:   talent.Status,
+		Reason:     reason,
+		BatchID:    batchIDFrom(ctx),
+	})
+}
+
+// GetHistory returns every recorded status transition of a talent.
+func (s *TalentService) GetHistory(ctx context.Context, talentID uuid.UUID) ([]domain.TalentStatusHistory, error) {
+	return s.historyRepo.ListByTalentID(ctx, talentID)
+}
+
+// recordHistory stores a status transition together with a snapshot of the
+// talent detail at that moment. It runs in the transaction of the
+// transition, so neither is kept without the other.
+func (s *TalentService) recordHistory(ctx context.Context, talent *domain.Talent, action domain.TalentHistoryAction, fromStatus *domain.TalentStatus, actorID *uuid.UUID, reason *string) error {
+	entry := &domain.TalentStatusHistory{
+		ID:         uuid.New(),
+		TalentID:   talent.ID,
+		Action:     action,
+		FromStatus: fromStatus,
+		ToStatus:   talent.Status,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+
+	entry.DetailSnapshot = s.detailSnapshot(ctx, talent)
+	entry.BatchID = batchIDFrom(ctx)
+
+	return s.historyRepo.Create(ctx, entry)
+}
+
+func (s *TalentService) ListVersions(ctx context.Context, talentID uuid.UUID) ([]domain.TalentVersion, error) {
+	return s.versionRepo.ListByTalentID(ctx, talentID)
+}
+
+// GetVersion resolves a version reference: a version number, "latest" or
+// "approved" (the most recently approved version).
+func (s *TalentService) GetVersion(ctx context.Context, talentID uuid.UUID, ref string) (*domain.TalentVersion, error) {
+	var version *domain.TalentVersion
+	var err error
+
+	switch ref {
+	case "latest":
+		version, err = s.versionRepo.GetLatest(ctx, talentID)
+	case "approved":
+		version, err = s.versionRepo.GetLastApproved(ctx, talentID)
+	default:
+		number, convErr := strconv.Atoi(ref)
+		if convErr != nil {
+			return nil, ErrVersionNotFound
+		}
+		version, err = s.versionRepo.GetByVersion(ctx, talentID, number)
+	}
+
+	if err != nil {
 		return nil, err
 	}
+	if version == nil {
+		return nil, ErrVersionNotFound
+	}
+	return version, nil
+}
 
-	// Create notification
-	message := "Talenta Anda ditolak. Alasan: " + reason
-	s.createNotification(ctx, talent.UserID, talent.ID, domain.NotificationTalentRejected, message)
+func (s *TalentService) DiffVersions(ctx context.Context, talentID uuid.UUID, fromRef, toRef string) (*domain.TalentVersionDiff, error) {
+	from, err := s.GetVersion(ctx, talentID, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetVersion(ctx, talentID, toRef)
+	if err != nil {
+		return nil, err
+	}
 
-	return talent, nil
+	return &domain.TalentVersionDiff{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Changes:     diffDetails(from.Detail, to.Detail),
+	}, nil
 }
 
-func (s *TalentService) BatchApprove(ctx context.Context, ids []uuid.UUID, verifierID uuid.UUID) (*domain.BatchResult, error) {
-	result := &domain.BatchResult{
-		FailedIDs: []domain.FailedItem{},
+// changesSinceApproval returns what changed in each talent since it was
+// last approved, keyed by talent. Talents never approved or unchanged since
+// are left out.
+func (s *TalentService) changesSinceApproval(ctx context.Context, talentIDs []uuid.UUID) (map[uuid.UUID]*domain.TalentVersionDiff, error) {
+	approved, err := s.versionRepo.ListLastApproved(ctx, talentIDs)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.versionRepo.ListLatest(ctx, talentIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, id := range ids {
-		_, err := s.Approve(ctx, id, verifierID)
-		if err != nil {
-			result.FailedCount++
-			result.FailedIDs = append(result.FailedIDs, domain.FailedItem{
-				ID:     id,
-				Reason: err.Error(),
-			})
-		} else {
-			result.SuccessCount++
+	changes := make(map[uuid.UUID]*domain.TalentVersionDiff)
+	for id, from := range approved {
+		to := latest[id]
+		if to == nil || to.Version == from.Version {
+			continue
+		}
+		changes[id] = &domain.TalentVersionDiff{
+			FromVersion: from.Version,
+			ToVersion:   to.Version,
+			Changes:     diffDetails(from.Detail, to.Detail),
 		}
 	}
+	return changes, nil
+}
 
-	return result, nil
+func (s *TalentService) createVersion(ctx context.Context, talent *domain.Talent, createdBy *uuid.UUID) error {
+	detail := s.detailSnapshot(ctx, talent)
+	if detail == nil {
+		detail = json.RawMessage("{}")
+	}
+
+	return s.versionRepo.Create(ctx, &domain.TalentVersion{
+		ID:        uuid.New(),
+		TalentID:  talent.ID,
+		Detail:    detail,
+		CreatedBy: createdBy,
+	})
 }
 
-func (s *TalentService) BatchReject(ctx context.Context, ids []uuid.UUID, verifierID uuid.UUID, reason string) (*domain.BatchResult, error) {
-	result := &domain.BatchResult{
-		FailedIDs: []domain.FailedItem{},
+// diffDetails compares two detail snapshots field by field, skipping the row
+// identifiers of older snapshots. Attachment changes are reported as the attachments field.
+func diffDetails(oldDetail, newDetail json.RawMessage) []domain.FieldChange {
+	var before, after map[string]interface{}
+	json.Unmarshal(oldDetail, &before)
+	json.Unmarshal(newDetail, &after)
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
 	}
+	for key := range after {
+		keys[key] = true
+	}
+	delete(keys, "id")
+	delete(keys, "talent_id")
 
-	for _, id := range ids {
-		_, err := s.Reject(ctx, id, verifierID, reason)
-		if err != nil {
-			result.FailedCount++
-			result.FailedIDs = append(result.FailedIDs, domain.FailedItem{
-				ID:     id,
-				Reason: err.Error(),
-			})
-		} else {
-			result.SuccessCount++
+	var fields []string
+	for key := range keys {
+		fields = append(fields, key)
+	}
+	sort.Strings(fields)
+
+	changes := []domain.FieldChange{}
+	for _, key := range fields {
+		if reflect.DeepEqual(before[key], after[key]) {
+			continue
 		}
+		changes = append(changes, domain.FieldChange{Field: key, Old: before[key], New: after[key]})
+	}
+	return changes
+}
+
+// detailSnapshot returns the current talent detail together with its
+// attachments as JSON, or nil when the detail cannot be loaded.
+func (s *TalentService) detailSnapshot(ctx context.Context, talent *domain.Talent) json.RawMessage {
+	detail, err := s.GetDetail(ctx, talent)
+	if err != nil || detail == nil {
+		return nil
 	}
 
-	return result, nil
+	detailBytes, _ := json.Marshal(detail)
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(detailBytes, &snapshot); err != nil || snapshot == nil {
+		return nil
+	}
+
+	attachments, err := s.attachmentRepo.ListByTalentID(ctx, talent.ID)
+	if err != nil {
+		return nil
+	}
+	files := []map[string]interface{}{}
+	for _, a := range attachments {
+		files = append(files, map[string]interface{}{
+			"kind":     a.Kind,
+			"caption":  a.Caption,
+			"file_url": a.FileURL,
+		})
+	}
+	snapshot[domain.ReviewFieldAttachments] = files
+
+	result, _ := json.Marshal(snapshot)
+	return result
 }
 
 func (s *TalentService) createNotification(ctx context.Context, userID uuid.UUID, talentID uuid.UUID, notifType domain.NotificationType, message string) {
 	notification := &domain.Notification{
-		ID:       uuid.New(),
 		UserID:   userID,
 		TalentID: &talentID,
 		Type:     notifType,
 		Message:  message,
-		IsRead:   false,
 	}
-	s.notificationRepo.Create(ctx, notification)
+	if err := s.notifications.Notify(ctx, notification); err != nil {
+		log.Printf("failed to notify %s (%s) about talent %s: %v", userID, notifType, talentID, err)
+	}
 }
 
 // Statistics
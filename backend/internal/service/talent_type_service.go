@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/jsonschema"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+var (
+	ErrTalentTypeNotFound  = errors.New("talent type not found")
+	ErrDuplicateTalentType = errors.New("talent type already exists")
+)
+
+var talentTypeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// TalentTypeService manages the talent type registry. Changing a schema only
+// affects talents created or edited afterwards; stored details are not
+// revalidated.
+type TalentTypeService struct {
+	talentTypeRepo *repository.TalentTypeRepository
+}
+
+func NewTalentTypeService(talentTypeRepo *repository.TalentTypeRepository) *TalentTypeService {
+	return &TalentTypeService{talentTypeRepo: talentTypeRepo}
+}
+
+func (s *TalentTypeService) List(ctx context.Context, activeOnly bool) ([]domain.TalentTypeDefinition, error) {
+	return s.talentTypeRepo.List(ctx, activeOnly)
+}
+
+func (s *TalentTypeService) GetByCode(ctx context.Context, code domain.TalentType) (*domain.TalentTypeDefinition, error) {
+	def, err := s.talentTypeRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return nil, ErrTalentTypeNotFound
+	}
+	return def, nil
+}
+
+func (s *TalentTypeService) Create(ctx context.Context, req domain.CreateTalentTypeRequest) (*domain.TalentTypeDefinition, error) {
+	def := &domain.TalentTypeDefinition{
+		Code:                    req.Code,
+		Name:                    strings.TrimSpace(req.Name),
+		Description:             req.Description,
+		DetailSchema:            req.DetailSchema,
+		MatchNameField:          emptyToNil(req.MatchNameField),
+		MatchOrganizerField:     emptyToNil(req.MatchOrganizerField),
+		MatchDateField:          emptyToNil(req.MatchDateField),
+		CatalogCompetitionField: emptyToNil(req.CatalogCompetitionField),
+		IsActive:                true,
+	}
+
+	var errs ValidationErrors
+	if !talentTypeCodePattern.MatchString(string(def.Code)) {
+		errs.add("code", "Kode hanya boleh huruf kecil, angka, dan garis bawah, diawali huruf")
+	}
+	errs = append(errs, validateTalentType(def)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	existing, err := s.talentTypeRepo.GetByCode(ctx, def.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDuplicateTalentType
+	}
+
+	if err := s.talentTypeRepo.Create(ctx, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Update changes a talent type. An empty match field clears it; deactivating
+// a type stops new submissions while existing talents keep working.
+func (s *TalentTypeService) Update(ctx context.Context, code domain.TalentType, req domain.UpdateTalentTypeRequest) (*domain.TalentTypeDefinition, error) {
+	def, err := s.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		def.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		def.Description = emptyToNil(req.Description)
+	}
+	if len(req.DetailSchema) > 0 {
+		def.DetailSchema = req.DetailSchema
+	}
+	if req.MatchNameField != nil {
+		def.MatchNameField = emptyToNil(req.MatchNameField)
+	}
+	if req.MatchOrganizerField != nil {
+		def.MatchOrganizerField = emptyToNil(req.MatchOrganizerField)
+	}
+	if req.MatchDateField != nil {
+		def.MatchDateField = emptyToNil(req.MatchDateField)
+	}
+	if req.CatalogCompetitionField != nil {
+		def.CatalogCompetitionField = emptyToNil(req.CatalogCompetitionField)
+	}
+	if req.IsActive != nil {
+		def.IsActive = *req.IsActive
+	}
+
+	if errs := validateTalentType(def); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := s.talentTypeRepo.Update(ctx, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// validateTalentType checks the name, that the schema can be enforced and
+// that every match and catalog field is a declared property of the right
+// kind.
+func validateTalentType(def *domain.TalentTypeDefinition) ValidationErrors {
+	var errs ValidationErrors
+	if def.Name == "" {
+		errs.add("name", "Nama wajib diisi")
+	}
+
+	if len(def.DetailSchema) == 0 {
+		errs.add("detail_schema", "Skema detail wajib diisi")
+		return errs
+	}
+	schema, err := jsonschema.Parse(def.DetailSchema)
+	if err != nil {
+		errs.add("detail_schema", "Skema detail tidak valid: "+err.Error())
+		return errs
+	}
+
+	if _, ok := schema.Properties[domain.ReviewFieldAttachments]; ok {
+		errs.add("detail_schema", "Properti attachments dipakai untuk lampiran dan tidak boleh dideklarasikan")
+	}
+
+	checkMatchField(&errs, schema, "match_name_field", def.MatchNameField, "")
+	checkMatchField(&errs, schema, "match_organizer_field", def.MatchOrganizerField, "")
+	checkMatchField(&errs, schema, "match_date_field", def.MatchDateField, "date")
+	checkMatchField(&errs, schema, "catalog_competition_field", def.CatalogCompetitionField, "")
+	if def.MatchNameField == nil && (def.MatchOrganizerField != nil || def.MatchDateField != nil) {
+		errs.add("match_name_field", "Wajib diisi jika field pencocokan lain diisi")
+	}
+	return errs
+}
+
+func checkMatchField(errs *ValidationErrors, schema *jsonschema.Schema, name string, field *string, format string) {
+	if field == nil {
+		return
+	}
+	prop, ok := schema.Properties[*field]
+	switch {
+	case !ok:
+		errs.add(name, "Field tidak ada di skema detail")
+	case prop.Type != "string" || prop.Format != format:
+		if format == "date" {
+			errs.add(name, "Field harus bertipe string dengan format date")
+		} else {
+			errs.add(name, "Field harus bertipe string")
+		}
+	}
+}
+
+// reviewFields returns the fields verifiers can comment on for a talent type:
+// the schema properties and the attachments.
+func reviewFields(def *domain.TalentTypeDefinition) []string {
+	schema, err := jsonschema.Parse(def.DetailSchema)
+	if err != nil {
+		return []string{domain.ReviewFieldAttachments}
+	}
+	return append(schema.PropertyNames(), domain.ReviewFieldAttachments)
+}
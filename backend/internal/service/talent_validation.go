@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/jsonschema"
+)
+
+// ValidationErrors carries field errors back to the handler, which renders
+// them as a VALIDATION_ERROR response.
+type ValidationErrors []domain.FieldError
+
+func (e ValidationErrors) Error() string {
+	return "validation failed"
+}
+
+func (e *ValidationErrors) add(field, message string) {
+	*e = append(*e, domain.FieldError{Field: field, Message: message})
+}
+
+// parseTalentDetail validates a request detail against the schema of the
+// talent type and returns the detail to store, without fields the schema
+// does not declare. Nothing is returned unless the whole detail is valid.
+// A draft may leave out required fields; the fields it does carry must still
+// be valid. Submitting the draft checks the detail again in full. Dates that
+// may not lie in the future are compared with the calendar date of now.
+func parseTalentDetail(def *domain.TalentTypeDefinition, detail interface{}, draft bool, now time.Time) (json.RawMessage, ValidationErrors) {
+	var errs ValidationErrors
+
+	if detail == nil && draft {
+		detail = map[string]interface{}{}
+	}
+	if _, ok := detail.(map[string]interface{}); !ok {
+		if detail == nil {
+			errs.add("detail", "Detail wajib diisi")
+		} else {
+			errs.add("detail", "Format detail tidak valid")
+		}
+		return nil, errs
+	}
+
+	schema, err := jsonschema.Parse(def.DetailSchema)
+	if err != nil {
+		// Schemas are checked when saved, so this is a broken registry row
+		log.Printf("invalid detail schema of talent type %s: %v", def.Code, err)
+		errs.add("talent_type", "Jenis talenta tidak valid")
+		return nil, errs
+	}
+
+	detail = schema.Prune(detail)
+	for _, e := range schema.Validate(detail, now) {
+		if draft && e.Keyword == "required" {
+			continue
+		}
+		field := "detail"
+		if e.Path != "" {
+			field += "." + e.Path
+		}
+		errs.add(field, e.Message)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// Both dates passed the date format check, so they compare as strings
+	fields, _ := detail.(map[string]interface{})
+	issued, _ := fields["certificate_issued_date"].(string)
+	expiry, _ := fields["certificate_expiry_date"].(string)
+	if issued != "" && expiry != "" && expiry <= issued {
+		errs.add("detail.certificate_expiry_date", "Masa berlaku sertifikat harus setelah tanggal terbit")
+		return nil, errs
+	}
+
+	detailBytes, err := json.Marshal(detail)
+	if err != nil {
+		errs.add("detail", "Format detail tidak valid")
+		return nil, errs
+	}
+	return detailBytes, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"sync"
 	"time"
 
@@ -24,6 +25,9 @@ type UploadInfo struct {
 	Filename    string
 	ContentType string
 	UploadType  string
+	FileSize    int64
+	FileHash    string
+	Confirmed   bool
 	ExpiresAt   time.Time
 }
 
@@ -52,8 +56,23 @@ var uploadTypeConfig = map[string]struct {
 		MaxSize:      10 * 1024 * 1024, // 10MB
 		AllowedTypes: []string{"application/pdf", "image/jpeg", "image/png"},
 	},
+	"talent_attachment": {
+		MaxSize:      10 * 1024 * 1024, // 10MB
+		AllowedTypes: []string{"application/pdf", "image/jpeg", "image/png", "image/webp"},
+	},
+	"announcement_attachment": {
+		MaxSize: 10 * 1024 * 1024, // 10MB
+		AllowedTypes: []string{
+			"application/pdf", "image/jpeg", "image/png", "image/webp",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+	},
 }
 
+// confirmedUploadTTL is how long a confirmed upload can still be attached.
+const confirmedUploadTTL = 24 * time.Hour
+
 func (s *UploadService) GeneratePresignedURL(ctx context.Context, userID uuid.UUID, req domain.PresignRequest) (*domain.PresignResponse, error) {
 	config, ok := uploadTypeConfig[req.UploadType]
 	if !ok {
@@ -135,9 +154,22 @@ func (s *UploadService) ConfirmUpload(ctx context.Context, uploadID uuid.UUID, u
 		return nil, err
 	}
 
-	// Remove from pending uploads
+	// Talent files are hashed so a certificate reused by another GTK can be
+	// spotted; a failed hash only disables that check
+	var fileHash string
+	if info.UploadType == "talent_certificate" || info.UploadType == "talent_attachment" {
+		fileHash, err = s.storage.HashObject(ctx, info.ObjectName)
+		if err != nil {
+			log.Printf("failed to hash upload %s: %v", uploadID, err)
+		}
+	}
+
+	// Keep the confirmed upload so it can be attached to a talent
 	s.mu.Lock()
-	delete(s.uploads, uploadID)
+	info.Confirmed = true
+	info.FileSize = objInfo.Size
+	info.FileHash = fileHash
+	info.ExpiresAt = time.Now().Add(confirmedUploadTTL)
 	s.mu.Unlock()
 
 	return &domain.ConfirmUploadResponse{
@@ -175,6 +207,29 @@ func (s *UploadService) GetUploadInfo(uploadID uuid.UUID) (*UploadInfo, bool) {
 	return info, ok
 }
 
+// GetConfirmedUpload returns an upload of the user that has been confirmed
+// and not yet attached.
+func (s *UploadService) GetConfirmedUpload(uploadID uuid.UUID, userID uuid.UUID) (*UploadInfo, error) {
+	s.mu.RLock()
+	info, ok := s.uploads[uploadID]
+	s.mu.RUnlock()
+
+	if !ok || info.UserID != userID || time.Now().After(info.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	if !info.Confirmed {
+		return nil, ErrFileNotUploaded
+	}
+	return info, nil
+}
+
+// Release forgets an upload once its file has been attached.
+func (s *UploadService) Release(uploadID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.uploads, uploadID)
+	s.mu.Unlock()
+}
+
 func (s *UploadService) GetFileURL(objectName string) string {
 	return s.storage.GetObjectURL(objectName)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,18 +21,33 @@ var (
 )
 
 type UserService struct {
-	userRepo   *repository.UserRepository
-	schoolRepo *repository.SchoolRepository
+	userRepo            *repository.UserRepository
+	schoolRepo          *repository.SchoolRepository
+	verificationService *EmailVerificationService
+	securityService     *SecurityService
+	txManager           *repository.TxManager
+	outbox              *OutboxService
 }
 
-func NewUserService(userRepo *repository.UserRepository, schoolRepo *repository.SchoolRepository) *UserService {
+func NewUserService(
+	userRepo *repository.UserRepository,
+	schoolRepo *repository.SchoolRepository,
+	verificationService *EmailVerificationService,
+	securityService *SecurityService,
+	txManager *repository.TxManager,
+	outbox *OutboxService,
+) *UserService {
 	return &UserService{
-		userRepo:   userRepo,
-		schoolRepo: schoolRepo,
+		userRepo:            userRepo,
+		schoolRepo:          schoolRepo,
+		verificationService: verificationService,
+		securityService:     securityService,
+		txManager:           txManager,
+		outbox:              outbox,
 	}
 }
 
-func (s *UserService) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
+func (s *UserService) Create(ctx context.Context, req domain.CreateUserRequest, creatorID uuid.UUID) (*domain.User, error) {
 	// Check email
 	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
@@ -92,10 +108,22 @@ func (s *UserService) Create(ctx context.Context, req domain.CreateUserRequest)
 		IsActive:     true,
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.outbox.Publish(ctx, domain.EventUserCreated, user.ID, &creatorID, userEventPayload(user))
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	// Account creation should not fail because the mail server is down;
+	// the user can request a new link later.
+	if err := s.verificationService.SendVerification(ctx, user); err != nil {
+		log.Printf("failed to send verification email to %s: %v", user.Email, err)
+	}
+
 	return user, nil
 }
 
@@ -110,7 +138,15 @@ func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User,
 	return user, nil
 }
 
-func (s *UserService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error) {
+// Update changes a user. A role change is reported to the user with a
+// security alert once it is saved.
+func (s *UserService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest, actorID uuid.UUID, meta domain.RequestMeta) (*domain.User, error) {
+	if req.Role != nil && !req.Role.IsValid() {
+		var errs ValidationErrors
+		errs.add("role", "Role harus super_admin, admin_sekolah atau gtk")
+		return nil, errs
+	}
+
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -119,6 +155,10 @@ func (s *UserService) Update(ctx context.Context, id uuid.UUID, req domain.Updat
 		return nil, ErrUserNotFound
 	}
 
+	if err := s.requestEmailChange(ctx, user, req.Email); err != nil {
+		return nil, err
+	}
+
 	if req.FullName != nil {
 		user.FullName = *req.FullName
 	}
@@ -147,10 +187,20 @@ func (s *UserService) Update(ctx context.Context, id uuid.UUID, req domain.Updat
 		user.SchoolID = req.SchoolID
 	}
 
+	previousRole := user.Role
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
+	if user.Role != previousRole {
+		detail := string(previousRole) + " → " + string(user.Role)
+		s.securityService.Record(ctx, user, domain.SecurityRoleChanged, &actorID, meta, detail)
+	}
+
 	return user, nil
 }
 
@@ -163,6 +213,10 @@ func (s *UserService) UpdateProfile(ctx context.Context, id uuid.UUID, req domai
 		return nil, ErrUserNotFound
 	}
 
+	if err := s.requestEmailChange(ctx, user, req.Email); err != nil {
+		return nil, err
+	}
+
 	if req.FullName != nil {
 		user.FullName = *req.FullName
 	}
@@ -186,7 +240,7 @@ func (s *UserService) UpdateProfile(ctx context.Context, id uuid.UUID, req domai
 	return user, nil
 }
 
-func (s *UserService) ChangePassword(ctx context.Context, id uuid.UUID, req domain.ChangePasswordRequest) error {
+func (s *UserService) ChangePassword(ctx context.Context, id uuid.UUID, req domain.ChangePasswordRequest, meta domain.RequestMeta) error {
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -204,7 +258,12 @@ func (s *UserService) ChangePassword(ctx context.Context, id uuid.UUID, req doma
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(ctx, id, newHash)
+	if err := s.userRepo.UpdatePassword(ctx, id, newHash); err != nil {
+		return err
+	}
+
+	s.securityService.Record(ctx, user, domain.SecurityPasswordChanged, &id, meta, "")
+	return nil
 }
 
 func (s *UserService) Delete(ctx context.Context, id uuid.UUID, currentUserID uuid.UUID) error {
@@ -223,7 +282,15 @@ func (s *UserService) Delete(ctx context.Context, id uuid.UUID, currentUserID uu
 	return s.userRepo.Delete(ctx, id)
 }
 
-func (s *UserService) Activate(ctx context.Context, id uuid.UUID) error {
+func (s *UserService) Activate(ctx context.Context, id uuid.UUID, actorID uuid.UUID, meta domain.RequestMeta) error {
+	return s.setActive(ctx, id, true, actorID, meta)
+}
+
+func (s *UserService) Deactivate(ctx context.Context, id uuid.UUID, actorID uuid.UUID, meta domain.RequestMeta) error {
+	return s.setActive(ctx, id, false, actorID, meta)
+}
+
+func (s *UserService) setActive(ctx context.Context, id uuid.UUID, active bool, actorID uuid.UUID, meta domain.RequestMeta) error {
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -232,27 +299,53 @@ func (s *UserService) Activate(ctx context.Context, id uuid.UUID) error {
 		return ErrUserNotFound
 	}
 
-	user.IsActive = true
-	return s.userRepo.Update(ctx, user)
-}
+	if user.IsActive == active {
+		return nil
+	}
 
-func (s *UserService) Deactivate(ctx context.Context, id uuid.UUID) error {
-	user, err := s.userRepo.GetByID(ctx, id)
-	if err != nil {
+	user.IsActive = active
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
-	if user == nil {
-		return ErrUserNotFound
-	}
 
-	user.IsActive = false
-	return s.userRepo.Update(ctx, user)
+	eventType := domain.SecurityAccountDeactivated
+	if active {
+		eventType = domain.SecurityAccountReactivated
+	}
+	s.securityService.Record(ctx, user, eventType, &actorID, meta, "")
+	return nil
 }
 
 func (s *UserService) List(ctx context.Context, params domain.ListParams) ([]domain.User, int, error) {
 	return s.userRepo.List(ctx, params)
 }
 
+// GetPendingEmail returns the new address awaiting confirmation, if any.
+func (s *UserService) GetPendingEmail(ctx context.Context, id uuid.UUID) (*string, error) {
+	return s.verificationService.GetPendingEmail(ctx, id)
+}
+
+func (s *UserService) ConfirmEmail(ctx context.Context, token string) (*domain.EmailVerification, error) {
+	return s.verificationService.Confirm(ctx, token)
+}
+
+func (s *UserService) ResendVerification(ctx context.Context, id uuid.UUID) error {
+	return s.verificationService.ResendVerification(ctx, id)
+}
+
+func (s *UserService) CancelEmailChange(ctx context.Context, id uuid.UUID) error {
+	return s.verificationService.CancelEmailChange(ctx, id)
+}
+
+// requestEmailChange never writes users.email; the new address only takes
+// effect once its confirmation link is used.
+func (s *UserService) requestEmailChange(ctx context.Context, user *domain.User, email *string) error {
+	if email == nil || *email == "" || *email == user.Email {
+		return nil
+	}
+	return s.verificationService.RequestEmailChange(ctx, user, *email)
+}
+
 func (s *UserService) GetSchool(ctx context.Context, schoolID uuid.UUID) (*domain.School, error) {
 	return s.schoolRepo.GetByID(ctx, schoolID)
 }
@@ -269,3 +362,13 @@ func (s *UserService) UpdatePhoto(ctx context.Context, id uuid.UUID, photoURL st
 	user.PhotoURL = &photoURL
 	return s.userRepo.Update(ctx, user)
 }
+
+func userEventPayload(user *domain.User) domain.UserEventPayload {
+	return domain.UserEventPayload{
+		UserID:   user.ID,
+		Email:    user.Email,
+		FullName: user.FullName,
+		Role:     user.Role,
+		SchoolID: user.SchoolID,
+	}
+}
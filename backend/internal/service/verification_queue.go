@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipodi/backend/internal/domain"
+	"github.com/sipodi/backend/internal/repository"
+)
+
+// Verification queue: claims, assignments and SLA tracking
+
+// GetQueuePageDetails loads what the verification queue shows next to the
+// given talents with one query per relation, whatever the page size.
+func (s *TalentService) GetQueuePageDetails(ctx context.Context, talentIDs []uuid.UUID) (*domain.QueuePageDetails, error) {
+	details := &domain.QueuePageDetails{}
+	var err error
+
+	if details.Attachments, err = s.attachmentRepo.ListByTalentIDs(ctx, talentIDs); err != nil {
+		return nil, err
+	}
+	if details.Entries, err = s.queueRepo.ListByTalentIDs(ctx, talentIDs); err != nil {
+		return nil, err
+	}
+	if details.Duplicates, err = s.duplicateRepo.ListByTalentIDs(ctx, talentIDs); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var assigneeIDs []uuid.UUID
+	for _, entry := range details.Entries {
+		if id := entry.ActiveAssignee(now); id != nil {
+			assigneeIDs = append(assigneeIDs, *id)
+		}
+	}
+	if details.Assignees, err = s.userRepo.ListByIDs(ctx, assigneeIDs); err != nil {
+		return nil, err
+	}
+
+	if details.Changes, err = s.changesSinceApproval(ctx, talentIDs); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// SLAStatus reports how long the talent has been waiting in its current
+// queue compared with the configured thresholds. A threshold of 0 disables it.
+func (s *TalentService) SLAStatus(talent *domain.Talent) domain.SLAStatus {
+	age := time.Since(talent.QueuedAt)
+	switch {
+	case s.slaEscalateDays > 0 && age >= days(s.slaEscalateDays):
+		return domain.SLAStatusOverdue
+	case s.slaWarningDays > 0 && age >= days(s.slaWarningDays):
+		return domain.SLAStatusWarning
+	default:
+		return domain.SLAStatusOK
+	}
+}
+
+// Claim locks a talent for the verifier for the configured claim TTL so
+// nobody else reviews it at the same time. Claiming again extends the lock.
+func (s *TalentService) Claim(ctx context.Context, id uuid.UUID, verifierID uuid.UUID, verifierRole domain.UserRole) (*domain.QueueEntry, error) {
+	talent, err := s.talentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if talent == nil {
+		return nil, ErrTalentNotFound
+	}
+	if err := s.checkCanVerify(talent, verifierRole); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.queueRepo.Claim(ctx, talent.ID, verifierID, time.Now().Add(s.claimTTL))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrClaimedByOther
+	}
+	return entry, nil
+}
+
+// ReleaseClaim gives a talent back to the shared queue. Only the current
+// holder or a super_admin may release it.
+func (s *TalentService) ReleaseClaim(ctx context.Context, id uuid.UUID, userID uuid.UUID, role domain.UserRole) error {
+	talent, err := s.talentRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if talent == nil {
+		return ErrTalentNotFound
+	}
+
+	entry, err := s.queueRepo.GetByTalentID(ctx, talent.ID)
+	if err != nil {
+		return err
+	}
+	holder := entry.ActiveAssignee(time.Now())
+	if holder == nil {
+		return nil
+	}
+	if *holder != userID && role != domain.RoleSuperAdmin {
+		return ErrClaimedByOther
+	}
+	return s.queueRepo.Release(ctx, talent.ID)
+}
+
+// Assign hands a talent to a specific verifier without an expiry. Pending
+// talents can go to a super_admin or an admin_sekolah of the owner's school;
+// talents awaiting endorsement only to a super_admin.
+func (s *TalentService) Assign(ctx context.Context, id uuid.UUID, assigneeID uuid.UUID, assignerID uuid.UUID) (*domain.QueueEntry, error) {
+	talent, err := s.talentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if talent == nil {
+		return nil, ErrTalentNotFound
+	}
+	if err := s.checkCanVerify(talent, domain.RoleSuperAdmin); err != nil {
+		return nil, err
+	}
+
+	assignee, err := s.userRepo.GetByID(ctx, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	if assignee == nil || !assignee.IsActive {
+		return nil, ErrInvalidAssignee
+	}
+	switch assignee.Role {
+	case domain.RoleSuperAdmin:
+	case domain.RoleAdminSekolah:
+		if talent.Status != domain.TalentStatusPending {
+			return nil, ErrInvalidAssignee
+		}
+		owner, err := s.userRepo.GetByID(ctx, talent.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if owner == nil || owner.SchoolID == nil || assignee.SchoolID == nil || *owner.SchoolID != *assignee.SchoolID {
+			return nil, ErrInvalidAssignee
+		}
+	default:
+		return nil, ErrInvalidAssignee
+	}
+
+	entry, err := s.queueRepo.Assign(ctx, talent.ID, assigneeID, assignerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if assigneeID != assignerID {
+		s.createNotification(ctx, assigneeID, talent.ID, domain.NotificationVerificationAssigned,
+			"Anda ditugaskan untuk memverifikasi talenta")
+	}
+	return entry, nil
+}
+
+// checkClaim rejects a verification by anyone other than the verifier
+// currently holding the talent.
+func (s *TalentService) checkClaim(ctx context.Context, talentID uuid.UUID, verifierID uuid.UUID) error {
+	entry, err := s.queueRepo.GetByTalentID(ctx, talentID)
+	if err != nil {
+		return err
+	}
+	if holder := entry.ActiveAssignee(time.Now()); holder != nil && *holder != verifierID {
+		return ErrClaimedByOther
+	}
+	return nil
+}
+
+// CheckSLA reminds verifiers of talents waiting past the warning threshold
+// and escalates talents waiting past the escalation threshold to every
+// super_admin. Each notice is sent once per queue visit.
+func (s *TalentService) CheckSLA(ctx context.Context) error {
+	now := time.Now()
+
+	if s.slaWarningDays > 0 {
+		talents, err := s.queueRepo.ListUnreminded(ctx, now.Add(-days(s.slaWarningDays)))
+		if err != nil {
+			return err
+		}
+		verifiers := s.newQueueVerifiers()
+		message := "Talenta sudah menunggu verifikasi lebih dari " + strconv.Itoa(s.slaWarningDays) + " hari"
+		for _, talent := range talents {
+			recipients, err := verifiers.recipients(ctx, talent)
+			if err != nil {
+				return err
+			}
+			for _, id := range recipients {
+				s.createNotification(ctx, id, talent.TalentID, domain.NotificationVerificationReminder, message)
+			}
+			if err := s.queueRepo.MarkReminded(ctx, talent.TalentID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.slaEscalateDays > 0 {
+		talents, err := s.queueRepo.ListUnescalated(ctx, now.Add(-days(s.slaEscalateDays)))
+		if err != nil {
+			return err
+		}
+		if len(talents) == 0 {
+			return nil
+		}
+		superAdmins, err := s.userRepo.ListActiveIDsByRole(ctx, domain.RoleSuperAdmin)
+		if err != nil {
+			return err
+		}
+		message := "Talenta belum diverifikasi lebih dari " + strconv.Itoa(s.slaEscalateDays) + " hari dan dieskalasi ke dinas"
+		for _, talent := range talents {
+			for _, id := range superAdmins {
+				s.createNotification(ctx, id, talent.TalentID, domain.NotificationVerificationEscalated, message)
+			}
+			if err := s.queueRepo.MarkEscalated(ctx, talent.TalentID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pendingSummary is what one verifier's weekly digest reports.
+type pendingSummary struct {
+	total   int
+	overdue int
+	oldest  time.Time
+}
+
+// SendPendingDigests sends every verifier a summary of the talents waiting
+// in their queue, once a week on the configured day at the digest hour. The
+// job can run any number of times: each verifier gets the digest of a week
+// once, and a digest missed while the server was down goes out on the next
+// run.
+func (s *TalentService) SendPendingDigests(ctx context.Context) error {
+	if s.pendingDigestDay < 0 {
+		return nil
+	}
+	now := time.Now()
+	week := latestAt(now, s.location, s.pendingDigestDay, s.digestHour)
+	key := string(domain.NotificationPendingDigest) + ":" + week.Format("2006-01-02")
+
+	// Verifiers who already got this week's digest are left out up front,
+	// so the runs after the first one of a week do almost no work.
+	sent, err := s.notifications.ClaimedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	talents, err := s.queueRepo.ListWaiting(ctx)
+	if err != nil {
+		return err
+	}
+
+	verifiers := s.newQueueVerifiers()
+	summaries := make(map[uuid.UUID]*pendingSummary)
+	var recipients []uuid.UUID
+	for _, talent := range talents {
+		ids, err := verifiers.recipients(ctx, talent)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if sent[id] {
+				continue
+			}
+			summary, ok := summaries[id]
+			if !ok {
+				summary = &pendingSummary{oldest: talent.QueuedAt}
+				summaries[id] = summary
+				recipients = append(recipients, id)
+			}
+			summary.total++
+			if s.slaWarningDays > 0 && now.Sub(talent.QueuedAt) >= days(s.slaWarningDays) {
+				summary.overdue++
+			}
+			if talent.QueuedAt.Before(summary.oldest) {
+				summary.oldest = talent.QueuedAt
+			}
+		}
+	}
+
+	for _, id := range recipients {
+		summary := summaries[id]
+		message := fmt.Sprintf("Ada %d talenta menunggu verifikasi Anda", summary.total)
+		if summary.overdue > 0 {
+			message += fmt.Sprintf(", %d di antaranya sudah lebih dari %d hari", summary.overdue, s.slaWarningDays)
+		}
+		waited := int(now.Sub(summary.oldest) / days(1))
+
+		err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+			return s.notifications.NotifyOnce(ctx, key, &domain.Notification{
+				UserID:  id,
+				Type:    domain.NotificationPendingDigest,
+				Message: message,
+			}, fmt.Sprintf("Terlama menunggu: %d hari", waited))
+		})
+		if err != nil {
+			log.Printf("failed to send pending verification digest to %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// parseWeekday turns an English weekday name into a time.Weekday. "none"
+// and unknown names give -1, which disables the weekly digest.
+func parseWeekday(name string) time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return d
+		}
+	}
+	if !strings.EqualFold(name, "none") {
+		log.Printf("unknown weekday %q, pending verification digest disabled", name)
+	}
+	return -1
+}
+
+// queueVerifiers resolves who should act on waiting talents. It loads the
+// verifiers of each school and the super admins once, so a job walking the
+// whole queue makes one query per school rather than one per talent.
+type queueVerifiers struct {
+	userRepo    *repository.UserRepository
+	schools     map[uuid.UUID][]uuid.UUID
+	superAdmins []uuid.UUID
+	loaded      bool
+}
+
+func (s *TalentService) newQueueVerifiers() *queueVerifiers {
+	return &queueVerifiers{userRepo: s.userRepo, schools: make(map[uuid.UUID][]uuid.UUID)}
+}
+
+// recipients returns who should act on a waiting talent: its assignee,
+// otherwise the verifiers of its queue.
+func (v *queueVerifiers) recipients(ctx context.Context, talent domain.QueuedTalent) ([]uuid.UUID, error) {
+	if talent.AssigneeID != nil {
+		return []uuid.UUID{*talent.AssigneeID}, nil
+	}
+
+	if talent.Status == domain.TalentStatusPending && talent.SchoolID != nil {
+		ids, ok := v.schools[*talent.SchoolID]
+		if !ok {
+			var err error
+			ids, err = v.userRepo.ListActiveIDsBySchoolAndRole(ctx, *talent.SchoolID, domain.RoleAdminSekolah)
+			if err != nil {
+				return nil, fmt.Errorf("load verifiers of school %s: %w", *talent.SchoolID, err)
+			}
+			v.schools[*talent.SchoolID] = ids
+		}
+		if len(ids) > 0 {
+			return ids, nil
+		}
+	}
+
+	// Endorsements and schools without an admin fall to super_admin
+	if !v.loaded {
+		ids, err := v.userRepo.ListActiveIDsByRole(ctx, domain.RoleSuperAdmin)
+		if err != nil {
+			return nil, fmt.Errorf("load super admins: %w", err)
+		}
+		v.superAdmins, v.loaded = ids, true
+	}
+	return v.superAdmins, nil
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/url"
 	"path"
 	"time"
@@ -106,6 +109,21 @@ func (s *MinIOStorage) GetObjectInfo(ctx context.Context, objectName string) (mi
 	return s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
 }
 
+// HashObject returns the hex SHA-256 of an object's content.
+func (s *MinIOStorage) HashObject(ctx context.Context, objectName string) (string, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, object); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func (s *MinIOStorage) DeleteObject(ctx context.Context, objectName string) error {
 	return s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
 }